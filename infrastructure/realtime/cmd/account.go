@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wiger123/okex_v5_golang/config"
+	. "github.com/wiger123/okex_v5_golang/database"
+	"github.com/wiger123/okex_v5_golang/restapi"
+	"github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/client"
+)
+
+// 交易账户: 每个账户独立的私有频道客户端, REST 客户端和数据库
+type account struct {
+	// 账户名称
+	name string
+	// 私有频道客户端
+	client *OkxClient
+	// REST 客户端
+	rest *restapi.Client
+	// 数据库
+	repo *DataRepo
+}
+
+// 读取配置中的全部账户密钥, 创建账户
+func loadAccounts(env utils.Environment, clock *utils.Clock, instruments *Instruments) []*account {
+	// 账户列表
+	var accounts []*account
+	// 逐个账户
+	for _, name := range strings.Split(config.Accounts, ",") {
+		// 去除空格
+		name = strings.TrimSpace(name)
+		// 空名称
+		if name == "" {
+			// 跳过
+			continue
+		}
+		// 读取密钥
+		creds, err := loadCredentials(name)
+		// 读取失败
+		if err != nil {
+			// 错误提示
+			log.Fatalf("[错误提示] 账户 %v 密钥读取失败: %v", name, err)
+		}
+		// 成功提示
+		log.Printf("[成功提示] 账户 %v 密钥读取成功: %v", name, creds)
+		// 添加账户
+		accounts = append(accounts, &account{
+			// 账户名称
+			name: name,
+			// 私有频道客户端
+			client: dialClient(env.PrivateURL, append(recordOption("private-"+name), WithClock(clock), WithEnvironment(env), WithCredentials(creds), WithInstruments(instruments))...),
+			// REST 客户端
+			rest: restapi.NewClient(env.RestURL, restapi.WithClock(clock), restapi.WithEnvironment(env), restapi.WithCredentials(creds)),
+			// 数据库
+			repo: NewDataRepo(),
+		})
+	}
+	// 没有账户
+	if len(accounts) == 0 {
+		// 错误提示
+		log.Fatalf("[错误提示] 未配置账户")
+	}
+	// 返回
+	return accounts
+}
+
+// 读取账户密钥: 配置了密钥目录时从文件读取, 否则从环境变量读取
+func loadCredentials(name string) (utils.Credentials, error) {
+	// 密钥文件
+	if config.CredentialsDir != "" {
+		// 从文件读取
+		return utils.CredentialsFromFile(filepath.Join(config.CredentialsDir, name+".json"))
+	}
+	// 从环境变量读取
+	return utils.CredentialsFromEnv(name)
+}
+
+// 启动账户: 登录, 订阅私有频道, 初始化数据库
+func (a *account) start() {
+	// 私有频道错误处理
+	a.client.OnError(func(err error) {
+		// 按错误类别处理
+		handleClientError("私有频道 "+a.name, err)
+	})
+	// 私有频道断线: 断线期间的定时撤单可能丢失, 重连后撤销全部挂单
+	a.client.OnStateChange(func(s ConnState) {
+		// 连接断开
+		if s == StateDisconnected {
+			// 普通提示
+			log.Printf("[普通提示] 账户 %v 私有频道断开, 正在重连", a.name)
+		}
+		// 重连成功
+		if s == StateReconnected {
+			// 撤销全部挂单, 在新协程中执行, 不阻塞读取循环
+			go func() {
+				// 撤单
+				if err := a.client.CancelAllOrders(config.InstID, a.repo); err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 账户 %v 重连后撤销挂单失败: %v", a.name, err)
+				}
+			}()
+		}
+	})
+
+	// 私有频道数据解析并处理
+	go a.client.ReadWebsocketLoop()
+
+	// 登录超时
+	loginCtx, loginCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// 函数结束前释放超时
+	defer loginCancel()
+	// 私有频道登陆并等待确认
+	if err := a.client.LoginAndWait(loginCtx); err != nil {
+		// 错误提示
+		log.Fatalf("[错误提示] 账户 %v 私有频道登陆失败: %v", a.name, err)
+	}
+
+	// 账户配置, 按账户实际持仓模式决定下单的持仓方向
+	if cfgs, err := a.rest.GetAccountConfig(); err != nil || len(cfgs) == 0 {
+		// 错误提示
+		log.Printf("[错误提示] 账户 %v 账户配置获取失败, 按买卖模式下单: %v", a.name, err)
+	} else {
+		// 持仓模式
+		a.client.SetPosMode(PosMode(cfgs[0].PosMode))
+		// 成功提示
+		log.Printf("[成功提示] 账户 %v 持仓模式: %v", a.name, cfgs[0].PosMode)
+	}
+
+	// 用当前账户, 持仓和挂单初始化数据库; 需在订阅私有频道前完成, 之后的推送数据覆盖 REST 数据, 较旧的 REST 数据不会覆盖较新的推送
+	if err := restapi.BootstrapDataRepo(a.rest, a.repo, config.InstType, config.InstID); err != nil {
+		// 错误提示
+		log.Printf("[错误提示] 账户 %v 数据库初始化失败, 等待推送数据: %v", a.name, err)
+	}
+
+	// 私有频道添加订阅
+	// 账户频道
+	a.client.Subscribe("account", "", "", "", a.repo.HandleMessage)
+	// 持仓频道
+	a.client.Subscribe("positions", config.InstType, "", config.InstID, a.repo.HandleMessage)
+	// 订单频道
+	a.client.Subscribe("orders", config.InstType, "", config.InstID, a.repo.HandleMessage)
+
+	// 订阅超时
+	subscribeCtx, subscribeCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// 函数结束前释放超时
+	defer subscribeCancel()
+	// 私有频道订阅并等待确认
+	if err := a.client.SubscribeAndWait(subscribeCtx); err != nil {
+		// 错误提示
+		log.Fatalf("[错误提示] 账户 %v 私有频道订阅失败: %v", a.name, err)
+	}
+}
@@ -1,65 +1,265 @@
 package main
 
 import (
+	"context"
+	"log"
 	"time"
 
 	"github.com/wiger123/okex_v5_golang/config"
 	. "github.com/wiger123/okex_v5_golang/database"
+	"github.com/wiger123/okex_v5_golang/recorder"
+	"github.com/wiger123/okex_v5_golang/restapi"
 	. "github.com/wiger123/okex_v5_golang/strategy"
+	"github.com/wiger123/okex_v5_golang/utils"
 	. "github.com/wiger123/okex_v5_golang/wsdata/client"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
 )
 
 // 主函数
 func main() {
-	// 数据库初始化
-	dataRepo := NewDataRepo()
+	// 交易环境: 实盘, 模拟盘或自定义地址, 切换时无需修改策略
+	env, err := utils.NewEnvironment(config.Env)
+	// 未知环境
+	if err != nil {
+		// 错误提示
+		log.Fatalf("[错误提示] 交易环境配置错误: %v", err)
+	}
+	// 普通提示
+	log.Printf("[普通提示] 交易环境: %v, 模拟盘: %v", env.Name, env.Simulated)
+	// 交易所时钟, 各客户端共享
+	clock := utils.NewClock()
+	// 创建 REST 客户端: 公共接口
+	restClient := restapi.NewClient(env.RestURL, restapi.WithClock(clock), restapi.WithEnvironment(env))
+	// 登录前同步交易所时钟
+	if err := restClient.SyncClock(); err != nil {
+		// 错误提示
+		log.Printf("[错误提示] 交易所时钟同步失败, 使用本地时间: %v", err)
+	}
+	// 定时同步交易所时钟
+	go func() {
+		// 循环同步
+		for {
+			// 等待同步间隔
+			time.Sleep(config.ClockSyncInterval * time.Second)
+			// 同步时钟
+			if err := restClient.SyncClock(); err != nil {
+				// 错误提示
+				log.Printf("[错误提示] 交易所时钟同步失败: %v", err)
+			}
+		}
+	}()
 
-	// 创建 okx 客户端: 公共频道
-	publicClient, _ := NewOkxClient(config.PublicURL)
-	// 创建 okx 客户端: 私有频道
-	privateClient, _ := NewOkxClient(config.PrivateURL)
-	// 私有频道登陆
-	privateClient.Login()
+	// 产品信息缓存, 各账户共享, 下单前按精度取整和校验
+	instruments := NewInstruments()
+	// 用 REST 接口加载产品信息, 失败时等待 instruments 频道推送
+	if err := restapi.LoadInstruments(restClient, instruments, config.InstType, ""); err != nil {
+		// 错误提示
+		log.Printf("[错误提示] 产品信息加载失败, 等待频道推送: %v", err)
+	} else {
+		// 成功提示
+		log.Printf("[成功提示] 产品信息加载成功, 产品数目: %v", instruments.Len())
+	}
 
-	// 等待登陆成功
-	time.Sleep(5 * time.Second)
-
-	// 公共频道添加订阅
-	// 交易频道
-	publicClient.Subscribe("trades", "", "", config.InstID, dataRepo.HandleMessage)
-	// 盘口频道
-	publicClient.Subscribe("books5", "", "", config.InstID, dataRepo.HandleMessage)
-	// 私有频道添加订阅
-	// 账户频道
-	privateClient.Subscribe("account", "", "", "", dataRepo.HandleMessage)
-	// 持仓频道
-	privateClient.Subscribe("positions", config.InstType, "", config.InstID, dataRepo.HandleMessage)
-	// 订单频道
-	privateClient.Subscribe("orders", config.InstType, "", config.InstID, dataRepo.HandleMessage)
-
-	// 公共频道订阅
-	publicClient.Run()
-	// 私有频道订阅
-	privateClient.Run()
+	// 创建 okx 客户端: 公共频道, 各账户共享
+	publicClient := dialClient(env.PublicURL, append(recordOption("public"), WithClock(clock), WithEnvironment(env))...)
+	// 创建账户: 每个账户独立的私有频道客户端和数据库
+	accounts := loadAccounts(env, clock, instruments)
 
+	// 公共频道错误处理
+	publicClient.OnError(func(err error) {
+		// 按错误类别处理
+		handleClientError("公共频道", err)
+	})
 	// 公共频道数据解析并处理
 	go publicClient.ReadWebsocketLoop()
-	// 私有频道数据解析并处理
-	go privateClient.ReadWebsocketLoop()
 
-	// 等待
-	time.Sleep(3 * time.Second)
+	// 行情延迟统计: 推送时间戳到校正后本地时间
+	latency := utils.NewLatencyStats()
+	// 定时输出行情延迟
+	go func() {
+		// 循环输出
+		for {
+			// 等待输出间隔
+			time.Sleep(config.LatencyLogInterval * time.Second)
+			// 取出统计结果
+			avg, max, n := latency.Take()
+			// 有样本
+			if n > 0 {
+				// 普通提示
+				log.Printf("[普通提示] 行情延迟 平均: %v, 最大: %v, 样本数: %v, 时钟偏移: %v", avg, max, n, clock.Offset())
+			}
+		}
+	}()
+
+	// 行情数据分发到每个账户的数据库
+	marketHandler := func(m PushMessage) {
+		// 记录行情延迟
+		if d, ok := marketLatency(clock, m); ok {
+			// 记录
+			latency.Observe(d)
+		}
+		// 逐个账户
+		for _, a := range accounts {
+			// 处理数据
+			a.repo.HandleMessage(m)
+		}
+	}
+	// 公共频道添加订阅
+	// 产品信息频道: 精度变化时更新缓存
+	publicClient.Subscribe("instruments", config.InstType, "", "", instruments.HandleMessage)
+	// 交易频道
+	publicClient.Subscribe("trades", "", "", config.InstID, marketHandler)
+	// 盘口频道
+	publicClient.Subscribe("books5", "", "", config.InstID, marketHandler)
+	// 深度校验失败: 重新订阅该频道获取全量推送
+	for _, a := range accounts {
+		// 注册处理
+		a.repo.OnBookResync(func(channel, instId string) {
+			// 在新协程中执行, 不阻塞读取循环
+			go func() {
+				// 重新订阅
+				if err := publicClient.Resubscribe(channel, instId); err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 深度重新订阅失败: %v", err)
+				}
+			}()
+		})
+	}
+	// 公共频道断线: 行情数据不再连续, 清空后由策略等待重建
+	publicClient.OnStateChange(func(s ConnState) {
+		// 连接断开
+		if s == StateDisconnected {
+			// 逐个账户
+			for _, a := range accounts {
+				// 清空行情数据
+				a.repo.ResetMarketData()
+			}
+		}
+	})
+	// 订阅超时
+	subscribeCtx, subscribeCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// 公共频道订阅并等待确认
+	if err := publicClient.SubscribeAndWait(subscribeCtx); err != nil {
+		// 错误提示
+		log.Fatalf("[错误提示] 公共频道订阅失败: %v", err)
+	}
+	// 释放超时
+	subscribeCancel()
 
-	// 私有频道保持连接
-	go PingPong(privateClient, dataRepo)
-	// 执行策略
-	go PrintMoney(privateClient, dataRepo)
+	// 逐个账户
+	for _, a := range accounts {
+		// 登录, 订阅私有频道, 初始化数据库
+		a.start()
+		// 执行策略
+		go PrintMoney(a.client, a.repo)
+	}
 
 	// 等待
 	time.Sleep(19990726 * time.Second)
 
 	// 关闭公共频道客户端
 	publicClient.Shutdown()
-	// 关闭私有频道客户端
-	privateClient.Shutdown()
+	// 逐个账户
+	for _, a := range accounts {
+		// 关闭私有频道客户端
+		a.client.Shutdown()
+	}
+}
+
+// 行情推送中最新数据的延迟, 按交易所时钟校正
+func marketLatency(clock *utils.Clock, m PushMessage) (time.Duration, bool) {
+	// 按推送类型
+	switch msg := m.(type) {
+	// 交易数据
+	case *TradeMessage:
+		// 有数据
+		if len(msg.Data) > 0 {
+			// 最新成交延迟
+			return clock.Latency(msg.Data[len(msg.Data)-1].Ts)
+		}
+	// 盘口数据
+	case *Book5Message:
+		// 有数据
+		if len(msg.Data) > 0 {
+			// 最新盘口延迟
+			return clock.Latency(msg.Data[len(msg.Data)-1].Ts)
+		}
+	// 深度数据
+	case *BookMessage:
+		// 有数据
+		if len(msg.Data) > 0 {
+			// 最新深度延迟
+			return clock.Latency(msg.Data[len(msg.Data)-1].Ts)
+		}
+	}
+	// 没有时间戳
+	return 0, false
+}
+
+// 创建客户端, 连接失败时重试, 多次失败后退出
+func dialClient(url string, opts ...ClientOption) *OkxClient {
+	// 重试等待时间
+	delay := config.ReconnectMinDelay * time.Millisecond
+	// 逐次尝试
+	for attempt := 1; ; attempt++ {
+		// 创建客户端
+		c, err := NewOkxClient(url, opts...)
+		// 创建成功
+		if err == nil {
+			// 返回
+			return c
+		}
+		// 非连接错误或重试次数用尽
+		if !IsKind(err, KindConnection) || attempt >= config.DialAttempts {
+			// 错误提示
+			log.Fatalf("[错误提示] OKX 客户端创建失败: %v", err)
+		}
+		// 等待
+		time.Sleep(delay)
+		// 等待时间翻倍
+		delay *= 2
+	}
+}
+
+// 客户端错误处理: 鉴权失败退出, 其他错误记录后由策略继续运行
+func handleClientError(name string, err error) {
+	// 按错误类别处理
+	switch KindOf(err) {
+	// 鉴权失败, 重连无法恢复
+	case KindAuth:
+		// 错误提示
+		log.Fatalf("[错误提示] %v 鉴权失败, 程序退出: %v", name, err)
+	// 连接错误, 读取循环自动重连
+	case KindConnection:
+		// 普通提示
+		log.Printf("[普通提示] %v 连接异常, 等待重连: %v", name, err)
+	// 限频, 策略下一轮重试
+	case KindRateLimited:
+		// 普通提示
+		log.Printf("[普通提示] %v 请求被限频: %v", name, err)
+	// 其他错误
+	default:
+		// 错误提示
+		log.Printf("[错误提示] %v 请求失败: %v", name, err)
+	}
+}
+
+// 开启原始数据记录时返回记录选项
+func recordOption(prefix string) []ClientOption {
+	// 未开启记录
+	if config.RecordDir == "" {
+		// 返回
+		return nil
+	}
+	// 创建记录器
+	r, err := recorder.NewRecorder(config.RecordDir, prefix)
+	// 创建失败, 不影响交易
+	if err != nil {
+		// 错误提示
+		log.Printf("[错误提示] 原始数据记录器创建失败: %v", err)
+		// 返回
+		return nil
+	}
+	// 返回
+	return []ClientOption{WithRecorder(r)}
 }
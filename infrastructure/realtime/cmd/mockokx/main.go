@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/wiger123/okex_v5_golang/mockokx"
+)
+
+// 主函数: 本地启动模拟 OKX 交易所
+func main() {
+	// 监听地址
+	addr := flag.String("addr", "localhost:8080", "监听地址")
+	// 解析参数
+	flag.Parse()
+
+	// 创建模拟交易所
+	server := mockokx.NewServer(mockokx.DefaultConfig())
+	// 提示地址
+	log.Printf("[成功提示] 模拟交易所公共频道: ws://%v%v", *addr, mockokx.PublicPath)
+	// 提示地址
+	log.Printf("[成功提示] 模拟交易所私有频道: ws://%v%v", *addr, mockokx.PrivatePath)
+	// 提示模拟账户密钥
+	log.Printf("[成功提示] 模拟账户: OKX_API_KEY=%v OKX_SECRET_KEY=%v OKX_PASSPHRASE=%v", mockokx.MockCredentials().ApiKey, mockokx.MockCredentials().SecretKey, mockokx.MockCredentials().PassPhrase)
+	// 提供服务
+	if err := server.ListenAndServe(*addr); err != nil {
+		// 错误提示
+		log.Fatalf("[错误提示] 模拟交易所启动失败: %v", err)
+	}
+}
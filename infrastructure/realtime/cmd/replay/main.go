@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/wiger123/okex_v5_golang/config"
+	. "github.com/wiger123/okex_v5_golang/database"
+	"github.com/wiger123/okex_v5_golang/recorder"
+	"github.com/wiger123/okex_v5_golang/replay"
+	. "github.com/wiger123/okex_v5_golang/strategy"
+	. "github.com/wiger123/okex_v5_golang/wsdata/client"
+)
+
+// 主函数: 回放记录的会话, 策略发出的请求只截获不发送
+func main() {
+	// 公共频道记录文件
+	public := flag.String("public", "", "公共频道记录文件, 多个文件用逗号分隔, 按时间顺序")
+	// 私有频道记录文件
+	private := flag.String("private", "", "私有频道记录文件, 多个文件用逗号分隔, 按时间顺序")
+	// 回放速度
+	speed := flag.Float64("speed", 1, "回放速度倍数, 1 为实时, 0 为尽快回放")
+	// 是否运行策略
+	runStrategy := flag.Bool("strategy", false, "回放时运行策略")
+	// 截获请求记录目录
+	out := flag.String("out", "", "截获请求的记录目录, 为空时不记录")
+	// 解析参数
+	flag.Parse()
+
+	// 数据库初始化
+	dataRepo := NewDataRepo()
+	// 回放器
+	player := replay.NewPlayer(*speed)
+	// 公共频道回放连接
+	publicTransport := player.AddSession(splitPaths(*public))
+	// 私有频道回放连接
+	privateTransport := player.AddSession(splitPaths(*private))
+	// 记录截获的请求
+	if *out != "" {
+		// 创建记录器
+		r, err := recorder.NewRecorder(*out, "replay")
+		// 创建失败
+		if err != nil {
+			// 错误提示
+			log.Fatalf("[错误提示] 截获请求记录器创建失败: %v", err)
+		}
+		// 函数结束前写完记录文件
+		defer r.Close()
+		// 公共频道截获请求
+		publicTransport.SetCapture(r)
+		// 私有频道截获请求
+		privateTransport.SetCapture(r)
+	}
+
+	// 创建回放客户端: 公共频道
+	publicClient, err := NewOkxClient(config.PublicURL, WithDialer(publicTransport.Dialer()))
+	// 创建失败
+	if err != nil {
+		// 错误提示
+		log.Fatalf("[错误提示] 回放客户端创建失败: %v", err)
+	}
+	// 创建回放客户端: 私有频道
+	privateClient, err := NewOkxClient(config.PrivateURL, WithDialer(privateTransport.Dialer()))
+	// 创建失败
+	if err != nil {
+		// 错误提示
+		log.Fatalf("[错误提示] 回放客户端创建失败: %v", err)
+	}
+
+	// 注册与实盘相同的信息处理, 回放中不发送订阅请求
+	// 交易频道
+	publicClient.Subscribe("trades", "", "", config.InstID, dataRepo.HandleMessage)
+	// 盘口频道
+	publicClient.Subscribe("books5", "", "", config.InstID, dataRepo.HandleMessage)
+	// 账户频道
+	privateClient.Subscribe("account", "", "", "", dataRepo.HandleMessage)
+	// 持仓频道
+	privateClient.Subscribe("positions", config.InstType, "", config.InstID, dataRepo.HandleMessage)
+	// 订单频道
+	privateClient.Subscribe("orders", config.InstType, "", config.InstID, dataRepo.HandleMessage)
+
+	// 公共频道数据解析并处理
+	go publicClient.ReadWebsocketLoop()
+	// 私有频道数据解析并处理
+	go privateClient.ReadWebsocketLoop()
+
+	// 执行策略
+	if *runStrategy {
+		// 策略
+		go PrintMoney(privateClient, dataRepo)
+	}
+
+	// 回放全部记录
+	if err := player.Run(); err != nil {
+		// 错误提示
+		log.Printf("[错误提示] 回放中断: %v", err)
+	}
+	// 成功提示
+	log.Printf("[成功提示] 回放完成, 推送数据: %v 条, 截获请求: 公共频道 %v 条, 私有频道 %v 条", player.Played(), len(publicTransport.Sent()), len(privateTransport.Sent()))
+
+	// 关闭公共频道客户端
+	publicClient.Shutdown()
+	// 关闭私有频道客户端
+	privateClient.Shutdown()
+}
+
+// 逗号分隔的文件列表
+func splitPaths(paths string) []string {
+	// 空列表
+	if paths == "" {
+		// 返回
+		return nil
+	}
+	// 分隔
+	return strings.Split(paths, ",")
+}
@@ -2,14 +2,48 @@ package config
 
 // 参数配置
 const (
-	// API Key
-	ApiKey = ""
-	// API Secret Key
-	SecretKey = ""
-	// API Passphrase
-	PassPhrase = ""
+	// 账户列表, 逗号分隔, 每个账户的密钥从环境变量 <账户>_API_KEY / <账户>_SECRET_KEY / <账户>_PASSPHRASE 读取
+	Accounts = "OKX"
+	// 密钥文件目录, 不为空时从 <目录>/<账户>.json 读取密钥, 文件权限需为 600
+	CredentialsDir = ""
+	// 交易环境: live 实盘, demo 模拟盘, custom 自定义地址
+	Env = "live"
 	// 公共频道地址
 	PublicURL = "wss://ws.okx.com:8443/ws/v5/public"
 	// 私有频道地址
 	PrivateURL = "wss://ws.okx.com:8443/ws/v5/private"
+	// REST 接口地址
+	RestURL = "https://www.okx.com"
+	// 模拟盘公共频道地址
+	DemoPublicURL = "wss://wspap.okx.com:8443/ws/v5/public?brokerId=9999"
+	// 模拟盘私有频道地址
+	DemoPrivateURL = "wss://wspap.okx.com:8443/ws/v5/private?brokerId=9999"
+	// 模拟盘 REST 接口地址, 请求需带 x-simulated-trading 请求头
+	DemoRestURL = "https://www.okx.com"
+	// 自定义公共频道地址
+	CustomPublicURL = "ws://localhost:8080/ws/v5/public"
+	// 自定义私有频道地址
+	CustomPrivateURL = "ws://localhost:8080/ws/v5/private"
+	// 自定义 REST 接口地址
+	CustomRestURL = "http://localhost:8080"
+	// 自定义地址是否为模拟盘
+	CustomSimulated = false
+	// REST 请求超时 Second
+	RestTimeout = 10
+	// 启动时连接尝试次数
+	DialAttempts = 5
+	// 重连初始等待 Millisecond
+	ReconnectMinDelay = 500
+	// 重连最大等待 Millisecond
+	ReconnectMaxDelay = 30000
+	// 重连登录等待 Second
+	ReconnectLoginTimeout = 10
+	// 空闲多久发送 ping Second, 需小于交易所 30 秒断开时间
+	PingInterval = 20
+	// 等待 pong 超时 Second, 超时后强制重连
+	PongTimeout = 10
+	// 交易所时钟同步间隔 Second
+	ClockSyncInterval = 60
+	// 行情延迟统计输出间隔 Second
+	LatencyLogInterval = 60
 )
@@ -8,4 +8,8 @@ const (
 	NBook5s = 15
 	// 保留最新的盘口加权数据数目
 	NBook5sAvg = 15
+	// 保留最新的强平订单数目
+	NLiquidation = 100
+	// 策略事件订阅缓冲区大小, 满时丢弃新事件
+	EventBuffer = 256
 )
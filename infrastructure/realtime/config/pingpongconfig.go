@@ -1,29 +0,0 @@
-package config
-
-// 参数配置
-const (
-	// 交易品种
-	PingPongInstID = "ETH-USDT"
-	// 交易模式
-	PingPongTdMode = "cash"
-	// 交易方向
-	PingPongSide = "buy"
-	// 持仓方向
-	PingPongPosSide = ""
-	// 挂单模式
-	PingPongOrdType = "limit"
-	// 每单张数
-	PingPongPerSize = "0.01"
-	// 每单价格
-	PingPongPerPrice = "200"
-	// 设定订单 ID
-	PingPongClOrdId1 = "20220328130100PING"
-	// 设定订单 ID
-	PingPongClOrdId2 = "20220328130100PONG"
-	// order ID
-	PingPongOrdId1 = ""
-	// order ID
-	PingPongOrdId2 = ""
-	// 操作间隔
-	PingPongDelay = 8
-)
@@ -0,0 +1,13 @@
+package config
+
+// 参数配置
+const (
+	// 限频时间窗口 Millisecond
+	RateLimitWindow = 2000
+	// 每个业务操作在时间窗口内的请求次数上限
+	RateLimitOpRequests = 60
+	// 单个订单操作在时间窗口内每个产品的订单数上限: order, cancel-order, amend-order
+	RateLimitSingleOrders = 60
+	// 批量订单操作在时间窗口内每个产品的订单数上限: batch-orders, batch-cancel-orders, batch-amend-orders
+	RateLimitBatchOrders = 300
+)
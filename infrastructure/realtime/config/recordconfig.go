@@ -0,0 +1,13 @@
+package config
+
+// 参数配置
+const (
+	// 原始数据记录目录, 为空时不记录
+	RecordDir = ""
+	// 单个记录文件最大未压缩字节数
+	RecordMaxBytes = 64 << 20
+	// 记录文件轮换间隔 Second
+	RecordRotateInterval = 3600
+	// 记录文件刷新间隔 Millisecond, 程序异常退出时最多丢失该时间内的数据
+	RecordFlushInterval = 1000
+)
@@ -10,8 +10,6 @@ const (
 	TdMode = "cash"
 	// 挂单模式
 	OrdType = "limit"
-	// 小数点后几位
-	FloatPrec = 2
 	// 订单编号长度
 	ClOrdIdLength = 10
 	// 交易量强弱比
@@ -30,9 +28,11 @@ const (
 	MinWeight = 0.1
 	// 档位划分
 	NumPost = 10
-	// 最大挂单量
+	// 挂单数量单位: sz 为张数 (币币为交易币数量), usdt 为计价货币金额, 下单时按价格和合约面值换算
+	PostUnit = "sz"
+	// 最大挂单量, 单位见 PostUnit
 	MaxPost = 1.0
-	// 最低张数
+	// 最低挂单量, 单位见 PostUnit
 	MinPost = 1.0
 	// 参考权重上限百分比
 	MaxRef = 10000.0
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"log"
+	"sync/atomic"
+
+	. "github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 数据事件类型
+type EventKind int
+
+// 数据事件类型枚举
+const (
+	// 成交
+	EventTrade EventKind = iota + 1
+	// 盘口
+	EventBook
+	// 订单更新
+	EventOrder
+	// 持仓
+	EventPosition
+	// 账户
+	EventAccount
+)
+
+// 数据事件: 推送写入数据库后发布, 按 Kind 读取对应字段; 事件数据为副本, 可任意读取
+type Event struct {
+	// 事件类型
+	Kind EventKind
+	// 产品 ID, 账户事件为空
+	InstId string
+	// 成交数据, EventTrade
+	Trades []Trade
+	// 盘口数据, EventBook
+	Book Book5
+	// 订单数据, EventOrder
+	Orders []Orders
+	// 持仓数据, EventPosition
+	Positions []Positions
+	// 账户数据, EventAccount
+	Accounts []Account
+}
+
+// 事件订阅
+//
+// 慢消费者策略: 每个订阅有固定大小的缓冲区, 缓冲区满时丢弃新事件并计数, 不阻塞推送处理;
+// 事件只表示数据已更新, 丢失事件后通过 LatestBook, OpenOrders, Position 等快照方法读取最新数据
+type Subscription struct {
+	// 所属数据库
+	dr *DataRepo
+	// 事件通道
+	ch chan Event
+	// 订阅的事件类型, 为空时订阅全部
+	kinds map[EventKind]bool
+	// 丢弃的事件数目
+	dropped uint64
+}
+
+// 事件通道, 取消订阅后关闭, 已缓冲的事件仍可读出
+func (s *Subscription) Events() <-chan Event {
+	// 返回
+	return s.ch
+}
+
+// 缓冲区满时丢弃的事件数目
+func (s *Subscription) Dropped() uint64 {
+	// 返回
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// 取消订阅并关闭事件通道, 可重复调用
+func (s *Subscription) Close() {
+	// 上锁
+	s.dr.subsMu.Lock()
+	// 函数结束前解锁
+	defer s.dr.subsMu.Unlock()
+	// 已取消
+	if !s.dr.subs[s] {
+		// 返回
+		return
+	}
+	// 删除订阅
+	delete(s.dr.subs, s)
+	// 关闭通道
+	close(s.ch)
+}
+
+// 订阅数据事件: size 为缓冲区大小, kinds 为空时订阅全部事件类型
+func (dr *DataRepo) SubscribeEvents(size int, kinds ...EventKind) *Subscription {
+	// 订阅初始化
+	s := &Subscription{dr: dr, ch: make(chan Event, Max(size, 1)), kinds: make(map[EventKind]bool)}
+	// 逐个事件类型
+	for _, kind := range kinds {
+		// 添加事件类型
+		s.kinds[kind] = true
+	}
+	// 上锁
+	dr.subsMu.Lock()
+	// 函数结束前解锁
+	defer dr.subsMu.Unlock()
+	// 添加订阅
+	dr.subs[s] = true
+	// 返回
+	return s
+}
+
+// 发布事件, 不阻塞; 缓冲区满的订阅丢弃该事件
+func (dr *DataRepo) publish(e Event) {
+	// 上锁
+	dr.subsMu.RLock()
+	// 函数结束前解锁
+	defer dr.subsMu.RUnlock()
+	// 逐个订阅
+	for s := range dr.subs {
+		// 未订阅该类型
+		if len(s.kinds) > 0 && !s.kinds[e.Kind] {
+			// 跳过
+			continue
+		}
+		// 非阻塞发送
+		select {
+		// 发送成功
+		case s.ch <- e:
+		// 缓冲区已满
+		default:
+			// 丢弃计数
+			n := atomic.AddUint64(&s.dropped, 1)
+			// 首次及每 1000 次提示
+			if n == 1 || n%1000 == 0 {
+				// 普通提示
+				log.Printf("[普通提示] 事件订阅处理过慢, 已丢弃事件数目: %v", n)
+			}
+		}
+	}
+}
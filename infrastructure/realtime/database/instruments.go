@@ -0,0 +1,72 @@
+package database
+
+import (
+	"log"
+	"sync"
+
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 产品基础信息缓存: 由 REST 接口或 instruments 频道加载, 提供下单精度
+type Instruments struct {
+	// 并发保护
+	mux sync.RWMutex
+	// 产品信息, 按产品 ID
+	data map[string]Instrument
+}
+
+// 创建产品信息缓存
+func NewInstruments() *Instruments {
+	// 返回结构体
+	return &Instruments{data: make(map[string]Instrument)}
+}
+
+// 更新产品信息, 已有产品覆盖
+func (s *Instruments) Update(list []Instrument) {
+	// 上锁
+	s.mux.Lock()
+	// 函数结束前解锁
+	defer s.mux.Unlock()
+	// 逐个产品
+	for _, inst := range list {
+		// 更新产品
+		s.data[inst.InstId] = inst
+	}
+}
+
+// 查找产品信息
+func (s *Instruments) Get(instId string) (Instrument, bool) {
+	// 上锁
+	s.mux.RLock()
+	// 函数结束前解锁
+	defer s.mux.RUnlock()
+	// 产品信息
+	inst, ok := s.data[instId]
+	// 返回
+	return inst, ok
+}
+
+// 已加载的产品数目
+func (s *Instruments) Len() int {
+	// 上锁
+	s.mux.RLock()
+	// 函数结束前解锁
+	defer s.mux.RUnlock()
+	// 返回
+	return len(s.data)
+}
+
+// 处理 instruments 频道推送
+func (s *Instruments) HandleMessage(m PushMessage) {
+	// 产品信息推送
+	im, ok := m.(*InstrumentsMessage)
+	// 非产品信息
+	if !ok {
+		// 普通提示
+		log.Printf("[普通提示] 产品信息缓存收到未知推送: %T", m)
+		// 返回
+		return
+	}
+	// 更新产品信息
+	s.Update(im.Data)
+}
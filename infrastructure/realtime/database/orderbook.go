@@ -0,0 +1,306 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	. "github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 深度增量推送前未收到全量推送
+var ErrBookNotSynced = errors.New("深度未同步, 等待全量推送")
+
+// 深度一方没有挂单
+var ErrBookEmpty = errors.New("深度没有挂单")
+
+// 深度校验失败: 本地深度与交易所不一致, 需重新订阅
+type BookChecksumError struct {
+	// 频道名
+	Channel string
+	// 产品 ID
+	InstID string
+	// 交易所校验和
+	Expected int32
+	// 本地校验和
+	Actual int32
+}
+
+// 错误信息
+func (e *BookChecksumError) Error() string {
+	// 字符串格式化
+	return fmt.Sprintf("深度校验失败 %v %v, 交易所: %v, 本地: %v", e.Channel, e.InstID, e.Expected, e.Actual)
+}
+
+// 深度序列号不连续: 增量推送丢失, 需重新订阅
+type BookSequenceError struct {
+	// 频道名
+	Channel string
+	// 产品 ID
+	InstID string
+	// 本地序列号
+	SeqId int64
+	// 推送的上一次序列号
+	PrevSeqId int64
+}
+
+// 错误信息
+func (e *BookSequenceError) Error() string {
+	// 字符串格式化
+	return fmt.Sprintf("深度序列号不连续 %v %v, 本地: %v, 推送: %v", e.Channel, e.InstID, e.SeqId, e.PrevSeqId)
+}
+
+// 本地深度: 全量推送重建, 增量推送按价格合并, 每次推送后校验
+type OrderBook struct {
+	// 频道名
+	Channel string
+	// 产品 ID
+	InstID string
+	// 卖方深度, 价格从低到高: [价格, 数量, 已弃用, 订单数]
+	Asks [][]string
+	// 买方深度, 价格从高到低: [价格, 数量, 已弃用, 订单数]
+	Bids [][]string
+	// 最近一次推送的时间戳
+	Ts string
+	// 最近一次推送的序列号
+	SeqId int64
+	// 是否已同步: 收到全量推送且校验通过
+	Synced bool
+}
+
+// 创建本地深度
+func NewOrderBook(channel, instId string) *OrderBook {
+	// 返回深度
+	return &OrderBook{Channel: channel, InstID: instId}
+}
+
+// 应用一次推送: snapshot 全量, update 增量, bbo-tbt 无 action 视为全量
+// 校验失败或序列号不连续时深度标记为未同步, 直到下一次全量推送
+func (ob *OrderBook) Apply(action string, b *Book) error {
+	// 增量推送
+	if action == "update" {
+		// 未同步, 丢弃增量
+		if !ob.Synced {
+			// 返回错误
+			return ErrBookNotSynced
+		}
+		// 序列号不连续, 仅在推送带序列号时检查
+		if b.PrevSeqId > 0 && ob.SeqId > 0 && b.PrevSeqId != ob.SeqId {
+			// 标记未同步
+			ob.Synced = false
+			// 返回错误
+			return &BookSequenceError{Channel: ob.Channel, InstID: ob.InstID, SeqId: ob.SeqId, PrevSeqId: b.PrevSeqId}
+		}
+		// 合并卖方深度
+		ob.Asks = mergeLevels(ob.Asks, b.Asks, true)
+		// 合并买方深度
+		ob.Bids = mergeLevels(ob.Bids, b.Bids, false)
+	} else {
+		// 全量卖方深度
+		ob.Asks = append([][]string(nil), b.Asks...)
+		// 全量买方深度
+		ob.Bids = append([][]string(nil), b.Bids...)
+	}
+	// 时间戳
+	ob.Ts = b.Ts
+	// 序列号
+	ob.SeqId = b.SeqId
+	// bbo-tbt 不带校验和
+	if ob.Channel != "bbo-tbt" {
+		// 本地校验和
+		actual := BookChecksum(ob.Bids, ob.Asks)
+		// 校验失败
+		if actual != b.Checksum {
+			// 标记未同步
+			ob.Synced = false
+			// 返回错误
+			return &BookChecksumError{Channel: ob.Channel, InstID: ob.InstID, Expected: b.Checksum, Actual: actual}
+		}
+	}
+	// 标记已同步
+	ob.Synced = true
+	// 返回
+	return nil
+}
+
+// 买一价和数量, 没有买单时返回 ErrBookEmpty
+func (ob *OrderBook) BestBid() (Decimal, Decimal, error) {
+	// 没有买单
+	if len(ob.Bids) == 0 {
+		// 返回错误
+		return Decimal{}, Decimal{}, ErrBookEmpty
+	}
+	// 返回
+	return ob.parseLevel(ob.Bids[0])
+}
+
+// 卖一价和数量, 没有卖单时返回 ErrBookEmpty
+func (ob *OrderBook) BestAsk() (Decimal, Decimal, error) {
+	// 没有卖单
+	if len(ob.Asks) == 0 {
+		// 返回错误
+		return Decimal{}, Decimal{}, ErrBookEmpty
+	}
+	// 返回
+	return ob.parseLevel(ob.Asks[0])
+}
+
+// 解析档位价格和数量, 数据不完整或无法解析时返回错误
+func (ob *OrderBook) parseLevel(level []string) (Decimal, Decimal, error) {
+	// 数据不完整
+	if len(level) < 2 {
+		// 返回错误
+		return Decimal{}, Decimal{}, fmt.Errorf("深度档位不完整 %v %v: %v", ob.Channel, ob.InstID, level)
+	}
+	// 价格
+	px, err := NewDecimal(level[0])
+	// 价格无效
+	if err != nil {
+		// 返回错误
+		return Decimal{}, Decimal{}, fmt.Errorf("深度档位价格无效 %v %v: %w", ob.Channel, ob.InstID, err)
+	}
+	// 数量
+	sz, err := NewDecimal(level[1])
+	// 数量无效
+	if err != nil {
+		// 返回错误
+		return Decimal{}, Decimal{}, fmt.Errorf("深度档位数量无效 %v %v: %w", ob.Channel, ob.InstID, err)
+	}
+	// 返回
+	return px, sz, nil
+}
+
+// 前 n 档深度副本: 买方, 卖方
+func (ob *OrderBook) Depth(n int) ([][]string, [][]string) {
+	// 买方深度
+	bids := append([][]string(nil), ob.Bids[:Min(n, len(ob.Bids))]...)
+	// 卖方深度
+	asks := append([][]string(nil), ob.Asks[:Min(n, len(ob.Asks))]...)
+	// 返回
+	return bids, asks
+}
+
+// 价格不低于 px 的买单累计数量: 以 px 卖出可成交的数量; 档位无法解析时返回错误
+func (ob *OrderBook) CumBidSize(px Decimal) (Decimal, error) {
+	// 从买一开始累计
+	return ob.cumSize(ob.Bids, func(levelPx Decimal) bool {
+		// 低于价格
+		return levelPx.Cmp(px) < 0
+	})
+}
+
+// 价格不高于 px 的卖单累计数量: 以 px 买入可成交的数量; 档位无法解析时返回错误
+func (ob *OrderBook) CumAskSize(px Decimal) (Decimal, error) {
+	// 从卖一开始累计
+	return ob.cumSize(ob.Asks, func(levelPx Decimal) bool {
+		// 高于价格
+		return levelPx.Cmp(px) > 0
+	})
+}
+
+// 从第一档开始累计数量, 直到 beyond 返回 true
+func (ob *OrderBook) cumSize(levels [][]string, beyond func(levelPx Decimal) bool) (Decimal, error) {
+	// 累计数量
+	sum := DecimalFromInt(0)
+	// 逐档
+	for _, level := range levels {
+		// 解析档位
+		levelPx, sz, err := ob.parseLevel(level)
+		// 解析失败
+		if err != nil {
+			// 返回错误
+			return Decimal{}, err
+		}
+		// 超出价格
+		if beyond(levelPx) {
+			// 结束
+			break
+		}
+		// 累加
+		sum = sum.Add(sz)
+	}
+	// 返回
+	return sum, nil
+}
+
+// 深度副本, 可在数据库锁外读取
+func (ob *OrderBook) Copy() OrderBook {
+	// 复制
+	cp := *ob
+	// 卖方深度
+	cp.Asks = append([][]string(nil), ob.Asks...)
+	// 买方深度
+	cp.Bids = append([][]string(nil), ob.Bids...)
+	// 返回
+	return cp
+}
+
+// 按价格合并增量深度, 数量为 0 删除该档位, ascending 为价格从低到高
+// 价格按小数比较, "0.10" 与 "0.1" 为同一档位; 价格无法解析的增量档位丢弃
+func mergeLevels(levels, updates [][]string, ascending bool) [][]string {
+	// 逐个档位
+	for _, update := range updates {
+		// 数据不完整
+		if len(update) < 2 {
+			// 跳过
+			continue
+		}
+		// 价格
+		px, err := NewDecimal(update[0])
+		// 价格无效
+		if err != nil {
+			// 跳过
+			continue
+		}
+		// 查找价格位置
+		i := sort.Search(len(levels), func(j int) bool {
+			// 当前档位价格与该价格比较
+			c := levelPx(levels[j]).Cmp(px)
+			// 价格从低到高
+			if ascending {
+				// 第一个不低于该价格的档位
+				return c >= 0
+			}
+			// 第一个不高于该价格的档位
+			return c <= 0
+		})
+		// 是否已有该价格档位
+		found := i < len(levels) && levelPx(levels[i]).Equal(px)
+		// 数量
+		sz, err := NewDecimal(update[1])
+		// 数量为 0, 删除档位
+		if err != nil || sz.IsZero() {
+			// 已有档位
+			if found {
+				// 删除
+				levels = append(levels[:i], levels[i+1:]...)
+			}
+			// 继续
+			continue
+		}
+		// 已有档位
+		if found {
+			// 替换
+			levels[i] = update
+			// 继续
+			continue
+		}
+		// 插入新档位
+		levels = append(levels, nil)
+		// 后移
+		copy(levels[i+1:], levels[i:])
+		// 插入
+		levels[i] = update
+	}
+	// 返回
+	return levels
+}
+
+// 档位价格, 无法解析时为空值
+func levelPx(level []string) Decimal {
+	// 价格
+	px, _ := NewDecimal(level[0])
+	// 返回
+	return px
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 构建推送, 校验和按推送后的期望深度计算
+func push(bids, asks, wantBids, wantAsks [][]string, prevSeqId, seqId int64) *Book {
+	// 返回推送
+	return &Book{Bids: bids, Asks: asks, PrevSeqId: prevSeqId, SeqId: seqId, Checksum: BookChecksum(wantBids, wantAsks)}
+}
+
+func TestOrderBookSnapshotAndUpdate(t *testing.T) {
+	ob := NewOrderBook("books", "BTC-USDT")
+	bids := [][]string{{"100.0", "1"}, {"99.5", "2"}}
+	asks := [][]string{{"100.5", "3"}, {"101", "4"}}
+	if err := ob.Apply("snapshot", push(bids, asks, bids, asks, -1, 10)); err != nil {
+		t.Fatalf("全量推送: %v", err)
+	}
+	if !ob.Synced || ob.SeqId != 10 {
+		t.Fatalf("全量推送后状态: %+v", ob)
+	}
+
+	// 增量: 修改买一, 插入新买档, 删除卖一 (价格格式不同但数值相同), 插入新卖档
+	updBids := [][]string{{"100", "5"}, {"99.8", "1"}}
+	updAsks := [][]string{{"100.50", "0"}, {"102", "6"}}
+	wantBids := [][]string{{"100", "5"}, {"99.8", "1"}, {"99.5", "2"}}
+	wantAsks := [][]string{{"101", "4"}, {"102", "6"}}
+	if err := ob.Apply("update", push(updBids, updAsks, wantBids, wantAsks, 10, 11)); err != nil {
+		t.Fatalf("增量推送: %v", err)
+	}
+	if !reflect.DeepEqual(ob.Bids, wantBids) || !reflect.DeepEqual(ob.Asks, wantAsks) {
+		t.Fatalf("合并结果: bids %v, asks %v", ob.Bids, ob.Asks)
+	}
+	if ob.SeqId != 11 {
+		t.Fatalf("序列号: %v", ob.SeqId)
+	}
+}
+
+func TestOrderBookUpdateBeforeSnapshot(t *testing.T) {
+	ob := NewOrderBook("books", "BTC-USDT")
+	if err := ob.Apply("update", push(nil, nil, nil, nil, 1, 2)); !errors.Is(err, ErrBookNotSynced) {
+		t.Fatalf("期望 ErrBookNotSynced, 实际: %v", err)
+	}
+}
+
+func TestOrderBookSequenceGap(t *testing.T) {
+	ob := NewOrderBook("books", "BTC-USDT")
+	bids := [][]string{{"100", "1"}}
+	asks := [][]string{{"101", "1"}}
+	if err := ob.Apply("snapshot", push(bids, asks, bids, asks, -1, 10)); err != nil {
+		t.Fatal(err)
+	}
+	// 序列号 11 丢失
+	err := ob.Apply("update", push(nil, nil, bids, asks, 11, 12))
+	var seqErr *BookSequenceError
+	if !errors.As(err, &seqErr) || seqErr.SeqId != 10 || seqErr.PrevSeqId != 11 {
+		t.Fatalf("期望 BookSequenceError, 实际: %v", err)
+	}
+	if ob.Synced {
+		t.Fatal("序列号不连续后应标记未同步")
+	}
+	// 未同步期间的增量丢弃
+	if err := ob.Apply("update", push(nil, nil, bids, asks, 12, 13)); !errors.Is(err, ErrBookNotSynced) {
+		t.Fatalf("期望 ErrBookNotSynced, 实际: %v", err)
+	}
+	// 全量推送重新同步
+	if err := ob.Apply("snapshot", push(bids, asks, bids, asks, -1, 20)); err != nil || !ob.Synced {
+		t.Fatalf("重新同步: %v", err)
+	}
+	// 无变化的增量序列号不变时也连续
+	if err := ob.Apply("update", push(nil, nil, bids, asks, 20, 20)); err != nil {
+		t.Fatalf("心跳增量: %v", err)
+	}
+}
+
+func TestOrderBookChecksumMismatch(t *testing.T) {
+	ob := NewOrderBook("books", "BTC-USDT")
+	bids := [][]string{{"100", "1"}}
+	asks := [][]string{{"101", "1"}}
+	b := push(bids, asks, bids, asks, -1, 10)
+	b.Checksum++
+	err := ob.Apply("snapshot", b)
+	var sumErr *BookChecksumError
+	if !errors.As(err, &sumErr) || ob.Synced {
+		t.Fatalf("期望 BookChecksumError, 实际: %v, synced %v", err, ob.Synced)
+	}
+}
+
+func TestMergeLevelsOrdering(t *testing.T) {
+	// 卖方价格从低到高, 字符串比较会把 "9.5" 排在 "10" 之后
+	asks := mergeLevels([][]string{{"10", "1"}}, [][]string{{"9.5", "1"}, {"10.25", "2"}, {"abc", "1"}}, true)
+	if want := [][]string{{"9.5", "1"}, {"10", "1"}, {"10.25", "2"}}; !reflect.DeepEqual(asks, want) {
+		t.Errorf("卖方合并: %v, 期望 %v", asks, want)
+	}
+	// 买方价格从高到低
+	bids := mergeLevels([][]string{{"10", "1"}}, [][]string{{"9.5", "1"}, {"10.25", "2"}, {"10.0", "0"}}, false)
+	if want := [][]string{{"10.25", "2"}, {"9.5", "1"}}; !reflect.DeepEqual(bids, want) {
+		t.Errorf("买方合并: %v, 期望 %v", bids, want)
+	}
+}
+
+func TestOrderBookBestAndCumSize(t *testing.T) {
+	ob := NewOrderBook("books", "BTC-USDT")
+	if _, _, err := ob.BestBid(); !errors.Is(err, ErrBookEmpty) {
+		t.Fatalf("空深度期望 ErrBookEmpty, 实际: %v", err)
+	}
+	bids := [][]string{{"100.1", "1"}, {"100", "2"}, {"99.9", "4"}}
+	asks := [][]string{{"100.2", "0.1"}, {"100.3", "0.2"}, {"100.5", "0.4"}}
+	if err := ob.Apply("snapshot", push(bids, asks, bids, asks, -1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if px, sz, err := ob.BestBid(); err != nil || px.String() != "100.1" || sz.String() != "1" {
+		t.Fatalf("买一: %v %v %v", px, sz, err)
+	}
+	if px, sz, err := ob.BestAsk(); err != nil || px.String() != "100.2" || sz.String() != "0.1" {
+		t.Fatalf("卖一: %v %v %v", px, sz, err)
+	}
+	if sum, err := ob.CumBidSize(MustDecimal("100")); err != nil || sum.String() != "3" {
+		t.Fatalf("买方累计: %v %v", sum, err)
+	}
+	// 小数累加没有浮点误差
+	if sum, err := ob.CumAskSize(MustDecimal("100.3")); err != nil || sum.String() != "0.3" {
+		t.Fatalf("卖方累计: %v %v", sum, err)
+	}
+}
+
+func TestOrderBookCorruptLevel(t *testing.T) {
+	ob := NewOrderBook("books", "BTC-USDT")
+	// 档位数据损坏时返回错误, 不按 0 计算
+	ob.Bids = [][]string{{"abc", "1"}}
+	ob.Asks = [][]string{{"100", "1"}, {"101", "x"}}
+	if _, _, err := ob.BestBid(); err == nil {
+		t.Fatal("买一价格无效期望错误")
+	}
+	if _, err := ob.CumAskSize(MustDecimal("101")); err == nil {
+		t.Fatal("卖方数量无效期望错误")
+	}
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"log"
 	"sync"
 
@@ -12,118 +13,212 @@ import (
 
 // DataRepo 负责数据管理, 接收 websocket 推送的数据, 并对外提供数据获取
 type DataRepo struct {
-	// 并发保护
-	Mu sync.Mutex
+	// 并发保护, 数据只通过快照方法对外提供
+	mu sync.Mutex
 	// 交易数据
-	TradeData []Trade
+	tradeData []Trade
 	// 盘口数据
-	Book5Data []Book5
+	book5Data []Book5
 	// 盘口价格数据
-	Book5AvgData []float64
+	book5AvgData []float64
 	// 盘口挂买价
-	BidsPrice float64
+	bidsPrice float64
 	// 盘口挂卖价
-	AsksPrice float64
+	asksPrice float64
 	// 账户数据
-	AccountData []Account
+	accountData []Account
 	// Token 数目
-	TokenAmt float64
+	tokenAmt float64
 	// USDT 数目
-	UsdtAmt float64
+	usdtAmt float64
 	// 持仓数据
-	PositionsData []Positions
-	// 多仓数据
-	PositionsLongData PositionsLong
-	// 空仓数据
-	PositionsShortData PositionsShort
+	positionsData []Positions
+	// 各持仓方向的最新持仓, 按 long / short / net
+	positionsBySide map[string]Positions
 	// 订单数据
-	OrdersData map[string]*Orders
+	ordersData map[string]*Orders
+	// 等待确认的改单, 按修改请求 ID; 值为确认前是否已收到改单失败推送
+	amends map[string]bool
+	// 行情数据, 按产品 ID
+	tickerData map[string]Ticker
+	// 深度数据, 按 BookKey(频道名, 产品 ID)
+	booksData map[string]*OrderBook
+	// 标记价格, 按产品 ID
+	markPriceData map[string]MarkPrice
+	// 指数行情, 按指数
+	indexTickerData map[string]IndexTicker
+	// 资金费率, 按产品 ID
+	fundingRateData map[string]FundingRate
+	// 持仓总量, 按产品 ID
+	openInterestData map[string]OpenInterest
+	// 限价, 按产品 ID
+	priceLimitData map[string]PriceLimit
+	// 最新的强平订单
+	liquidationData []Liquidation
+	// 深度校验失败时的重新订阅处理
+	bookResync func(channel, instId string)
+	// 频道数据处理并发保护
+	handlersMu sync.RWMutex
+	// 频道数据处理, 按频道名
+	handlers map[string]RepoHandler
+	// 事件订阅并发保护
+	subsMu sync.RWMutex
+	// 事件订阅
+	subs map[*Subscription]bool
 }
 
 // 创建 DataRepo
 func NewDataRepo() *DataRepo {
-	// 返回结构体
-	return &DataRepo{
+	// 结构体初始化
+	dr := &DataRepo{
 		// 交易数据
-		TradeData: make([]Trade, 0),
+		tradeData: make([]Trade, 0),
 		// 盘口数据
-		Book5Data: make([]Book5, 0),
+		book5Data: make([]Book5, 0),
 		// 盘口价格数据
-		Book5AvgData: make([]float64, 0),
+		book5AvgData: make([]float64, 0),
 		// 盘口挂买价
-		BidsPrice: 0,
+		bidsPrice: 0,
 		// 盘口挂卖价
-		AsksPrice: 0,
+		asksPrice: 0,
 		// 账户数据
-		AccountData: make([]Account, 0),
+		accountData: make([]Account, 0),
 		// Token 数目
-		TokenAmt: 0,
+		tokenAmt: 0,
 		// USDT 数目
-		UsdtAmt: 0,
+		usdtAmt: 0,
 		// 持仓数据
-		PositionsData: make([]Positions, 0),
+		positionsData: make([]Positions, 0),
+		// 各持仓方向的最新持仓
+		positionsBySide: make(map[string]Positions),
 		// 订单数据
-		OrdersData: make(map[string]*Orders),
+		ordersData: make(map[string]*Orders),
+		// 等待确认的改单
+		amends: make(map[string]bool),
+		// 行情数据
+		tickerData: make(map[string]Ticker),
+		// 深度数据
+		booksData: make(map[string]*OrderBook),
+		// 标记价格
+		markPriceData: make(map[string]MarkPrice),
+		// 指数行情
+		indexTickerData: make(map[string]IndexTicker),
+		// 资金费率
+		fundingRateData: make(map[string]FundingRate),
+		// 持仓总量
+		openInterestData: make(map[string]OpenInterest),
+		// 限价
+		priceLimitData: make(map[string]PriceLimit),
+		// 强平订单
+		liquidationData: make([]Liquidation, 0),
+		// 频道数据处理
+		handlers: make(map[string]RepoHandler),
+		// 事件订阅
+		subs: make(map[*Subscription]bool),
 	}
+	// 注册内置频道
+	dr.registerHandlers()
+	// 返回
+	return dr
 }
 
-// 处理信息
-func (dr *DataRepo) HandleMessage(m PushMessage) {
-	// 获取频道名和产品 ID
-	channel, _ := m.ChannelAndInstID()
-	// 定义错误
-	var err error
+// 行情数据是否足够策略运行
+func (dr *DataRepo) Ready() bool {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 判断交易数据数目 盘口数据数目
+	return len(dr.tradeData) >= Ntrade && len(dr.book5Data) >= NBook5s
+}
 
-	// 频道选择
-	switch channel {
+// 清空行情数据: 断线期间的数据不连续, 重连后重新收集
+func (dr *DataRepo) ResetMarketData() {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
 	// 交易数据
-	case "trades":
-		// 交易数据信息
-		tm := m.(*TradeMessage)
-		// 显示数据
-		// log.Println("[成功提示] 交易数据: ", tm)
-		// 处理数据
-		err = dr.handleTrade(tm)
+	dr.tradeData = make([]Trade, 0)
 	// 盘口数据
-	case "books5":
-		// 盘口数据信息
-		bm := m.(*Book5Message)
-		// 显示数据
-		// log.Printf("[成功提示] 盘口数据: %v", bm)
-		// 处理数据
-		err = dr.handleBook5(bm)
+	dr.book5Data = make([]Book5, 0)
+	// 盘口价格数据
+	dr.book5AvgData = make([]float64, 0)
+	// 深度数据, 重新订阅后由全量推送重建
+	dr.booksData = make(map[string]*OrderBook)
+}
+
+// 深度数据 Key 值
+func BookKey(channel, instId string) string {
+	// 频道名 / 产品 ID
+	return channel + "/" + instId
+}
+
+// 频道数据处理
+type RepoHandler func(m PushMessage) error
+
+// 注册内置频道的数据处理
+func (dr *DataRepo) registerHandlers() {
+	// 交易数据
+	dr.RegisterHandler("trades", func(m PushMessage) error { return dr.handleTrade(m.(*TradeMessage)) })
+	// 盘口数据
+	dr.RegisterHandler("books5", func(m PushMessage) error { return dr.handleBook5(m.(*Book5Message)) })
+	// 行情数据
+	dr.RegisterHandler("tickers", func(m PushMessage) error { return dr.handleTicker(m.(*TickerMessage)) })
+	// 深度数据
+	for _, channel := range []string{"books", "books-l2-tbt", "books50-l2-tbt", "bbo-tbt"} {
+		// 深度数据
+		dr.RegisterHandler(channel, func(m PushMessage) error { return dr.handleBook(m.(*BookMessage)) })
+	}
+	// 标记价格
+	dr.RegisterHandler("mark-price", func(m PushMessage) error { return dr.handleMarkPrice(m.(*MarkPriceMessage)) })
+	// 指数行情
+	dr.RegisterHandler("index-tickers", func(m PushMessage) error { return dr.handleIndexTicker(m.(*IndexTickerMessage)) })
+	// 资金费率
+	dr.RegisterHandler("funding-rate", func(m PushMessage) error { return dr.handleFundingRate(m.(*FundingRateMessage)) })
+	// 持仓总量
+	dr.RegisterHandler("open-interest", func(m PushMessage) error { return dr.handleOpenInterest(m.(*OpenInterestMessage)) })
+	// 限价
+	dr.RegisterHandler("price-limit", func(m PushMessage) error { return dr.handlePriceLimit(m.(*PriceLimitMessage)) })
+	// 强平订单
+	dr.RegisterHandler("liquidation-orders", func(m PushMessage) error { return dr.handleLiquidation(m.(*LiquidationMessage)) })
 	// 账户数据
-	case "account":
-		// 账户数据信息
-		am := m.(*AccountMessage)
-		// 显示数据
-		// log.Printf("[成功提示] 账户数据: %v", bm)
-		// 处理数据
-		err = dr.handleAccount(am)
+	dr.RegisterHandler("account", func(m PushMessage) error { return dr.handleAccount(m.(*AccountMessage)) })
 	// 持仓数据
-	case "positions":
-		// 持仓数据信息
-		pm := m.(*PositionsMessage)
-		// 显示数据
-		// log.Printf("[成功提示] 持仓数据: %v", pm)
-		// 处理数据
-		err = dr.handlePositions(pm)
+	dr.RegisterHandler("positions", func(m PushMessage) error { return dr.handlePositions(m.(*PositionsMessage)) })
 	// 订单数据
-	case "orders":
-		// 订单数据信息
-		om := m.(*OrdersMessage)
-		// 显示数据
-		// log.Printf("[成功提示] 订单数据: %v", om)
-		// 处理数据
-		err = dr.handleOrders(om)
+	dr.RegisterHandler("orders", func(m PushMessage) error { return dr.handleOrders(m.(*OrdersMessage)) })
+}
+
+// 注册频道数据处理, 已注册时覆盖; 自定义频道的处理需自行加锁
+func (dr *DataRepo) RegisterHandler(channel string, handler RepoHandler) {
+	// 上锁
+	dr.handlersMu.Lock()
+	// 函数结束前解锁
+	defer dr.handlersMu.Unlock()
+	// 数据处理赋值
+	dr.handlers[channel] = handler
+}
+
+// 处理信息
+func (dr *DataRepo) HandleMessage(m PushMessage) {
+	// 获取频道名和产品 ID
+	channel, _ := m.ChannelAndInstID()
+	// 上锁
+	dr.handlersMu.RLock()
+	// 频道数据处理
+	handler := dr.handlers[channel]
+	// 解锁
+	dr.handlersMu.RUnlock()
 	// 未知频道
-	default:
+	if handler == nil {
 		// 普通提示
 		log.Printf("[普通提示] 未知频道: %v", channel)
+		// 返回
+		return
 	}
-
 	// 处理信息错误
-	if err != nil {
+	if err := handler(m); err != nil {
 		// 普通提示
 		log.Printf("[普通提示] 处理信息出错: %v", err)
 	}
@@ -132,52 +227,271 @@ func (dr *DataRepo) HandleMessage(m PushMessage) {
 // 处理交易数据
 func (dr *DataRepo) handleTrade(m *TradeMessage) error {
 	// 数据库上锁
-	dr.Mu.Lock()
+	dr.mu.Lock()
 	// 函数结束前解锁
-	defer dr.Mu.Unlock()
+	defer dr.mu.Unlock()
 	// 追加数据
-	dr.TradeData = append(dr.TradeData, m.Data...)
+	dr.tradeData = append(dr.tradeData, m.Data...)
 	// 保留数据
-	dr.TradeData = append(dr.TradeData[:0], dr.TradeData[Max(len(dr.TradeData)-Ntrade, 0):]...)
+	dr.tradeData = append(dr.tradeData[:0], dr.tradeData[Max(len(dr.tradeData)-Ntrade, 0):]...)
+	// 发布成交事件
+	dr.publish(Event{Kind: EventTrade, InstId: m.Arg.InstID, Trades: append([]Trade(nil), m.Data...)})
 	// 显示数据
-	// log.Println("[成功提示] 数据库交易数据: ", dr.TradeData)
+	// log.Println("[成功提示] 数据库交易数据: ", dr.tradeData)
 	// 显示数据数目
-	// log.Println("[成功提示] 数据库交易数据数目: ", len(dr.TradeData))
+	// log.Println("[成功提示] 数据库交易数据数目: ", len(dr.tradeData))
 	// 未出错返回
 	return nil
 }
 
-// 处理盘口数据
+// 处理盘口数据: books5 推送没有 checksum, 每次均为全量, 不做校验
 func (dr *DataRepo) handleBook5(m *Book5Message) error {
 	// 数据库上锁
-	dr.Mu.Lock()
+	dr.mu.Lock()
 	// 函数结束前解锁
-	defer dr.Mu.Unlock()
+	defer dr.mu.Unlock()
+	// 逐条数据
+	for i := range m.Data {
+		// 盘口不足五档, 策略按档位读取会越界
+		if !fullBook5(&m.Data[i]) {
+			// 返回错误
+			return fmt.Errorf("%v %v 盘口不足五档, 丢弃该推送", m.Arg.Channel, m.Arg.InstID)
+		}
+	}
 	// 追加数据
-	dr.Book5Data = append(dr.Book5Data, m.Data...)
+	dr.book5Data = append(dr.book5Data, m.Data...)
 	// 保留数据
-	dr.Book5Data = append(dr.Book5Data[:0], dr.Book5Data[Max(len(dr.Book5Data)-NBook5s, 0):]...)
+	dr.book5Data = append(dr.book5Data[:0], dr.book5Data[Max(len(dr.book5Data)-NBook5s, 0):]...)
 	// 判断数据是否为空
 	if len(m.Data) > 0 {
 		// 更新买价
-		dr.BidsPrice = 0.618*String2Float64(m.Data[0].Bids[0][0]) + 0.382*String2Float64(m.Data[0].Asks[0][0]) + config.Delta
+		dr.bidsPrice = 0.618*m.Data[0].Bids[0][0].Float64() + 0.382*m.Data[0].Asks[0][0].Float64() + config.Delta
 		// 更新卖价
-		dr.AsksPrice = 0.382*String2Float64(m.Data[0].Bids[0][0]) + 0.618*String2Float64(m.Data[0].Asks[0][0]) - config.Delta
+		dr.asksPrice = 0.382*m.Data[0].Bids[0][0].Float64() + 0.618*m.Data[0].Asks[0][0].Float64() - config.Delta
 		// 盘口加权价格
-		avgPrice := (String2Float64(m.Data[0].Asks[0][0])+String2Float64(m.Data[0].Bids[0][0]))*0.35 +
-			(String2Float64(m.Data[0].Asks[1][0])+String2Float64(m.Data[0].Bids[1][0]))*0.1 +
-			(String2Float64(m.Data[0].Asks[2][0])+String2Float64(m.Data[0].Bids[2][0]))*0.03 +
-			(String2Float64(m.Data[0].Asks[3][0])+String2Float64(m.Data[0].Bids[3][0]))*0.015 +
-			(String2Float64(m.Data[0].Asks[4][0])+String2Float64(m.Data[0].Bids[4][0]))*0.005
+		avgPrice := (m.Data[0].Asks[0][0].Float64()+m.Data[0].Bids[0][0].Float64())*0.35 +
+			(m.Data[0].Asks[1][0].Float64()+m.Data[0].Bids[1][0].Float64())*0.1 +
+			(m.Data[0].Asks[2][0].Float64()+m.Data[0].Bids[2][0].Float64())*0.03 +
+			(m.Data[0].Asks[3][0].Float64()+m.Data[0].Bids[3][0].Float64())*0.015 +
+			(m.Data[0].Asks[4][0].Float64()+m.Data[0].Bids[4][0].Float64())*0.005
 		// 追加数据
-		dr.Book5AvgData = append(dr.Book5AvgData, avgPrice)
+		dr.book5AvgData = append(dr.book5AvgData, avgPrice)
 		// 保留数据
-		dr.Book5AvgData = append(dr.Book5AvgData[:0], dr.Book5AvgData[Max(len(dr.Book5AvgData)-NBook5sAvg, 0):]...)
+		dr.book5AvgData = append(dr.book5AvgData[:0], dr.book5AvgData[Max(len(dr.book5AvgData)-NBook5sAvg, 0):]...)
+		// 最新盘口
+		book := m.Data[len(m.Data)-1]
+		// 卖方深度副本
+		book.Asks = copyLevels(book.Asks)
+		// 买方深度副本
+		book.Bids = copyLevels(book.Bids)
+		// 发布盘口事件
+		dr.publish(Event{Kind: EventBook, InstId: m.Arg.InstID, Book: book})
 	}
 	// 显示数据
-	// log.Println("[成功提示] 数据库盘口数据: ", dr.Book5Data)
+	// log.Println("[成功提示] 数据库盘口数据: ", dr.book5Data)
 	// 显示数据数目
-	// log.Println("[成功提示] 数据库盘口数据数目: ", len(dr.Book5Data))
+	// log.Println("[成功提示] 数据库盘口数据数目: ", len(dr.book5Data))
+	// 未出错返回
+	return nil
+}
+
+// 盘口买卖方各有五档, 且每档有价格
+func fullBook5(b *Book5) bool {
+	// 档位数目
+	if len(b.Bids) < 5 || len(b.Asks) < 5 {
+		// 返回
+		return false
+	}
+	// 逐档
+	for i := 0; i < 5; i++ {
+		// 缺少价格
+		if len(b.Bids[i]) == 0 || len(b.Asks[i]) == 0 {
+			// 返回
+			return false
+		}
+	}
+	// 返回
+	return true
+}
+
+// 处理深度数据: 全量推送重建, 增量推送合并, 校验失败时重新订阅
+func (dr *DataRepo) handleBook(m *BookMessage) error {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 深度数据 Key 值
+	key := BookKey(m.Arg.Channel, m.Arg.InstID)
+	// 本地深度
+	book, ok := dr.booksData[key]
+	// 首次推送
+	if !ok {
+		// 创建本地深度
+		book = NewOrderBook(m.Arg.Channel, m.Arg.InstID)
+		// 添加到数据库
+		dr.booksData[key] = book
+	}
+	// 是否需要重新订阅
+	resync := false
+	// 应用错误
+	var err error
+	// 逐条数据
+	for i := range m.Data {
+		// 应用推送
+		err = book.Apply(m.Action, &m.Data[i])
+		// 未同步时丢弃增量, 等待重新订阅后的全量推送
+		if err == ErrBookNotSynced {
+			// 不作为错误
+			err = nil
+			// 结束
+			break
+		}
+		// 校验失败或序列号不连续
+		if err != nil {
+			// 重新订阅
+			resync = true
+			// 结束
+			break
+		}
+	}
+	// 重新订阅处理
+	handler := dr.bookResync
+	// 解锁, 重新订阅处理在锁外执行
+	dr.mu.Unlock()
+	// 重新订阅
+	if resync && handler != nil {
+		// 处理
+		handler(m.Arg.Channel, m.Arg.InstID)
+	}
+	// 返回
+	return err
+}
+
+// 注册深度校验失败处理, 通常为重新订阅该频道以获取全量推送
+func (dr *DataRepo) OnBookResync(handler func(channel, instId string)) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 重新订阅处理
+	dr.bookResync = handler
+}
+
+// 已同步的深度副本
+func (dr *DataRepo) Book(channel, instId string) (OrderBook, bool) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 本地深度
+	book, ok := dr.booksData[BookKey(channel, instId)]
+	// 不存在或未同步
+	if !ok || !book.Synced {
+		// 返回
+		return OrderBook{}, false
+	}
+	// 返回副本
+	return book.Copy(), true
+}
+
+// 处理行情数据: 保留每个产品的最新数据
+func (dr *DataRepo) handleTicker(m *TickerMessage) error {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 逐条数据
+	for _, data := range m.Data {
+		// 更新数据
+		dr.tickerData[data.InstID] = data
+	}
+	// 未出错返回
+	return nil
+}
+
+// 处理标记价格: 保留每个产品的最新数据
+func (dr *DataRepo) handleMarkPrice(m *MarkPriceMessage) error {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 逐条数据
+	for _, data := range m.Data {
+		// 更新数据
+		dr.markPriceData[data.InstID] = data
+	}
+	// 未出错返回
+	return nil
+}
+
+// 处理指数行情: 保留每个产品的最新数据
+func (dr *DataRepo) handleIndexTicker(m *IndexTickerMessage) error {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 逐条数据
+	for _, data := range m.Data {
+		// 更新数据
+		dr.indexTickerData[data.InstID] = data
+	}
+	// 未出错返回
+	return nil
+}
+
+// 处理资金费率: 保留每个产品的最新数据
+func (dr *DataRepo) handleFundingRate(m *FundingRateMessage) error {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 逐条数据
+	for _, data := range m.Data {
+		// 更新数据
+		dr.fundingRateData[data.InstID] = data
+	}
+	// 未出错返回
+	return nil
+}
+
+// 处理持仓总量: 保留每个产品的最新数据
+func (dr *DataRepo) handleOpenInterest(m *OpenInterestMessage) error {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 逐条数据
+	for _, data := range m.Data {
+		// 更新数据
+		dr.openInterestData[data.InstID] = data
+	}
+	// 未出错返回
+	return nil
+}
+
+// 处理限价: 保留每个产品的最新数据
+func (dr *DataRepo) handlePriceLimit(m *PriceLimitMessage) error {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 逐条数据
+	for _, data := range m.Data {
+		// 更新数据
+		dr.priceLimitData[data.InstID] = data
+	}
+	// 未出错返回
+	return nil
+}
+
+// 处理强平订单: 保留最新的强平订单
+func (dr *DataRepo) handleLiquidation(m *LiquidationMessage) error {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 追加数据
+	dr.liquidationData = append(dr.liquidationData, m.Data...)
+	// 保留数据
+	dr.liquidationData = append(dr.liquidationData[:0], dr.liquidationData[Max(len(dr.liquidationData)-NLiquidation, 0):]...)
 	// 未出错返回
 	return nil
 }
@@ -185,11 +499,11 @@ func (dr *DataRepo) handleBook5(m *Book5Message) error {
 // 处理账户数据
 func (dr *DataRepo) handleAccount(m *AccountMessage) error {
 	// 数据库上锁
-	dr.Mu.Lock()
+	dr.mu.Lock()
 	// 函数结束前解锁
-	defer dr.Mu.Unlock()
+	defer dr.mu.Unlock()
 	// 追加数据
-	dr.AccountData = m.Data
+	dr.accountData = m.Data
 	// 判断数据是否为空
 	if len(m.Data) > 0 {
 		// 循环
@@ -197,18 +511,20 @@ func (dr *DataRepo) handleAccount(m *AccountMessage) error {
 			// Token
 			if m.Data[0].Details[i].Ccy == config.TokenInstID {
 				// 设置余额
-				dr.TokenAmt = String2Float64(m.Data[0].Details[i].CashBal)
+				dr.tokenAmt = String2Float64(m.Data[0].Details[i].CashBal)
 			}
 			// USDT
 			if m.Data[0].Details[i].Ccy == config.UsdtInstID {
 				// 设置余额
-				dr.UsdtAmt = String2Float64(m.Data[0].Details[i].CashBal)
+				dr.usdtAmt = String2Float64(m.Data[0].Details[i].CashBal)
 			}
 		}
 
 	}
+	// 发布账户事件
+	dr.publish(Event{Kind: EventAccount, Accounts: copyAccounts(m.Data)})
 	// 显示数据
-	// log.Println("[成功提示] 数据库账户数据: ", dr.AccountData)
+	// log.Println("[成功提示] 数据库账户数据: ", dr.accountData)
 	// 未出错返回
 	return nil
 }
@@ -216,107 +532,193 @@ func (dr *DataRepo) handleAccount(m *AccountMessage) error {
 // 处理订单数据: 本地订单簿维护
 func (dr *DataRepo) handleOrders(m *OrdersMessage) error {
 	// 数据库上锁
-	dr.Mu.Lock()
+	dr.mu.Lock()
 	// 函数结束前解锁
-	defer dr.Mu.Unlock()
+	defer dr.mu.Unlock()
 	// 添加订单
 	for i := 0; i < len(m.Data); i++ {
-		// 判断 PingPong 订单类型
-		if m.Data[i].InstId == config.PingPongInstID {
-			// 跳过操作
-			continue
-		}
 		// 初始化
 		newOrder := m.Data[i]
+		// 改单失败, 推送数据仍为原订单, 下方按推送替换本地订单, 撤销确认时写入的新价格和数量
+		if newOrder.AmendFailed() {
+			// 错误提示
+			log.Printf("[错误提示] 改单失败, 恢复原订单 clOrdId: %v, reqId: %v, code: %v, msg: %v", newOrder.ClOrdId, newOrder.ReqId, newOrder.Code, newOrder.Msg)
+			// 改单尚未确认, 确认到达时不再修改本地订单
+			if _, ok := dr.amends[newOrder.ReqId]; ok {
+				// 标记失败
+				dr.amends[newOrder.ReqId] = true
+			}
+		}
 		// 挂单
 		switch m.Data[i].State {
 		// live: 等待成交: 添加到数据库
 		case "live":
 			// 更新订单簿
-			dr.OrdersData[m.Data[i].ClOrdId] = &newOrder
+			dr.ordersData[m.Data[i].ClOrdId] = &newOrder
 		// partially_filled: 部分成交: 添加到数据库
 		case "partially_filled":
 			// 更新订单簿
-			dr.OrdersData[m.Data[i].ClOrdId] = &newOrder
+			dr.ordersData[m.Data[i].ClOrdId] = &newOrder
 		// canceled: 撤单成功: 从数据库删除
 		case "canceled":
 			// 删除元素, 不存在不会报错
-			delete(dr.OrdersData, m.Data[i].ClOrdId)
+			delete(dr.ordersData, m.Data[i].ClOrdId)
 		// filled: 完全成交: 从数据库删除
 		case "filled":
 			// 删除元素, 不存在不会报错
-			delete(dr.OrdersData, m.Data[i].ClOrdId)
+			delete(dr.ordersData, m.Data[i].ClOrdId)
 		// 其他情况
 		default:
 			// 暂时不处理
 			continue
 		}
 	}
+	// 发布订单事件
+	dr.publish(Event{Kind: EventOrder, InstId: m.Arg.InstId, Orders: append([]Orders(nil), m.Data...)})
 	// 显示数据
-	// log.Println("[成功提示] 数据库订单数据: ", dr.OrdersData)
+	// log.Println("[成功提示] 数据库订单数据: ", dr.ordersData)
 	// 未出错返回
 	return nil
 }
 
-// 处理持仓数据
-func (dr *DataRepo) handlePositions(m *PositionsMessage) error {
+// 处理下单结果: 被拒绝的本地订单立即删除, 成功的订单记录订单 ID
+func (dr *DataRepo) HandlePostResponse(clOrdIds []string, resp *OpResponse) {
 	// 数据库上锁
-	dr.Mu.Lock()
+	dr.mu.Lock()
 	// 函数结束前解锁
-	defer dr.Mu.Unlock()
-	// 追加数据
-	dr.PositionsData = m.Data
-	// 数据分类
-	for i := 0; i < len(dr.PositionsData); i++ {
-		// 多仓归类
-		if dr.PositionsData[i].PosSide == "long" {
-			/// 持仓方向
-			dr.PositionsLongData.PosSide = dr.PositionsData[i].PosSide
-			// 持仓数量
-			dr.PositionsLongData.Pos = dr.PositionsData[i].Pos
-			// 可平仓数量
-			dr.PositionsLongData.AvailPos = dr.PositionsData[i].AvailPos
-			// 开仓平均价
-			dr.PositionsLongData.AvgPx = dr.PositionsData[i].AvgPx
-			// 未实现收益
-			dr.PositionsLongData.Upl = dr.PositionsData[i].Upl
-			// 未实现收益率
-			dr.PositionsLongData.UplRatio = dr.PositionsData[i].UplRatio
-			// 杠杆倍数
-			dr.PositionsLongData.Lever = dr.PositionsData[i].Lever
-			// 预估强平价
-			dr.PositionsLongData.LiqPx = dr.PositionsData[i].LiqPx
-			// 标记价格
-			dr.PositionsLongData.MarkPx = dr.PositionsData[i].MarkPx
-			// 跳过
-			continue
+	defer dr.mu.Unlock()
+	// 整个请求失败, 没有逐个订单结果
+	if len(resp.Data) == 0 && resp.Code != "0" {
+		// 错误提示
+		log.Printf("[错误提示] 下单请求被拒绝 code: %v, msg: %v", resp.Code, resp.Msg)
+		// 逐个订单
+		for _, clOrdId := range clOrdIds {
+			// 删除本地订单
+			dr.removeLocalOrder(clOrdId)
 		}
-		// 空仓归类
-		if dr.PositionsData[i].PosSide == "short" {
-			/// 持仓方向
-			dr.PositionsShortData.PosSide = dr.PositionsData[i].PosSide
-			// 持仓数量
-			dr.PositionsShortData.Pos = dr.PositionsData[i].Pos
-			// 可平仓数量
-			dr.PositionsShortData.AvailPos = dr.PositionsData[i].AvailPos
-			// 开仓平均价
-			dr.PositionsShortData.AvgPx = dr.PositionsData[i].AvgPx
-			// 未实现收益
-			dr.PositionsShortData.Upl = dr.PositionsData[i].Upl
-			// 未实现收益率
-			dr.PositionsShortData.UplRatio = dr.PositionsData[i].UplRatio
-			// 杠杆倍数
-			dr.PositionsShortData.Lever = dr.PositionsData[i].Lever
-			// 预估强平价
-			dr.PositionsShortData.LiqPx = dr.PositionsData[i].LiqPx
-			// 标记价格
-			dr.PositionsShortData.MarkPx = dr.PositionsData[i].MarkPx
+		// 返回
+		return
+	}
+	// 逐个订单结果
+	for _, result := range resp.Data {
+		// 下单失败
+		if !result.Ok() {
+			// 错误提示
+			log.Printf("[错误提示] 订单被拒绝 clOrdId: %v, sCode: %v, sMsg: %v", result.ClOrdId, result.SCode, result.SMsg)
+			// 删除本地订单
+			dr.removeLocalOrder(result.ClOrdId)
 			// 跳过
 			continue
 		}
+		// 本地订单记录订单 ID
+		if val, ok := dr.ordersData[result.ClOrdId]; ok && val.State == "local" {
+			// 订单 ID
+			val.OrdId = result.OrdId
+		}
+	}
+}
+
+// 记录发出的改单, 确认前收到的改单失败推送据此识别
+func (dr *DataRepo) BeginAmends(reqIds []string) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 逐个改单
+	for _, reqId := range reqIds {
+		// 未收到失败推送
+		dr.amends[reqId] = false
+	}
+}
+
+// 结束改单记录: 请求未发出, 连接断开或确认已处理
+func (dr *DataRepo) EndAmends(reqIds []string) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 逐个改单
+	for _, reqId := range reqIds {
+		// 删除记录
+		delete(dr.amends, reqId)
+	}
+}
+
+// 改单成功后更新本地订单的价格和数量; 确认前已收到改单失败推送时不修改
+func (dr *DataRepo) AmendLocalOrder(reqId, clOrdId, ordId string, newSz, newPx Decimal) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 是否已收到改单失败推送
+	failed := dr.amends[reqId]
+	// 确认已处理
+	delete(dr.amends, reqId)
+	// 改单已失败, 本地订单为推送的原订单
+	if failed {
+		// 普通提示
+		log.Printf("[普通提示] 改单确认前已收到失败推送, 不修改本地订单 clOrdId: %v, reqId: %v", clOrdId, reqId)
+		// 返回
+		return
+	}
+	// 按用户订单 ID 查找
+	order, ok := dr.ordersData[clOrdId]
+	// 未找到时按订单 ID 查找
+	if !ok {
+		// 逐个订单
+		for _, val := range dr.ordersData {
+			// 订单 ID 匹配
+			if ordId != "" && val.OrdId == ordId {
+				// 找到订单
+				order, ok = val, true
+				// 跳出循环
+				break
+			}
+		}
+	}
+	// 订单不存在, 可能已成交或已撤销
+	if !ok {
+		// 返回
+		return
+	}
+	// 修改数量
+	if !newSz.IsEmpty() {
+		// 更新数量
+		order.Sz = newSz
+	}
+	// 修改价格
+	if !newPx.IsEmpty() {
+		// 更新价格
+		order.Px = newPx
+	}
+}
+
+// 删除仍处于本地状态的订单, 需在持有锁时调用
+func (dr *DataRepo) removeLocalOrder(clOrdId string) {
+	// 判断订单是否仍为本地状态
+	if val, ok := dr.ordersData[clOrdId]; ok && val.State == "local" {
+		// 删除订单
+		delete(dr.ordersData, clOrdId)
+	}
+}
+
+// 处理持仓数据
+func (dr *DataRepo) handlePositions(m *PositionsMessage) error {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 追加数据
+	dr.positionsData = m.Data
+	// 按持仓方向归类, 未推送的方向保留原数据
+	for _, p := range m.Data {
+		// 更新持仓
+		dr.positionsBySide[p.PosSide] = p
 	}
+	// 发布持仓事件
+	dr.publish(Event{Kind: EventPosition, InstId: m.Arg.InstId, Positions: append([]Positions(nil), m.Data...)})
 	// 显示数据
-	// log.Println("[成功提示] 数据库持仓数据: ", dr.PositionsData)
+	// log.Println("[成功提示] 数据库持仓数据: ", dr.positionsData)
 	// 未出错返回
 	return nil
 }
@@ -0,0 +1,260 @@
+package database
+
+import (
+	"sort"
+
+	. "github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 快照方法在锁内复制数据后返回, 调用方可任意读取, 不受之后推送的影响
+
+// 最新的盘口数据副本
+func (dr *DataRepo) LatestBook() (Book5, bool) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 没有数据
+	if len(dr.book5Data) == 0 {
+		// 返回
+		return Book5{}, false
+	}
+	// 最新数据
+	book := dr.book5Data[len(dr.book5Data)-1]
+	// 卖方深度副本
+	book.Asks = copyLevels(book.Asks)
+	// 买方深度副本
+	book.Bids = copyLevels(book.Bids)
+	// 返回
+	return book, true
+}
+
+// 深度档位副本
+func copyLevels(levels [][]Decimal) [][]Decimal {
+	// 副本初始化
+	out := make([][]Decimal, len(levels))
+	// 逐档复制
+	for i := range levels {
+		// 档位副本
+		out[i] = append([]Decimal(nil), levels[i]...)
+	}
+	// 返回
+	return out
+}
+
+// 最近 n 条交易数据副本, 按时间先后; n <= 0 时返回全部
+func (dr *DataRepo) RecentTrades(n int) []Trade {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 起始位置
+	start := 0
+	// 只取最近 n 条
+	if n > 0 {
+		// 起始位置
+		start = Max(len(dr.tradeData)-n, 0)
+	}
+	// 返回副本
+	return append([]Trade(nil), dr.tradeData[start:]...)
+}
+
+// 盘口加权价格副本, 按时间先后
+func (dr *DataRepo) Book5AvgPrices() []float64 {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 返回副本
+	return append([]float64(nil), dr.book5AvgData...)
+}
+
+// 已收集的交易数据和盘口数据数目
+func (dr *DataRepo) MarketDataLen() (int, int) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 返回
+	return len(dr.tradeData), len(dr.book5Data)
+}
+
+// 未完成订单副本, 按用户订单 ID 排序; 包含已发出等待确认的本地订单 (State 为 local)
+func (dr *DataRepo) OpenOrders() []Orders {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 副本初始化
+	orders := make([]Orders, 0, len(dr.ordersData))
+	// 逐个订单
+	for _, val := range dr.ordersData {
+		// 添加副本
+		orders = append(orders, *val)
+	}
+	// 排序
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ClOrdId < orders[j].ClOrdId })
+	// 返回
+	return orders
+}
+
+// 持仓方向 (long / short / net) 的最新持仓副本, 没有持仓数据时返回空持仓
+func (dr *DataRepo) Position(side string) Positions {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 返回
+	return dr.positionsBySide[side]
+}
+
+// 账户数据副本
+func (dr *DataRepo) Accounts() []Account {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 返回副本
+	return copyAccounts(dr.accountData)
+}
+
+// 账户数据副本, 包含币种明细
+func copyAccounts(list []Account) []Account {
+	// 副本初始化
+	accounts := make([]Account, len(list))
+	// 逐个账户
+	for i := range list {
+		// 账户副本
+		accounts[i] = list[i]
+		// 币种明细副本
+		accounts[i].Details = append([]AccountDetails(nil), list[i].Details...)
+	}
+	// 返回
+	return accounts
+}
+
+// Token 和 USDT 余额
+func (dr *DataRepo) Balances() (float64, float64) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 返回
+	return dr.tokenAmt, dr.usdtAmt
+}
+
+// 产品的最新行情
+func (dr *DataRepo) Ticker(instId string) (Ticker, bool) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 行情数据
+	data, ok := dr.tickerData[instId]
+	// 返回
+	return data, ok
+}
+
+// 产品的最新标记价格
+func (dr *DataRepo) MarkPrice(instId string) (MarkPrice, bool) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 标记价格
+	data, ok := dr.markPriceData[instId]
+	// 返回
+	return data, ok
+}
+
+// 指数的最新行情
+func (dr *DataRepo) IndexTicker(instId string) (IndexTicker, bool) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 指数行情
+	data, ok := dr.indexTickerData[instId]
+	// 返回
+	return data, ok
+}
+
+// 产品的最新资金费率
+func (dr *DataRepo) FundingRate(instId string) (FundingRate, bool) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 资金费率
+	data, ok := dr.fundingRateData[instId]
+	// 返回
+	return data, ok
+}
+
+// 产品的最新持仓总量
+func (dr *DataRepo) OpenInterest(instId string) (OpenInterest, bool) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 持仓总量
+	data, ok := dr.openInterestData[instId]
+	// 返回
+	return data, ok
+}
+
+// 产品的最新限价
+func (dr *DataRepo) PriceLimit(instId string) (PriceLimit, bool) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 限价
+	data, ok := dr.priceLimitData[instId]
+	// 返回
+	return data, ok
+}
+
+// 最近 n 条强平订单副本; n <= 0 时返回全部
+func (dr *DataRepo) RecentLiquidations(n int) []Liquidation {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 起始位置
+	start := 0
+	// 只取最近 n 条
+	if n > 0 {
+		// 起始位置
+		start = Max(len(dr.liquidationData)-n, 0)
+	}
+	// 返回副本
+	return append([]Liquidation(nil), dr.liquidationData[start:]...)
+}
+
+// 添加本地订单: 订单发出后等待交易所确认, 期间策略可见, 避免重复下单; instIds 与 clOrdIds 一一对应
+func (dr *DataRepo) AddLocalOrders(instIds, clOrdIds []string) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 逐个订单
+	for i, clOrdId := range clOrdIds {
+		// 本地订单, 记录产品 ID 以便确认后撤单
+		dr.ordersData[clOrdId] = &Orders{InstId: instIds[i], ClOrdId: clOrdId, State: "local"}
+	}
+}
+
+// 删除仍处于本地状态的订单, 用于订单未成功发出
+func (dr *DataRepo) RemoveLocalOrders(clOrdIds []string) {
+	// 数据库上锁
+	dr.mu.Lock()
+	// 函数结束前解锁
+	defer dr.mu.Unlock()
+	// 逐个订单
+	for _, clOrdId := range clOrdIds {
+		// 删除本地订单
+		dr.removeLocalOrder(clOrdId)
+	}
+}
@@ -0,0 +1,853 @@
+package mockokx
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/client"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 单个交易品种的行情
+type market struct {
+	// 产品 ID
+	instID string
+	// 中间价
+	mid float64
+}
+
+// 撮合引擎: 随机游走行情, 订单全部成交或挂单等待
+type engine struct {
+	// 配置
+	cfg Config
+	// 模拟交易所, 用于推送数据
+	srv *Server
+	// 并发锁
+	mux sync.Mutex
+	// 随机数
+	rand *rand.Rand
+	// 价格小数位数
+	prec int
+	// 行情
+	markets map[string]*market
+	// 挂单: 订单 ID -> 订单
+	orders map[string]*Orders
+	// 订单 ID 计数
+	nextOrdId int64
+	// 成交 ID 计数
+	nextTradeId int64
+	// 币种余额
+	balances map[string]float64
+	// 持仓: 产品 ID|持仓方向 -> 持仓
+	positions map[string]*Positions
+}
+
+// 创建撮合引擎
+func newEngine(cfg Config, srv *Server) *engine {
+	// 引擎初始化
+	e := &engine{
+		// 配置
+		cfg: cfg,
+		// 模拟交易所
+		srv: srv,
+		// 随机数
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+		// 价格小数位数
+		prec: int(math.Max(0, math.Ceil(-math.Log10(cfg.TickSz)-1e-9))),
+		// 行情
+		markets: make(map[string]*market),
+		// 挂单
+		orders: make(map[string]*Orders),
+		// 订单 ID 计数
+		nextOrdId: time.Now().UnixNano() / 1e6,
+		// 成交 ID 计数
+		nextTradeId: 1,
+		// 币种余额
+		balances: make(map[string]float64),
+		// 持仓
+		positions: make(map[string]*Positions),
+	}
+	// 逐个交易品种
+	for _, instID := range cfg.InstIDs {
+		// 行情初始化
+		e.markets[instID] = &market{instID: instID, mid: cfg.InitPx}
+		// 交易币, 计价币
+		base, quote := splitInstID(instID)
+		// 交易币余额
+		e.balances[base] = cfg.BaseBal
+		// 计价币余额
+		e.balances[quote] = cfg.QuoteBal
+	}
+	// 返回
+	return e
+}
+
+// 拆分产品 ID 为交易币和计价币
+func splitInstID(instID string) (string, string) {
+	// 按 - 拆分
+	parts := strings.Split(instID, "-")
+	// 格式不符
+	if len(parts) < 2 {
+		// 返回
+		return instID, ""
+	}
+	// 返回
+	return parts[0], parts[1]
+}
+
+// 产品类型
+func instType(instID string) string {
+	// 永续合约
+	if strings.HasSuffix(instID, "-SWAP") {
+		// 返回
+		return "SWAP"
+	}
+	// 币币
+	return "SPOT"
+}
+
+// 当前毫秒时间戳字符串
+func nowMs() string {
+	// 返回
+	return strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+}
+
+// 价格格式化
+func (e *engine) fmtPx(px float64) Decimal {
+	// 按价格精度取整
+	return MustDecimal(strconv.FormatFloat(math.Round(px/e.cfg.TickSz)*e.cfg.TickSz, 'f', e.prec, 64))
+}
+
+// 数量格式化
+func fmtSz(sz float64) Decimal {
+	// 返回
+	return DecimalFromFloat(sz)
+}
+
+// 买一价, 卖一价
+func (e *engine) bestBidAsk(m *market) (float64, float64) {
+	// 买一价: 中间价向下取整
+	bid := math.Floor(m.mid/e.cfg.TickSz) * e.cfg.TickSz
+	// 卖一价: 买一价加一档
+	return bid, bid + e.cfg.TickSz
+}
+
+// 行情循环
+func (e *engine) run(done chan struct{}) {
+	// 定时器
+	ticker := time.NewTicker(e.cfg.Interval)
+	// 函数结束前停止定时器
+	defer ticker.Stop()
+	// 循环
+	for {
+		// 事件选择
+		select {
+		// 停止
+		case <-done:
+			// 返回
+			return
+		// 推送行情
+		case <-ticker.C:
+			// 逐个交易品种
+			for instID := range e.markets {
+				// 行情变动
+				e.tick(instID)
+			}
+		}
+	}
+}
+
+// 单个交易品种行情变动: 推送成交和盘口, 撮合挂单
+func (e *engine) tick(instID string) {
+	// 上锁
+	e.mux.Lock()
+	// 行情
+	m := e.markets[instID]
+	// 随机游走 -2 到 2 档
+	m.mid += float64(e.rand.Intn(5)-2) * e.cfg.TickSz
+	// 价格不低于一档
+	m.mid = math.Max(m.mid, e.cfg.TickSz*2)
+	// 买一价, 卖一价
+	bid, ask := e.bestBidAsk(m)
+	// 成交数据
+	trades := make([]Trade, 0, 3)
+	// 随机 1 到 3 笔成交
+	for i := e.rand.Intn(3); i >= 0; i-- {
+		// 成交方向
+		side, px := "buy", ask
+		// 一半概率为卖
+		if e.rand.Intn(2) == 0 {
+			// 卖出成交在买一价
+			side, px = "sell", bid
+		}
+		// 添加成交
+		trades = append(trades, Trade{
+			// 产品 ID
+			InstID: instID,
+			// 成交 ID
+			TradeID: strconv.FormatInt(e.nextTradeId, 10),
+			// 成交价格
+			Px: e.fmtPx(px),
+			// 成交数量
+			Sz: fmtSz(float64(1 + e.rand.Intn(500))),
+			// 成交方向
+			Side: side,
+			// 成交时间
+			Ts: nowMs(),
+		})
+		// 成交 ID 自增
+		e.nextTradeId++
+	}
+	// 盘口数据
+	book := Book5{Ts: nowMs()}
+	// 五档深度
+	for i := 0; i < 5; i++ {
+		// 卖方深度
+		book.Asks = append(book.Asks, []Decimal{e.fmtPx(ask + float64(i)*e.cfg.TickSz), fmtSz(float64(100 + e.rand.Intn(5000))), DecimalFromInt(0), DecimalFromInt(int64(1 + e.rand.Intn(10)))})
+		// 买方深度
+		book.Bids = append(book.Bids, []Decimal{e.fmtPx(bid - float64(i)*e.cfg.TickSz), fmtSz(float64(100 + e.rand.Intn(5000))), DecimalFromInt(0), DecimalFromInt(int64(1 + e.rand.Intn(10)))})
+	}
+	// 撮合挂单
+	updates, filled := e.matchResting(m)
+	// 解锁
+	e.mux.Unlock()
+
+	// 推送成交
+	e.srv.broadcast(false, "trades", instID, func(arg channelArg) interface{} {
+		// 成交推送
+		var tm TradeMessage
+		// 频道名
+		tm.Arg.Channel = arg.Channel
+		// 产品 ID
+		tm.Arg.InstID = instID
+		// 成交数据
+		tm.Data = trades
+		// 返回
+		return &tm
+	})
+	// 推送盘口
+	e.srv.broadcast(false, "books5", instID, func(arg channelArg) interface{} {
+		// 盘口推送
+		var bm Book5Message
+		// 频道名
+		bm.Arg.Channel = arg.Channel
+		// 产品 ID
+		bm.Arg.InstID = instID
+		// 盘口数据
+		bm.Data = []Book5{book}
+		// 返回
+		return &bm
+	})
+	// 推送订单和成交后的账户变化
+	e.pushUpdates(instID, updates, filled)
+}
+
+// 撮合挂单: 价格穿过盘口的挂单全部成交, 需在持有锁时调用
+func (e *engine) matchResting(m *market) ([]Orders, bool) {
+	// 买一价, 卖一价
+	bid, ask := e.bestBidAsk(m)
+	// 订单变化
+	var updates []Orders
+	// 挂单 ID 排序, 保证撮合顺序稳定
+	ids := make([]string, 0, len(e.orders))
+	// 逐个挂单
+	for id := range e.orders {
+		// 添加 ID
+		ids = append(ids, id)
+	}
+	// 排序
+	sort.Strings(ids)
+	// 逐个挂单
+	for _, id := range ids {
+		// 挂单
+		o := e.orders[id]
+		// 非当前品种
+		if o.InstId != m.instID {
+			// 跳过
+			continue
+		}
+		// 委托价
+		px := o.Px.Float64()
+		// 买单价格不低于卖一价, 或卖单价格不高于买一价
+		if (o.Side == "buy" && px >= ask) || (o.Side == "sell" && px <= bid) {
+			// 挂单以委托价成交
+			e.fill(o, px, "M")
+			// 移除挂单
+			delete(e.orders, id)
+			// 添加变化
+			updates = append(updates, *o)
+		}
+	}
+	// 返回
+	return updates, len(updates) > 0
+}
+
+// 订单全部成交并更新余额或持仓, 需在持有锁时调用
+func (e *engine) fill(o *Orders, px float64, execType string) {
+	// 数量
+	sz := o.Sz.Float64()
+	// 成交时间
+	ts := nowMs()
+	// 订单状态
+	o.State = "filled"
+	// 最新成交价格
+	o.FillPx = e.fmtPx(px)
+	// 最新成交数量
+	o.FillSz = o.Sz
+	// 累计成交数量
+	o.AccFillSz = o.Sz
+	// 成交均价
+	o.AvgPx = o.FillPx
+	// 最新成交时间
+	o.FillTime = ts
+	// 订单更新时间
+	o.UTime = ts
+	// 最新成交 ID
+	o.TradeId = strconv.FormatInt(e.nextTradeId, 10)
+	// 成交 ID 自增
+	e.nextTradeId++
+	// 流动性方向
+	o.ExecType = execType
+	// 手续费: 千分之一
+	o.FillFee = fmtSz(-sz * px * 0.001)
+	// 订单手续费
+	o.Fee = o.FillFee
+
+	// 交易币, 计价币
+	base, quote := splitInstID(o.InstId)
+	// 非保证金模式: 更新余额
+	if o.TdMode == "cash" {
+		// 买入
+		if o.Side == "buy" {
+			// 交易币增加
+			e.balances[base] += sz
+			// 计价币减少
+			e.balances[quote] -= sz * px * 1.001
+		} else {
+			// 交易币减少
+			e.balances[base] -= sz
+			// 计价币增加
+			e.balances[quote] += sz * px * 0.999
+		}
+		// 返回
+		return
+	}
+
+	// 持仓方向
+	posSide := o.PosSide
+	// 默认买卖模式
+	if posSide == "" {
+		// 买卖模式
+		posSide = "net"
+	}
+	// 持仓 Key 值
+	key := o.InstId + "|" + posSide
+	// 持仓
+	p, ok := e.positions[key]
+	// 新建持仓
+	if !ok {
+		// 持仓初始化
+		p = &Positions{InstType: instType(o.InstId), InstId: o.InstId, PosSide: posSide, MgnMode: o.TdMode, Pos: DecimalFromInt(0), CTime: ts, Ccy: quote}
+		// 添加持仓
+		e.positions[key] = p
+	}
+	// 持仓数量
+	pos := p.Pos.Float64()
+	// 开仓均价
+	avgPx := p.AvgPx.Float64()
+	// 数量变化: 多仓买入增加, 空仓卖出增加, 买卖模式买入为正
+	delta := sz
+	// 减少持仓的方向
+	if (posSide == "long" && o.Side == "sell") || (posSide == "short" && o.Side == "buy") || (posSide == "net" && o.Side == "sell") {
+		// 数量为负
+		delta = -sz
+	}
+	// 新持仓数量
+	newPos := pos + delta
+	// 加仓时更新开仓均价
+	if math.Abs(newPos) > math.Abs(pos) {
+		// 加权均价
+		avgPx = (avgPx*math.Abs(pos) + px*sz) / math.Abs(newPos)
+	}
+	// 持仓数量
+	p.Pos = fmtSz(newPos)
+	// 可平仓数量
+	p.AvailPos = fmtSz(math.Abs(newPos))
+	// 开仓均价
+	p.AvgPx = e.fmtPx(avgPx)
+	// 最新成交 ID
+	p.TradeId = o.TradeId
+	// 持仓更新时间
+	p.UTime = ts
+	// 持仓推送时间
+	p.PTime = ts
+	// 计价币扣除手续费
+	e.balances[quote] -= sz * px * 0.001
+}
+
+// 推送订单变化, 有成交时推送持仓和账户
+func (e *engine) pushUpdates(instID string, updates []Orders, filled bool) {
+	// 没有变化
+	if len(updates) == 0 {
+		// 返回
+		return
+	}
+	// 推送订单
+	e.srv.broadcast(true, "orders", instID, func(arg channelArg) interface{} {
+		// 订单推送
+		var om OrdersMessage
+		// 频道名
+		om.Arg.Channel = arg.Channel
+		// 产品类型
+		om.Arg.InstType = arg.InstType
+		// 产品 ID
+		om.Arg.InstId = arg.InstID
+		// 订单数据
+		om.Data = updates
+		// 返回
+		return &om
+	})
+	// 没有成交
+	if !filled {
+		// 返回
+		return
+	}
+	// 推送持仓
+	e.srv.broadcast(true, "positions", instID, func(arg channelArg) interface{} {
+		// 返回
+		return e.positionsMessage(arg)
+	})
+	// 推送账户
+	e.srv.broadcast(true, "account", "", func(arg channelArg) interface{} {
+		// 返回
+		return e.accountMessage(arg)
+	})
+}
+
+// 订阅后推送当前快照
+func (e *engine) pushSnapshot(sess *session, arg channelArg) {
+	// 频道选择
+	switch arg.Channel {
+	// 账户
+	case "account":
+		// 推送账户
+		sess.writeJSON(e.accountMessage(arg))
+	// 持仓
+	case "positions":
+		// 推送持仓
+		sess.writeJSON(e.positionsMessage(arg))
+	// 产品信息
+	case "instruments":
+		// 推送产品信息
+		sess.writeJSON(e.instrumentsMessage(arg))
+	}
+}
+
+// 产品信息推送
+func (e *engine) instrumentsMessage(arg channelArg) *InstrumentsMessage {
+	// 产品信息推送
+	var im InstrumentsMessage
+	// 频道名
+	im.Arg.Channel = arg.Channel
+	// 产品类型
+	im.Arg.InstType = arg.InstType
+	// 产品信息
+	im.Data = []Instrument{}
+	// 逐个交易品种
+	for _, instID := range e.cfg.InstIDs {
+		// 产品类型过滤
+		if arg.InstType != "" && arg.InstType != instType(instID) {
+			// 跳过
+			continue
+		}
+		// 交易币, 计价币
+		base, quote := splitInstID(instID)
+		// 添加产品信息
+		im.Data = append(im.Data, Instrument{
+			// 产品类型
+			InstType: instType(instID),
+			// 产品 ID
+			InstId: instID,
+			// 交易货币币种
+			BaseCcy: base,
+			// 计价货币币种
+			QuoteCcy: quote,
+			// 下单价格精度
+			TickSz: DecimalFromFloat(e.cfg.TickSz),
+			// 下单数量精度
+			LotSz: DecimalFromFloat(e.cfg.LotSz),
+			// 最小下单数量
+			MinSz: DecimalFromFloat(e.cfg.MinSz),
+			// 产品状态
+			State: "live",
+		})
+	}
+	// 返回
+	return &im
+}
+
+// 账户推送
+func (e *engine) accountMessage(arg channelArg) *AccountMessage {
+	// 上锁
+	e.mux.Lock()
+	// 函数结束前解锁
+	defer e.mux.Unlock()
+	// 账户数据
+	account := Account{UTime: nowMs()}
+	// 币种排序
+	ccys := make([]string, 0, len(e.balances))
+	// 逐个币种
+	for ccy := range e.balances {
+		// 添加币种
+		ccys = append(ccys, ccy)
+	}
+	// 排序
+	sort.Strings(ccys)
+	// 逐个币种
+	for _, ccy := range ccys {
+		// 余额
+		bal := fmtSz(e.balances[ccy]).String()
+		// 添加资产详情
+		account.Details = append(account.Details, AccountDetails{Ccy: ccy, Eq: bal, CashBal: bal, AvailBal: bal, AvailEq: bal, UTime: account.UTime})
+	}
+	// 账户推送
+	var am AccountMessage
+	// 频道名
+	am.Arg.Channel = arg.Channel
+	// 币种
+	am.Arg.Ccy = arg.Ccy
+	// 账户数据
+	am.Data = []Account{account}
+	// 返回
+	return &am
+}
+
+// 持仓推送
+func (e *engine) positionsMessage(arg channelArg) *PositionsMessage {
+	// 上锁
+	e.mux.Lock()
+	// 函数结束前解锁
+	defer e.mux.Unlock()
+	// 持仓推送
+	var pm PositionsMessage
+	// 频道名
+	pm.Arg.Channel = arg.Channel
+	// 产品类型
+	pm.Arg.InstType = arg.InstType
+	// 产品 ID
+	pm.Arg.InstId = arg.InstID
+	// 持仓数据
+	pm.Data = []Positions{}
+	// 逐个持仓
+	for _, p := range e.positions {
+		// 产品 ID 过滤
+		if arg.InstID == "" || arg.InstID == p.InstId {
+			// 添加持仓
+			pm.Data = append(pm.Data, *p)
+		}
+	}
+	// 返回
+	return &pm
+}
+
+// 处理订单操作: 下单, 撤单, 改单
+func (e *engine) handleOp(sess *session, req request) {
+	// 逐个订单结果
+	var results []OpResult
+	// 订单变化, 按产品 ID 分组
+	updates := make(map[string][]Orders)
+	// 是否有成交
+	filled := make(map[string]bool)
+	// 上锁
+	e.mux.Lock()
+	// 逐个参数
+	for _, raw := range req.Args {
+		// 单个结果
+		var result OpResult
+		// 订单变化
+		var update *Orders
+		// 操作分类
+		switch req.Op {
+		// 下单
+		case "order", "batch-orders":
+			// 参数初始化
+			var arg PostOrder
+			// 解析参数
+			json.Unmarshal(raw, &arg)
+			// 下单
+			result, update = e.place(arg)
+		// 撤单
+		case "cancel-order", "batch-cancel-orders":
+			// 参数初始化
+			var arg CancelOrder
+			// 解析参数
+			json.Unmarshal(raw, &arg)
+			// 撤单
+			result, update = e.cancel(arg)
+		// 改单
+		case "amend-order", "batch-amend-orders":
+			// 参数初始化
+			var arg AmendOrder
+			// 解析参数
+			json.Unmarshal(raw, &arg)
+			// 改单
+			result, update = e.amend(arg)
+		}
+		// 添加结果
+		results = append(results, result)
+		// 有订单变化
+		if update != nil {
+			// 添加变化
+			updates[update.InstId] = append(updates[update.InstId], *update)
+			// 记录成交
+			filled[update.InstId] = filled[update.InstId] || update.State == "filled"
+		}
+	}
+	// 解锁
+	e.mux.Unlock()
+
+	// 失败数目
+	failed := 0
+	// 逐个结果
+	for i := range results {
+		// 失败
+		if !results[i].Ok() {
+			// 计数
+			failed++
+		}
+	}
+	// 请求结果 code: 0 全部成功, 1 全部失败, 2 部分成功
+	code, msg := "0", ""
+	// 全部失败
+	if failed > 0 && failed == len(results) {
+		// 全部失败
+		code, msg = "1", "Operation failed."
+	} else if failed > 0 {
+		// 部分成功
+		code, msg = "2", "Bulk operation partially succeeded."
+	}
+	// 返回响应
+	sess.writeJSON(opResponse(req.Id, req.Op, code, msg, results))
+	// 逐个产品推送变化
+	for instID, list := range updates {
+		// 推送变化
+		e.pushUpdates(instID, list, filled[instID])
+	}
+}
+
+// 下单, 需在持有锁时调用
+func (e *engine) place(arg PostOrder) (OpResult, *Orders) {
+	// 结果
+	result := OpResult{ClOrdId: arg.ClOrdId, Tag: arg.Tag, SCode: "0"}
+	// 行情
+	m, ok := e.markets[arg.InstId]
+	// 产品不存在
+	if !ok {
+		// 返回错误
+		result.SCode, result.SMsg = "51001", "Instrument ID does not exist."
+		// 返回
+		return result, nil
+	}
+	// 数量
+	sz := arg.Sz.Float64()
+	// 数量错误
+	if sz <= 0 {
+		// 返回错误
+		result.SCode, result.SMsg = "51000", "Parameter sz error"
+		// 返回
+		return result, nil
+	}
+	// 委托价
+	px := arg.Px.Float64()
+	// 限价类订单委托价错误
+	if arg.OrdType != "market" && px <= 0 {
+		// 返回错误
+		result.SCode, result.SMsg = "51000", "Parameter px error"
+		// 返回
+		return result, nil
+	}
+	// 订单 ID 自增
+	e.nextOrdId++
+	// 订单 ID
+	result.OrdId = strconv.FormatInt(e.nextOrdId, 10)
+	// 创建时间
+	ts := nowMs()
+	// 订单
+	o := &Orders{
+		// 产品类型
+		InstType: instType(arg.InstId),
+		// 产品 ID
+		InstId: arg.InstId,
+		// 保证金币种
+		Ccy: arg.Ccy,
+		// 订单 ID
+		OrdId: result.OrdId,
+		// 用户订单 ID
+		ClOrdId: arg.ClOrdId,
+		// 订单标签
+		Tag: arg.Tag,
+		// 委托价格
+		Px: arg.Px,
+		// 原始委托数量
+		Sz: arg.Sz,
+		// 订单类型
+		OrdType: arg.OrdType,
+		// 订单方向
+		Side: arg.Side,
+		// 持仓方向
+		PosSide: arg.PosSide,
+		// 交易模式
+		TdMode: arg.TdMode,
+		// 市价单委托数量的类型
+		TgtCcy: arg.TgtCcy,
+		// 累计成交数量
+		AccFillSz: DecimalFromInt(0),
+		// 订单状态
+		State: "live",
+		// 订单更新时间
+		UTime: ts,
+		// 订单创建时间
+		CTime: ts,
+	}
+	// 买一价, 卖一价
+	bid, ask := e.bestBidAsk(m)
+	// 是否穿过盘口, 以及对手价
+	crossing, fillPx := false, px
+	// 买单
+	if arg.Side == "buy" {
+		// 市价单或价格不低于卖一价
+		crossing, fillPx = arg.OrdType == "market" || px >= ask, ask
+	} else {
+		// 市价单或价格不高于买一价
+		crossing, fillPx = arg.OrdType == "market" || px <= bid, bid
+	}
+	// 订单类型
+	switch {
+	// 只做 maker 单穿过盘口: 撤单
+	case crossing && arg.OrdType == "post_only":
+		// 撤单
+		o.State = "canceled"
+	// 穿过盘口: 以对手价成交
+	case crossing:
+		// 成交
+		e.fill(o, fillPx, "T")
+	// 未穿过盘口的立即成交类订单: 撤单
+	case arg.OrdType == "ioc" || arg.OrdType == "fok":
+		// 撤单
+		o.State = "canceled"
+	// 挂单
+	default:
+		// 添加挂单
+		e.orders[o.OrdId] = o
+	}
+	// 返回
+	return result, o
+}
+
+// 查找挂单, 需在持有锁时调用
+func (e *engine) find(ordId, clOrdId string) *Orders {
+	// 按订单 ID 查找
+	if o, ok := e.orders[ordId]; ok {
+		// 返回
+		return o
+	}
+	// 按用户订单 ID 查找
+	for _, o := range e.orders {
+		// 用户订单 ID 匹配
+		if clOrdId != "" && o.ClOrdId == clOrdId {
+			// 返回
+			return o
+		}
+	}
+	// 未找到
+	return nil
+}
+
+// 撤单, 需在持有锁时调用
+func (e *engine) cancel(arg CancelOrder) (OpResult, *Orders) {
+	// 结果
+	result := OpResult{ClOrdId: arg.ClOrdId, OrdId: arg.OrdId, SCode: "0"}
+	// 查找挂单
+	o := e.find(arg.OrdId, arg.ClOrdId)
+	// 挂单不存在
+	if o == nil {
+		// 返回错误
+		result.SCode, result.SMsg = "51400", "Cancellation failed as the order does not exist."
+		// 返回
+		return result, nil
+	}
+	// 移除挂单
+	delete(e.orders, o.OrdId)
+	// 订单 ID
+	result.OrdId = o.OrdId
+	// 订单状态
+	o.State = "canceled"
+	// 订单更新时间
+	o.UTime = nowMs()
+	// 返回
+	return result, o
+}
+
+// 改单, 需在持有锁时调用
+func (e *engine) amend(arg AmendOrder) (OpResult, *Orders) {
+	// 结果
+	result := OpResult{ClOrdId: arg.ClOrdId, OrdId: arg.OrdId, ReqId: arg.ReqId, SCode: "0"}
+	// 查找挂单
+	o := e.find(arg.OrdId, arg.ClOrdId)
+	// 挂单不存在
+	if o == nil {
+		// 返回错误
+		result.SCode, result.SMsg = "51503", "Order modification failed as the order does not exist."
+		// 返回
+		return result, nil
+	}
+	// 订单 ID
+	result.OrdId = o.OrdId
+	// 修改后的数量
+	newSz, szErr := NewDecimal(arg.NewSz)
+	// 修改后的价格
+	newPx, pxErr := NewDecimal(arg.NewPx)
+	// 参数错误
+	if (arg.NewSz != "" && (szErr != nil || newSz.Sign() <= 0)) || (arg.NewPx != "" && (pxErr != nil || newPx.Sign() <= 0)) {
+		// 返回错误
+		result.SCode, result.SMsg = "51000", "Parameter newSz or newPx error"
+		// 返回
+		return result, nil
+	}
+	// 修改数量
+	if arg.NewSz != "" {
+		// 更新数量
+		o.Sz = newSz
+	}
+	// 修改价格
+	if arg.NewPx != "" {
+		// 更新价格
+		o.Px = newPx
+	}
+	// 修改请求 ID
+	o.ReqId = arg.ReqId
+	// 修改结果
+	o.AmendResult = "0"
+	// 订单更新时间
+	o.UTime = nowMs()
+	// 买一价, 卖一价
+	bid, ask := e.bestBidAsk(e.markets[o.InstId])
+	// 委托价
+	px := o.Px.Float64()
+	// 修改后价格穿过盘口, 以委托价成交
+	if (o.Side == "buy" && px >= ask) || (o.Side == "sell" && px <= bid) {
+		// 成交
+		e.fill(o, px, "M")
+		// 移除挂单
+		delete(e.orders, o.OrdId)
+	}
+	// 返回
+	return result, o
+}
@@ -0,0 +1,221 @@
+package mockokx_test
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wiger123/okex_v5_golang/config"
+	. "github.com/wiger123/okex_v5_golang/database"
+	"github.com/wiger123/okex_v5_golang/mockokx"
+	"github.com/wiger123/okex_v5_golang/restapi"
+	. "github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/client"
+)
+
+// 启动模拟交易所, 返回自定义交易环境
+func startMock(t *testing.T) Environment {
+	// 模拟交易所配置
+	cfg := mockokx.DefaultConfig()
+	// 加快行情推送
+	cfg.Interval = 20 * time.Millisecond
+	// 创建模拟交易所
+	s := mockokx.NewServer(cfg)
+	// 启动行情推送
+	s.Start()
+	// HTTP 服务
+	ts := httptest.NewServer(s.Handler())
+	// 测试结束关闭
+	t.Cleanup(func() {
+		// 关闭模拟交易所
+		s.Close()
+		// 关闭 HTTP 服务
+		ts.Close()
+	})
+	// websocket 地址
+	ws := "ws" + strings.TrimPrefix(ts.URL, "http")
+	// 返回交易环境
+	return Environment{Name: EnvCustom, PublicURL: ws + mockokx.PublicPath, PrivateURL: ws + mockokx.PrivatePath, RestURL: ts.URL}
+}
+
+// 创建客户端并启动读取循环
+func dial(t *testing.T, url string, opts ...ClientOption) *OkxClient {
+	// 创建客户端
+	c, err := NewOkxClient(url, opts...)
+	// 创建失败
+	if err != nil {
+		// 终止测试
+		t.Fatalf("NewOkxClient(%v): %v", url, err)
+	}
+	// 读取循环
+	go c.ReadWebsocketLoop()
+	// 测试结束关闭
+	t.Cleanup(c.Shutdown)
+	// 返回
+	return c
+}
+
+// 测试超时
+func testContext(t *testing.T) context.Context {
+	// 超时
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// 测试结束释放
+	t.Cleanup(cancel)
+	// 返回
+	return ctx
+}
+
+func TestRestEndpoints(t *testing.T) {
+	env := startMock(t)
+	rest := restapi.NewClient(env.RestURL, restapi.WithEnvironment(env), restapi.WithCredentials(mockokx.MockCredentials()))
+
+	if err := rest.SyncClock(); err != nil {
+		t.Fatalf("SyncClock: %v", err)
+	}
+	instruments := NewInstruments()
+	if err := restapi.LoadInstruments(rest, instruments, config.InstType, ""); err != nil {
+		t.Fatalf("LoadInstruments: %v", err)
+	}
+	inst, ok := instruments.Get(config.InstID)
+	if !ok || inst.TickSz.Sign() <= 0 || inst.LotSz.Sign() <= 0 {
+		t.Fatalf("产品信息错误: %+v", inst)
+	}
+	cfgs, err := rest.GetAccountConfig()
+	if err != nil || len(cfgs) != 1 || cfgs[0].PosMode != "net_mode" {
+		t.Fatalf("GetAccountConfig: %+v, %v", cfgs, err)
+	}
+	dr := NewDataRepo()
+	if err := restapi.BootstrapDataRepo(rest, dr, config.InstType, config.InstID); err != nil {
+		t.Fatalf("BootstrapDataRepo: %v", err)
+	}
+	if token, usdt := dr.Balances(); token <= 0 || usdt <= 0 {
+		t.Fatalf("余额错误: %v, %v", token, usdt)
+	}
+
+	// 密钥错误的私有请求被拒绝
+	bad := mockokx.MockCredentials()
+	bad.SecretKey = "wrong"
+	if _, err := restapi.NewClient(env.RestURL, restapi.WithCredentials(bad)).GetBalance(""); err == nil {
+		t.Fatal("期望签名错误")
+	}
+}
+
+func TestClientAgainstMock(t *testing.T) {
+	env := startMock(t)
+	ctx := testContext(t)
+
+	// 公共频道: 行情写入数据库
+	market := NewDataRepo()
+	events := market.SubscribeEvents(config.EventBuffer, EventTrade, EventBook)
+	defer events.Close()
+	public := dial(t, env.PublicURL, WithEnvironment(env))
+	public.Subscribe("trades", "", "", config.InstID, market.HandleMessage)
+	public.Subscribe("books5", "", "", config.InstID, market.HandleMessage)
+	if err := public.SubscribeAndWait(ctx); err != nil {
+		t.Fatalf("公共频道订阅: %v", err)
+	}
+	seen := make(map[EventKind]bool)
+	for len(seen) < 2 {
+		select {
+		case e := <-events.Events():
+			seen[e.Kind] = true
+		case <-ctx.Done():
+			t.Fatalf("未收到行情, 已收到: %v", seen)
+		}
+	}
+	book, ok := market.LatestBook()
+	if !ok || len(book.Bids) == 0 {
+		t.Fatalf("盘口为空: %+v", book)
+	}
+
+	// 私有频道: 登录, 下单, 撤单
+	rest := restapi.NewClient(env.RestURL, restapi.WithEnvironment(env), restapi.WithCredentials(mockokx.MockCredentials()))
+	instruments := NewInstruments()
+	if err := restapi.LoadInstruments(rest, instruments, config.InstType, config.InstID); err != nil {
+		t.Fatalf("LoadInstruments: %v", err)
+	}
+	repo := NewDataRepo()
+	private := dial(t, env.PrivateURL, WithEnvironment(env), WithCredentials(mockokx.MockCredentials()), WithInstruments(instruments))
+	if err := private.LoginAndWait(ctx); err != nil {
+		t.Fatalf("登录: %v", err)
+	}
+	private.Subscribe("orders", config.InstType, "", config.InstID, repo.HandleMessage)
+	if err := private.SubscribeAndWait(ctx); err != nil {
+		t.Fatalf("私有频道订阅: %v", err)
+	}
+
+	// 远低于买一价的买单, 挂单等待
+	px := book.Bids[0][0].Float64() / 2
+	order, err := private.NewOrder(config.InstID, TdModeCash, SideBuy, OrdTypeLimit, DecimalFromInt(10), WithPx(DecimalFromFloat(px)), WithClOrdId("e2e1"), WithSnap())
+	if err != nil {
+		t.Fatalf("NewOrder: %v", err)
+	}
+	pending, err := private.PostOrders("order", []PostOrder{order}, repo)
+	if err != nil {
+		t.Fatalf("PostOrders: %v", err)
+	}
+	resp, err := pending.Wait(ctx)
+	if err != nil || len(resp.Data) != 1 || !resp.Data[0].Ok() {
+		t.Fatalf("下单结果: %+v, %v", resp, err)
+	}
+	open, err := rest.GetOpenOrders(config.InstType, config.InstID)
+	if err != nil || len(open) != 1 || open[0].ClOrdId != "e2e1" {
+		t.Fatalf("未成交订单: %+v, %v", open, err)
+	}
+
+	pending, err = private.CancelOrders("cancel-order", []CancelOrder{private.CancelSingleOrder(config.InstID, "", "e2e1")}, repo)
+	if err != nil {
+		t.Fatalf("CancelOrders: %v", err)
+	}
+	if _, err := pending.Wait(ctx); err != nil {
+		t.Fatalf("撤单结果: %v", err)
+	}
+	open, err = rest.GetOpenOrders(config.InstType, config.InstID)
+	if err != nil || len(open) != 0 {
+		t.Fatalf("撤单后未成交订单: %+v, %v", open, err)
+	}
+}
+
+func TestListenAndServeReturnsAfterClose(t *testing.T) {
+	// 空闲端口
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	s := mockokx.NewServer(mockokx.DefaultConfig())
+	done := make(chan error, 1)
+	go func() { done <- s.ListenAndServe(addr) }()
+	// 等待服务启动
+	for i := 0; ; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if i == 100 {
+			t.Fatalf("服务未启动: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("ListenAndServe: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close 后 ListenAndServe 未返回")
+	}
+	// 端口已释放
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		conn.Close()
+		t.Fatal("Close 后仍在监听")
+	}
+}
@@ -0,0 +1,186 @@
+package mockokx
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	. "github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// REST 响应
+type restResponse struct {
+	// 错误码
+	Code string `json:"code"`
+	// 错误消息
+	Msg string `json:"msg"`
+	// 数据
+	Data interface{} `json:"data"`
+}
+
+// 注册 REST 接口: 系统时间, 产品信息, 账户余额, 持仓, 账户配置, 未成交订单, 成交明细
+func (s *Server) handleRest(mux *http.ServeMux) {
+	// 系统时间
+	mux.HandleFunc("/api/v5/public/time", s.restPublic(func(r *http.Request) interface{} {
+		// 返回
+		return []ServerTime{{Ts: nowMs()}}
+	}))
+	// 产品信息
+	mux.HandleFunc("/api/v5/public/instruments", s.restPublic(func(r *http.Request) interface{} {
+		// 返回
+		return s.engine.instruments(r.URL.Query().Get("instType"), r.URL.Query().Get("instId"))
+	}))
+	// 账户余额
+	mux.HandleFunc("/api/v5/account/balance", s.restPrivate(func(r *http.Request) interface{} {
+		// 返回
+		return s.engine.accountMessage(channelArg{Channel: "account"}).Data
+	}))
+	// 持仓信息
+	mux.HandleFunc("/api/v5/account/positions", s.restPrivate(func(r *http.Request) interface{} {
+		// 返回
+		return s.engine.positionsMessage(channelArg{Channel: "positions", InstID: r.URL.Query().Get("instId")}).Data
+	}))
+	// 账户配置
+	mux.HandleFunc("/api/v5/account/config", s.restPrivate(func(r *http.Request) interface{} {
+		// 返回
+		return []AccountConfig{{Uid: s.cfg.Credentials.ApiKey, AcctLv: "2", PosMode: s.cfg.PosMode}}
+	}))
+	// 未成交订单
+	mux.HandleFunc("/api/v5/trade/orders-pending", s.restPrivate(func(r *http.Request) interface{} {
+		// 返回
+		return s.engine.openOrders(r.URL.Query().Get("instId"))
+	}))
+	// 成交明细, 模拟交易所不保留历史成交
+	mux.HandleFunc("/api/v5/trade/fills", s.restPrivate(func(r *http.Request) interface{} {
+		// 返回
+		return []Fill{}
+	}))
+}
+
+// 公共接口
+func (s *Server) restPublic(data func(r *http.Request) interface{}) http.HandlerFunc {
+	// 返回处理器
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 返回数据
+		writeRest(w, restResponse{Code: "0", Msg: "", Data: data(r)})
+	}
+}
+
+// 私有接口: 校验 API Key, Passphrase, 时间戳和签名
+func (s *Server) restPrivate(data func(r *http.Request) interface{}) http.HandlerFunc {
+	// 返回处理器
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 校验请求
+		if code, msg := s.verifyRest(r); code != "0" {
+			// 返回错误
+			writeRest(w, restResponse{Code: code, Msg: msg, Data: []interface{}{}})
+			// 返回
+			return
+		}
+		// 返回数据
+		writeRest(w, restResponse{Code: "0", Msg: "", Data: data(r)})
+	}
+}
+
+// 校验私有接口签名请求头, 返回错误码和错误消息
+func (s *Server) verifyRest(r *http.Request) (string, string) {
+	// 请求体
+	body, err := ioutil.ReadAll(r.Body)
+	// 读取失败
+	if err != nil {
+		// 返回错误
+		return "50000", "Body can not be empty."
+	}
+	// API Key
+	if r.Header.Get("OK-ACCESS-KEY") != s.cfg.Credentials.ApiKey {
+		// 返回错误
+		return "50111", "Invalid OK-ACCESS-KEY."
+	}
+	// API Passphrase
+	if r.Header.Get("OK-ACCESS-PASSPHRASE") != s.cfg.Credentials.PassPhrase {
+		// 返回错误
+		return "50105", "Invalid OK-ACCESS-PASSPHRASE."
+	}
+	// 时间戳
+	timestamp := r.Header.Get("OK-ACCESS-TIMESTAMP")
+	// 解析时间戳
+	ts, err := time.Parse("2006-01-02T15:04:05.000Z", timestamp)
+	// 时间戳超出 30 秒
+	if err != nil || time.Since(ts) > 30*time.Second || time.Until(ts) > 30*time.Second {
+		// 返回错误
+		return "50102", "Timestamp request expired."
+	}
+	// 计算签名
+	sign, err := s.cfg.Credentials.Sign(PreHashString(timestamp, r.Method, r.URL.RequestURI(), string(body)))
+	// 签名不一致
+	if err != nil || r.Header.Get("OK-ACCESS-SIGN") != sign {
+		// 返回错误
+		return "50113", "Invalid Sign."
+	}
+	// 校验通过
+	return "0", ""
+}
+
+// 写入 REST 响应
+func writeRest(w http.ResponseWriter, resp restResponse) {
+	// 响应格式
+	w.Header().Set("Content-Type", "application/json")
+	// 发送响应
+	json.NewEncoder(w).Encode(resp)
+}
+
+// 产品信息, instId 为空时返回该产品类型全部产品
+func (e *engine) instruments(instType, instId string) []Instrument {
+	// 该产品类型全部产品
+	list := e.instrumentsMessage(channelArg{Channel: "instruments", InstType: instType}).Data
+	// 未指定产品 ID
+	if instId == "" {
+		// 返回
+		return list
+	}
+	// 过滤结果
+	out := []Instrument{}
+	// 逐个产品
+	for _, inst := range list {
+		// 产品 ID 一致
+		if inst.InstId == instId {
+			// 添加产品
+			out = append(out, inst)
+		}
+	}
+	// 返回
+	return out
+}
+
+// 未成交订单, 按订单 ID 排序; instId 为空时返回全部产品
+func (e *engine) openOrders(instId string) []Orders {
+	// 上锁
+	e.mux.Lock()
+	// 函数结束前解锁
+	defer e.mux.Unlock()
+	// 订单列表
+	orders := []Orders{}
+	// 逐个挂单
+	for _, o := range e.orders {
+		// 产品 ID 过滤
+		if instId == "" || o.InstId == instId {
+			// 添加订单
+			orders = append(orders, *o)
+		}
+	}
+	// 排序
+	sort.Slice(orders, func(i, j int) bool {
+		// 订单 ID 按数值比较
+		a, _ := strconv.ParseInt(orders[i].OrdId, 10, 64)
+		// 订单 ID 按数值比较
+		b, _ := strconv.ParseInt(orders[j].OrdId, 10, 64)
+		// 返回
+		return a < b
+	})
+	// 返回
+	return orders
+}
@@ -0,0 +1,443 @@
+package mockokx
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/wiger123/okex_v5_golang/config"
+	. "github.com/wiger123/okex_v5_golang/utils"
+)
+
+// 公共频道路径
+const PublicPath = "/ws/v5/public"
+
+// 私有频道路径
+const PrivatePath = "/ws/v5/private"
+
+// 模拟交易所配置
+type Config struct {
+	// 账户密钥
+	Credentials Credentials
+	// 交易品种
+	InstIDs []string
+	// 初始价格
+	InitPx float64
+	// 价格精度
+	TickSz float64
+	// 数量精度
+	LotSz float64
+	// 最小下单数量
+	MinSz float64
+	// 行情推送间隔
+	Interval time.Duration
+	// 初始计价币余额
+	QuoteBal float64
+	// 初始交易币余额
+	BaseBal float64
+	// 账户持仓模式, 由账户配置接口返回
+	PosMode string
+}
+
+// 模拟交易所默认密钥, 不是真实账户
+func MockCredentials() Credentials {
+	// 返回密钥
+	return Credentials{
+		// API Key
+		ApiKey: "mock-api-key",
+		// API Secret Key
+		SecretKey: "mock-secret-key",
+		// API Passphrase
+		PassPhrase: "mock-passphrase",
+	}
+}
+
+// 默认配置: 使用模拟账户和 config 中的交易品种
+func DefaultConfig() Config {
+	// 返回配置
+	return Config{
+		// 账户密钥
+		Credentials: MockCredentials(),
+		// 交易品种
+		InstIDs: []string{config.InstID},
+		// 初始价格
+		InitPx: 0.1,
+		// 价格精度
+		TickSz: 0.00001,
+		// 数量精度
+		LotSz: 1,
+		// 最小下单数量
+		MinSz: 1,
+		// 行情推送间隔
+		Interval: 200 * time.Millisecond,
+		// 初始计价币余额
+		QuoteBal: 10000,
+		// 初始交易币余额
+		BaseBal: 100000,
+		// 账户持仓模式
+		PosMode: "net_mode",
+	}
+}
+
+// 模拟交易所: 本地提供 OKX v5 websocket 公共和私有频道, 以及启动所需的 REST 接口
+type Server struct {
+	// 配置
+	cfg Config
+	// 并发锁
+	mux sync.Mutex
+	// 当前连接
+	sessions map[*session]bool
+	// 撮合引擎
+	engine *engine
+	// 连接升级
+	upgrader websocket.Upgrader
+	// 停止通知
+	done chan struct{}
+	// 只停止一次
+	closeOnce sync.Once
+	// ListenAndServe 启动的 HTTP 服务
+	httpServer *http.Server
+}
+
+// 创建模拟交易所
+func NewServer(cfg Config) *Server {
+	// 模拟交易所初始化
+	s := &Server{
+		// 配置
+		cfg: cfg,
+		// 当前连接
+		sessions: make(map[*session]bool),
+		// 停止通知
+		done: make(chan struct{}),
+	}
+	// 撮合引擎
+	s.engine = newEngine(cfg, s)
+	// 返回
+	return s
+}
+
+// HTTP 路由: 公共频道, 私有频道和 REST 接口
+func (s *Server) Handler() http.Handler {
+	// 路由
+	mux := http.NewServeMux()
+	// 公共频道
+	mux.HandleFunc(PublicPath, func(w http.ResponseWriter, r *http.Request) {
+		// 处理连接
+		s.serve(w, r, false)
+	})
+	// 私有频道
+	mux.HandleFunc(PrivatePath, func(w http.ResponseWriter, r *http.Request) {
+		// 处理连接
+		s.serve(w, r, true)
+	})
+	// REST 接口
+	s.handleRest(mux)
+	// 返回路由
+	return mux
+}
+
+// 启动行情推送
+func (s *Server) Start() {
+	// 行情循环
+	go s.engine.run(s.done)
+}
+
+// 停止行情推送, 关闭 HTTP 服务和全部连接, 可重复调用
+func (s *Server) Close() {
+	// 停止行情
+	s.closeOnce.Do(func() { close(s.done) })
+	// 上锁
+	s.mux.Lock()
+	// 函数结束前解锁
+	defer s.mux.Unlock()
+	// 关闭 HTTP 服务, ListenAndServe 随即返回
+	if s.httpServer != nil {
+		// 关闭服务
+		s.httpServer.Close()
+	}
+	// 逐个连接
+	for sess := range s.sessions {
+		// 关闭连接
+		sess.conn.Close()
+	}
+}
+
+// 监听地址并提供服务, 阻塞直到 Close 或服务出错; Close 后返回 nil
+func (s *Server) ListenAndServe(addr string) error {
+	// HTTP 服务
+	srv := &http.Server{Addr: addr, Handler: s.Handler()}
+	// 上锁
+	s.mux.Lock()
+	// 事件选择
+	select {
+	// 已关闭
+	case <-s.done:
+		// 解锁
+		s.mux.Unlock()
+		// 返回
+		return nil
+	// 未关闭
+	default:
+	}
+	// 记录服务, 由 Close 关闭
+	s.httpServer = srv
+	// 解锁
+	s.mux.Unlock()
+	// 启动行情推送
+	s.Start()
+	// 提供服务
+	err := srv.ListenAndServe()
+	// 由 Close 关闭
+	if err == http.ErrServerClosed {
+		// 返回
+		return nil
+	}
+	// 返回
+	return err
+}
+
+// 处理单个 websocket 连接
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, private bool) {
+	// 升级为 websocket 连接
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	// 升级失败
+	if err != nil {
+		// 错误提示
+		log.Printf("[错误提示] 模拟交易所连接升级失败: %v", err)
+		// 返回
+		return
+	}
+	// 连接初始化
+	sess := &session{
+		// websocket 连接
+		conn: conn,
+		// 是否私有频道
+		private: private,
+		// 订阅频道
+		subs: make(map[string]channelArg),
+	}
+	// 上锁
+	s.mux.Lock()
+	// 添加连接
+	s.sessions[sess] = true
+	// 解锁
+	s.mux.Unlock()
+	// 函数结束前移除连接
+	defer func() {
+		// 上锁
+		s.mux.Lock()
+		// 删除连接
+		delete(s.sessions, sess)
+		// 解锁
+		s.mux.Unlock()
+		// 关闭连接
+		conn.Close()
+	}()
+	// 循环读取请求
+	for {
+		// 读取请求
+		_, data, err := conn.ReadMessage()
+		// 读取失败, 连接断开
+		if err != nil {
+			// 返回
+			return
+		}
+		// 处理请求
+		s.handleRequest(sess, data)
+	}
+}
+
+// 请求内容
+type request struct {
+	// 消息的唯一标识
+	Id string `json:"id"`
+	// 业务操作
+	Op string `json:"op"`
+	// 请求参数
+	Args []json.RawMessage `json:"args"`
+}
+
+// 登录参数
+type loginArg struct {
+	// API Key
+	APIKey string `json:"apiKey"`
+	// API Passphrase
+	Passphrase string `json:"passphrase"`
+	// 时间戳
+	Timestamp string `json:"timestamp"`
+	// 签名字符串
+	Sign string `json:"sign"`
+}
+
+// 处理单条请求
+func (s *Server) handleRequest(sess *session, data []byte) {
+	// 心跳
+	if string(data) == "ping" {
+		// 返回 pong
+		sess.writeRaw([]byte("pong"))
+		// 返回
+		return
+	}
+	// 请求初始化
+	var req request
+	// 解析请求
+	if err := json.Unmarshal(data, &req); err != nil {
+		// 返回错误
+		sess.writeEvent("error", "60012", "Invalid request: "+string(data), nil)
+		// 返回
+		return
+	}
+	// 操作分类
+	switch req.Op {
+	// 登录
+	case "login":
+		// 处理登录
+		s.handleLogin(sess, req)
+	// 订阅
+	case "subscribe":
+		// 处理订阅
+		s.handleSubscribe(sess, req, true)
+	// 取消订阅
+	case "unsubscribe":
+		// 处理取消订阅
+		s.handleSubscribe(sess, req, false)
+	// 下单, 撤单, 改单
+	case "order", "batch-orders", "cancel-order", "batch-cancel-orders", "amend-order", "batch-amend-orders":
+		// 需要私有频道登录
+		if !sess.private || !sess.isLoggedIn() {
+			// 返回错误
+			sess.writeJSON(opResponse(req.Id, req.Op, "60011", "Please log in", nil))
+			// 返回
+			return
+		}
+		// 交给撮合引擎
+		s.engine.handleOp(sess, req)
+	// 未知操作
+	default:
+		// 返回错误
+		sess.writeEvent("error", "60012", "Invalid request: "+string(data), nil)
+	}
+}
+
+// 处理登录: 校验 API Key, Passphrase, 时间戳和签名
+func (s *Server) handleLogin(sess *session, req request) {
+	// 参数初始化
+	var arg loginArg
+	// 解析参数
+	if len(req.Args) != 1 || json.Unmarshal(req.Args[0], &arg) != nil {
+		// 返回错误
+		sess.writeEvent("error", "60012", "Invalid request", nil)
+		// 返回
+		return
+	}
+	// 时间戳
+	ts, err := strconv.ParseInt(arg.Timestamp, 10, 64)
+	// 时间戳超出 30 秒
+	if err != nil || math.Abs(float64(time.Now().Unix()-ts)) > 30 {
+		// 返回错误
+		sess.writeEvent("error", "60006", "Timestamp request expired", nil)
+		// 返回
+		return
+	}
+	// 计算签名
+	sign, err := s.cfg.Credentials.Sign(PreHashString(arg.Timestamp, "GET", "/users/self/verify", ""))
+	// 校验账户和签名
+	if err != nil || arg.APIKey != s.cfg.Credentials.ApiKey || arg.Passphrase != s.cfg.Credentials.PassPhrase || arg.Sign != sign {
+		// 返回错误
+		sess.writeEvent("error", "60009", "Login failed.", nil)
+		// 返回
+		return
+	}
+	// 标记登录
+	sess.setLoggedIn()
+	// 返回成功
+	sess.writeEvent("login", "0", "", nil)
+}
+
+// 公共频道
+var publicChannels = map[string]bool{"trades": true, "books5": true, "instruments": true}
+
+// 私有频道
+var privateChannels = map[string]bool{"account": true, "positions": true, "orders": true}
+
+// 处理订阅和取消订阅
+func (s *Server) handleSubscribe(sess *session, req request, subscribe bool) {
+	// 逐个频道
+	for _, raw := range req.Args {
+		// 参数初始化
+		var arg channelArg
+		// 解析参数
+		if err := json.Unmarshal(raw, &arg); err != nil {
+			// 返回错误
+			sess.writeEvent("error", "60012", "Invalid request", nil)
+			// 跳过
+			continue
+		}
+		// 频道是否存在
+		if (sess.private && !privateChannels[arg.Channel]) || (!sess.private && !publicChannels[arg.Channel]) {
+			// 返回错误
+			sess.writeEvent("error", "60018", "Wrong URL or channel:"+arg.Channel+" doesn't exist.", nil)
+			// 跳过
+			continue
+		}
+		// 私有频道需要登录
+		if sess.private && !sess.isLoggedIn() {
+			// 返回错误
+			sess.writeEvent("error", "60011", "Please log in", nil)
+			// 跳过
+			continue
+		}
+		// 取消订阅
+		if !subscribe {
+			// 移除订阅
+			sess.unsubscribe(arg)
+			// 返回成功
+			sess.writeEvent("unsubscribe", "", "", &arg)
+			// 跳过
+			continue
+		}
+		// 添加订阅
+		sess.subscribe(arg)
+		// 返回成功
+		sess.writeEvent("subscribe", "", "", &arg)
+		// 产品信息, 账户和持仓订阅后推送当前快照
+		s.engine.pushSnapshot(sess, arg)
+	}
+}
+
+// 广播数据给订阅了频道的连接
+func (s *Server) broadcast(private bool, channel, instID string, build func(arg channelArg) interface{}) {
+	// 上锁
+	s.mux.Lock()
+	// 复制连接列表
+	sessions := make([]*session, 0, len(s.sessions))
+	// 逐个连接
+	for sess := range s.sessions {
+		// 添加连接
+		sessions = append(sessions, sess)
+	}
+	// 解锁
+	s.mux.Unlock()
+	// 逐个连接
+	for _, sess := range sessions {
+		// 频道类型不一致
+		if sess.private != private {
+			// 跳过
+			continue
+		}
+		// 查找订阅
+		arg, ok := sess.matching(channel, instID)
+		// 未订阅
+		if !ok {
+			// 跳过
+			continue
+		}
+		// 发送数据
+		sess.writeJSON(build(arg))
+	}
+}
@@ -0,0 +1,172 @@
+package mockokx
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 频道订阅参数
+type channelArg struct {
+	// 频道名
+	Channel string `json:"channel"`
+	// 产品类型
+	InstType string `json:"instType,omitempty"`
+	// 标的指数
+	Uly string `json:"uly,omitempty"`
+	// 产品 ID
+	InstID string `json:"instId,omitempty"`
+	// 币种
+	Ccy string `json:"ccy,omitempty"`
+}
+
+// 事件推送
+type eventMessage struct {
+	// 事件类型
+	Event string `json:"event"`
+	// 错误码
+	Code string `json:"code,omitempty"`
+	// 错误消息
+	Msg string `json:"msg,omitempty"`
+	// 订阅频道参数
+	Arg *channelArg `json:"arg,omitempty"`
+}
+
+// 单个 websocket 连接
+type session struct {
+	// 并发锁
+	mux sync.Mutex
+	// websocket 连接
+	conn *websocket.Conn
+	// 是否私有频道
+	private bool
+	// 是否已登录
+	loggedIn bool
+	// 订阅频道
+	subs map[string]channelArg
+}
+
+// 订阅 Key 值
+func subKey(channel, instID string) string {
+	// 字符串拼接
+	return channel + "|" + instID
+}
+
+// 是否已登录
+func (s *session) isLoggedIn() bool {
+	// 上锁
+	s.mux.Lock()
+	// 函数结束前解锁
+	defer s.mux.Unlock()
+	// 返回状态
+	return s.loggedIn
+}
+
+// 标记登录
+func (s *session) setLoggedIn() {
+	// 上锁
+	s.mux.Lock()
+	// 函数结束前解锁
+	defer s.mux.Unlock()
+	// 标记登录
+	s.loggedIn = true
+}
+
+// 添加订阅
+func (s *session) subscribe(arg channelArg) {
+	// 上锁
+	s.mux.Lock()
+	// 函数结束前解锁
+	defer s.mux.Unlock()
+	// 添加订阅
+	s.subs[subKey(arg.Channel, arg.InstID)] = arg
+}
+
+// 移除订阅
+func (s *session) unsubscribe(arg channelArg) {
+	// 上锁
+	s.mux.Lock()
+	// 函数结束前解锁
+	defer s.mux.Unlock()
+	// 删除订阅
+	delete(s.subs, subKey(arg.Channel, arg.InstID))
+}
+
+// 查找匹配的订阅: 指定产品 ID 或未指定产品 ID 的订阅
+func (s *session) matching(channel, instID string) (channelArg, bool) {
+	// 上锁
+	s.mux.Lock()
+	// 函数结束前解锁
+	defer s.mux.Unlock()
+	// 指定产品 ID
+	if arg, ok := s.subs[subKey(channel, instID)]; ok {
+		// 返回订阅
+		return arg, true
+	}
+	// 未指定产品 ID
+	arg, ok := s.subs[subKey(channel, "")]
+	// 返回订阅
+	return arg, ok
+}
+
+// 发送原始数据
+func (s *session) writeRaw(data []byte) {
+	// 上锁
+	s.mux.Lock()
+	// 函数结束前解锁
+	defer s.mux.Unlock()
+	// 发送数据, 失败时由读取循环发现断开
+	s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// 发送 json 数据
+func (s *session) writeJSON(v interface{}) {
+	// 转为 json 格式
+	data, err := json.Marshal(v)
+	// 转换失败
+	if err != nil {
+		// 返回
+		return
+	}
+	// 发送数据
+	s.writeRaw(data)
+}
+
+// 发送事件
+func (s *session) writeEvent(event, code, msg string, arg *channelArg) {
+	// 发送事件
+	s.writeJSON(&eventMessage{
+		// 事件类型
+		Event: event,
+		// 错误码
+		Code: code,
+		// 错误消息
+		Msg: msg,
+		// 订阅频道参数
+		Arg: arg,
+	})
+}
+
+// 订单操作响应
+func opResponse(id, op, code, msg string, data []OpResult) *OpResponse {
+	// 没有结果时返回空列表
+	if data == nil {
+		// 空列表
+		data = []OpResult{}
+	}
+	// 返回响应
+	return &OpResponse{
+		// 消息的唯一标识
+		Id: id,
+		// 业务操作
+		Op: op,
+		// 请求结果 code
+		Code: code,
+		// 请求失败时的 msg
+		Msg: msg,
+		// 逐个订单的操作结果
+		Data: data,
+	}
+}
@@ -0,0 +1,76 @@
+package recorder
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// 记录文件读取器
+type Reader struct {
+	// 文件
+	file *os.File
+	// 解压读取
+	gz *gzip.Reader
+	// 逐行读取
+	scanner *bufio.Scanner
+}
+
+// 打开记录文件
+func Open(path string) (*Reader, error) {
+	// 打开文件
+	file, err := os.Open(path)
+	// 打开失败
+	if err != nil {
+		// 返回错误
+		return nil, err
+	}
+	// 解压读取
+	gz, err := gzip.NewReader(file)
+	// 格式错误
+	if err != nil {
+		// 关闭文件
+		file.Close()
+		// 返回错误
+		return nil, err
+	}
+	// 逐行读取
+	scanner := bufio.NewScanner(gz)
+	// 单条数据可能较大, 扩大缓冲区
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	// 返回
+	return &Reader{file: file, gz: gz, scanner: scanner}, nil
+}
+
+// 读取下一条记录, 读完返回 io.EOF
+func (r *Reader) Next() (*Frame, error) {
+	// 读取一行
+	if !r.scanner.Scan() {
+		// 读取错误
+		if err := r.scanner.Err(); err != nil {
+			// 返回错误
+			return nil, err
+		}
+		// 读完
+		return nil, io.EOF
+	}
+	// 记录初始化
+	var frame Frame
+	// 解析记录
+	if err := json.Unmarshal(r.scanner.Bytes(), &frame); err != nil {
+		// 返回错误
+		return nil, err
+	}
+	// 返回
+	return &frame, nil
+}
+
+// 关闭文件
+func (r *Reader) Close() error {
+	// 关闭解压
+	r.gz.Close()
+	// 关闭文件
+	return r.file.Close()
+}
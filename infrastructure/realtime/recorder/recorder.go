@@ -0,0 +1,245 @@
+package recorder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wiger123/okex_v5_golang/config"
+)
+
+// 数据方向
+const (
+	// 收到的数据
+	DirIn = "in"
+	// 发出的数据
+	DirOut = "out"
+)
+
+// 单条记录: 一行 json
+type Frame struct {
+	// 本地收发时间 Nanosecond
+	Ts int64 `json:"ts"`
+	// 数据方向: in, out
+	Dir string `json:"dir"`
+	// 原始数据
+	Frame string `json:"frame"`
+}
+
+// 原始数据记录器: 按大小和时间轮换的 gzip json lines 文件
+type Recorder struct {
+	// 并发锁
+	mux sync.Mutex
+	// 记录目录
+	dir string
+	// 文件名前缀
+	prefix string
+	// 当前文件
+	file *os.File
+	// 压缩写入
+	gz *gzip.Writer
+	// 当前文件未压缩字节数
+	written int64
+	// 当前文件创建时间
+	openedAt time.Time
+	// 上次刷新时间
+	flushedAt time.Time
+	// 文件序号, 同一秒内轮换时区分文件名
+	seq int
+	// 是否已关闭
+	closed bool
+}
+
+// 创建记录器, 文件名为 prefix-时间-序号.jsonl.gz
+func NewRecorder(dir, prefix string) (*Recorder, error) {
+	// 创建目录
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		// 返回错误
+		return nil, err
+	}
+	// 记录器初始化
+	r := &Recorder{
+		// 记录目录
+		dir: dir,
+		// 文件名前缀
+		prefix: prefix,
+	}
+	// 创建首个文件
+	if err := r.rotate(); err != nil {
+		// 返回错误
+		return nil, err
+	}
+	// 返回
+	return r, nil
+}
+
+// 记录一条数据, 可并发调用
+func (r *Recorder) Record(dir string, data []byte) error {
+	// 本地时间
+	now := time.Now()
+	// 转为 json 格式, 登录请求隐去密钥
+	line, err := json.Marshal(&Frame{Ts: now.UnixNano(), Dir: dir, Frame: string(redact(data))})
+	// 转换失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 换行
+	line = append(line, '\n')
+	// 上锁
+	r.mux.Lock()
+	// 函数结束前解锁
+	defer r.mux.Unlock()
+	// 记录器已关闭
+	if r.closed {
+		// 返回错误
+		return os.ErrClosed
+	}
+	// 上次轮换失败没有打开的文件, 或超过大小或时间, 轮换文件
+	if r.gz == nil || r.written+int64(len(line)) > config.RecordMaxBytes || now.Sub(r.openedAt) > config.RecordRotateInterval*time.Second {
+		// 轮换
+		if err := r.rotate(); err != nil {
+			// 返回错误
+			return err
+		}
+	}
+	// 写入
+	n, err := r.gz.Write(line)
+	// 累计字节数
+	r.written += int64(n)
+	// 写入失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 定时刷新, 不逐条刷新以保持压缩率
+	if now.Sub(r.flushedAt) > config.RecordFlushInterval*time.Millisecond {
+		// 刷新时间
+		r.flushedAt = now
+		// 刷新
+		return r.gz.Flush()
+	}
+	// 返回
+	return nil
+}
+
+// 关闭记录器, 写完当前文件
+func (r *Recorder) Close() error {
+	// 上锁
+	r.mux.Lock()
+	// 函数结束前解锁
+	defer r.mux.Unlock()
+	// 关闭当前文件
+	err := r.closeFile()
+	// 标记关闭
+	r.closed = true
+	// 返回
+	return err
+}
+
+// 关闭当前文件并创建新文件, 需在持有锁时调用; 创建失败时没有打开的文件, 下次记录时重试
+func (r *Recorder) rotate() error {
+	// 关闭当前文件
+	if err := r.closeFile(); err != nil {
+		// 返回错误
+		return err
+	}
+	// 当前时间
+	now := time.Now()
+	// 文件序号
+	r.seq++
+	// 文件路径
+	path := filepath.Join(r.dir, fmt.Sprintf("%v-%v-%04d.jsonl.gz", r.prefix, now.Format("20060102-150405"), r.seq))
+	// 创建文件, 记录中含账户数据, 仅本人可读
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	// 创建失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 当前文件
+	r.file = file
+	// 压缩写入
+	r.gz = gzip.NewWriter(file)
+	// 重置字节数
+	r.written = 0
+	// 创建时间
+	r.openedAt = now
+	// 刷新时间
+	r.flushedAt = now
+	// 返回
+	return nil
+}
+
+// 关闭当前文件, 需在持有锁时调用
+func (r *Recorder) closeFile() error {
+	// 没有打开的文件
+	if r.file == nil {
+		// 返回
+		return nil
+	}
+	// 写完压缩数据
+	gzErr := r.gz.Close()
+	// 关闭文件
+	fileErr := r.file.Close()
+	// 清除当前文件, 不再写入已关闭的文件
+	r.file = nil
+	// 清除压缩写入
+	r.gz = nil
+	// 压缩失败
+	if gzErr != nil {
+		// 返回错误
+		return gzErr
+	}
+	// 返回
+	return fileErr
+}
+
+// 登录请求中的密钥字段
+var secretFields = []string{"apiKey", "passphrase", "sign"}
+
+// 隐去登录请求中的密钥, 其他数据原样返回
+func redact(data []byte) []byte {
+	// 非登录请求
+	if !bytes.Contains(data, []byte(`"login"`)) || !bytes.Contains(data, []byte(`"op"`)) {
+		// 返回
+		return data
+	}
+	// 请求内容
+	var request struct {
+		// 操作
+		Op string `json:"op"`
+		// 账户列表
+		Args []map[string]interface{} `json:"args"`
+	}
+	// 解析失败, 不是登录请求
+	if err := json.Unmarshal(data, &request); err != nil || request.Op != "login" {
+		// 返回
+		return data
+	}
+	// 逐个账户
+	for _, arg := range request.Args {
+		// 逐个密钥字段
+		for _, field := range secretFields {
+			// 存在该字段
+			if _, ok := arg[field]; ok {
+				// 隐去
+				arg[field] = "***"
+			}
+		}
+	}
+	// 转为 json 格式
+	redacted, err := json.Marshal(&request)
+	// 转换失败, 不记录原文
+	if err != nil {
+		// 返回
+		return []byte(`{"op":"login"}`)
+	}
+	// 返回
+	return redacted
+}
@@ -0,0 +1,100 @@
+package recorder
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 读取目录中全部记录
+func readAll(t *testing.T, dir string) []string {
+	// 记录文件
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl.gz"))
+	// 查找失败
+	if err != nil {
+		// 终止测试
+		t.Fatal(err)
+	}
+	// 记录内容
+	var frames []string
+	// 逐个文件
+	for _, path := range paths {
+		// 打开文件
+		r, err := Open(path)
+		// 打开失败
+		if err != nil {
+			// 终止测试
+			t.Fatal(err)
+		}
+		// 逐条读取
+		for {
+			// 读取记录
+			f, err := r.Next()
+			// 读取结束
+			if err == io.EOF {
+				// 结束
+				break
+			}
+			// 读取失败
+			if err != nil {
+				// 终止测试
+				t.Fatalf("%v: %v", path, err)
+			}
+			// 添加记录
+			frames = append(frames, f.Frame)
+		}
+		// 关闭文件
+		r.Close()
+	}
+	// 返回
+	return frames
+}
+
+func TestRecorderRecoversFromFailedRotate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "rec")
+	r, err := NewRecorder(dir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Record(DirIn, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	// 保存当前文件后删除目录, 轮换时无法创建新文件
+	saved := t.TempDir()
+	r.mux.Lock()
+	r.closeFile()
+	r.mux.Unlock()
+	if err := os.Rename(dir, filepath.Join(saved, "rec")); err != nil {
+		t.Fatal(err)
+	}
+	r.mux.Lock()
+	err = r.rotate()
+	r.mux.Unlock()
+	if err == nil {
+		t.Fatal("期望创建文件失败")
+	}
+	// 没有打开的文件时返回错误, 不写入已关闭的文件
+	if err := r.Record(DirIn, []byte("b")); err == nil {
+		t.Fatal("期望记录失败")
+	}
+	// 目录恢复后重新创建文件
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Record(DirIn, []byte("c")); err != nil {
+		t.Fatalf("恢复后记录失败: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Record(DirIn, []byte("d")); err != os.ErrClosed {
+		t.Fatalf("关闭后期望 os.ErrClosed, 实际: %v", err)
+	}
+	if got := readAll(t, filepath.Join(saved, "rec")); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("轮换前记录: %q", got)
+	}
+	if got := readAll(t, dir); len(got) != 1 || got[0] != "c" {
+		t.Fatalf("恢复后记录: %q", got)
+	}
+}
@@ -0,0 +1,178 @@
+package replay
+
+import (
+	"io"
+	"time"
+
+	"github.com/wiger123/okex_v5_golang/recorder"
+)
+
+// 回放速度: 尽快回放
+const AsFastAsPossible = 0
+
+// 单个会话的记录来源
+type source struct {
+	// 按时间顺序的记录文件
+	paths []string
+	// 当前文件序号
+	idx int
+	// 当前文件读取器
+	reader *recorder.Reader
+	// 下一条收到的数据
+	head *recorder.Frame
+	// 回放连接
+	transport *Transport
+}
+
+// 读取下一条收到的数据, 发出的请求跳过, 全部读完时 head 为空
+func (s *source) advance() error {
+	// 清空
+	s.head = nil
+	// 循环读取
+	for {
+		// 打开下一个文件
+		if s.reader == nil {
+			// 全部文件已读完
+			if s.idx >= len(s.paths) {
+				// 返回
+				return nil
+			}
+			// 打开文件
+			reader, err := recorder.Open(s.paths[s.idx])
+			// 打开失败
+			if err != nil {
+				// 返回错误
+				return err
+			}
+			// 当前读取器
+			s.reader = reader
+			// 文件序号
+			s.idx++
+		}
+		// 读取记录
+		frame, err := s.reader.Next()
+		// 当前文件读完
+		if err == io.EOF {
+			// 关闭文件
+			s.reader.Close()
+			// 切换下一个文件
+			s.reader = nil
+			// 继续
+			continue
+		}
+		// 读取失败
+		if err != nil {
+			// 返回错误
+			return err
+		}
+		// 原会话发出的请求, 由回放中的策略重新生成
+		if frame.Dir != recorder.DirIn {
+			// 跳过
+			continue
+		}
+		// 下一条数据
+		s.head = frame
+		// 返回
+		return nil
+	}
+}
+
+// 回放器: 按记录时间合并多个会话, 逐条推送给对应的客户端
+type Player struct {
+	// 回放速度倍数, 1 为实时, 0 为尽快回放
+	speed float64
+	// 会话列表
+	sources []*source
+	// 已推送的数据数
+	played int
+}
+
+// 创建回放器
+func NewPlayer(speed float64) *Player {
+	// 返回回放器
+	return &Player{speed: speed}
+}
+
+// 添加一个会话的记录文件, 返回供客户端使用的回放连接
+func (p *Player) AddSession(paths []string) *Transport {
+	// 回放连接
+	t := NewTransport()
+	// 添加会话
+	p.sources = append(p.sources, &source{paths: paths, transport: t})
+	// 返回
+	return t
+}
+
+// 已推送的数据数
+func (p *Player) Played() int {
+	// 返回
+	return p.played
+}
+
+// 回放全部记录, 每条数据处理完毕后才推送下一条, 返回时全部数据已处理完毕
+func (p *Player) Run() error {
+	// 函数结束前关闭记录文件
+	defer func() {
+		// 逐个会话
+		for _, s := range p.sources {
+			// 关闭文件
+			if s.reader != nil {
+				// 关闭
+				s.reader.Close()
+			}
+		}
+	}()
+	// 读取每个会话的第一条数据
+	for _, s := range p.sources {
+		// 读取
+		if err := s.advance(); err != nil {
+			// 返回错误
+			return err
+		}
+	}
+	// 上一条数据的记录时间
+	var lastTs int64
+	// 上一条数据的推送时间
+	var lastPlay time.Time
+	// 循环推送
+	for {
+		// 记录时间最早的会话
+		var next *source
+		// 逐个会话
+		for _, s := range p.sources {
+			// 有待推送数据且时间更早
+			if s.head != nil && (next == nil || s.head.Ts < next.head.Ts) {
+				// 选择该会话
+				next = s
+			}
+		}
+		// 全部推送完毕
+		if next == nil {
+			// 返回
+			return nil
+		}
+		// 按记录间隔等待
+		if p.speed > 0 && lastTs > 0 {
+			// 记录间隔按速度缩放
+			gap := time.Duration(float64(next.head.Ts-lastTs) / p.speed)
+			// 扣除处理已耗时间
+			time.Sleep(gap - time.Since(lastPlay))
+		}
+		// 记录时间
+		lastTs = next.head.Ts
+		// 推送时间
+		lastPlay = time.Now()
+		// 推送数据, 客户端已关闭时结束回放
+		if !next.transport.push([]byte(next.head.Frame)) {
+			// 返回
+			return nil
+		}
+		// 计数
+		p.played++
+		// 读取下一条
+		if err := next.advance(); err != nil {
+			// 返回错误
+			return err
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package replay
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/wiger123/okex_v5_golang/recorder"
+	"github.com/wiger123/okex_v5_golang/wsdata/client"
+)
+
+// 回放连接已关闭
+var ErrReplayClosed = errors.New("回放连接已关闭")
+
+// 回放连接: 按记录顺序推送收到的数据, 截获客户端发出的请求, 不访问网络
+type Transport struct {
+	// 并发锁
+	mux sync.Mutex
+	// 待推送的记录数据
+	frames chan []byte
+	// 处理完毕通知: 客户端再次读取时说明上一条已处理完毕
+	acks chan struct{}
+	// 是否有已推送未确认的数据, 只在读取协程中访问
+	delivered bool
+	// 心跳响应
+	pongs chan []byte
+	// 关闭通知
+	done chan struct{}
+	// 是否已关闭
+	closed bool
+	// 截获的请求
+	sent [][]byte
+	// 截获请求的记录器, 为空时不记录
+	capture *recorder.Recorder
+}
+
+// 创建回放连接
+func NewTransport() *Transport {
+	// 返回连接
+	return &Transport{
+		// 记录数据
+		frames: make(chan []byte),
+		// 处理完毕通知
+		acks: make(chan struct{}, 1),
+		// 心跳响应
+		pongs: make(chan []byte, 16),
+		// 关闭通知
+		done: make(chan struct{}),
+	}
+}
+
+// 截获的请求同时写入记录器
+func (t *Transport) SetCapture(r *recorder.Recorder) {
+	// 上锁
+	t.mux.Lock()
+	// 函数结束前解锁
+	defer t.mux.Unlock()
+	// 记录器
+	t.capture = r
+}
+
+// 返回始终使用该回放连接的建立方式, 回放期间断线重连仍连到同一记录
+func (t *Transport) Dialer() client.Dialer {
+	// 返回建立方式
+	return func(url string) (client.Transport, error) {
+		// 上锁
+		t.mux.Lock()
+		// 函数结束前解锁
+		defer t.mux.Unlock()
+		// 已关闭的连接无法再次建立
+		if t.closed {
+			// 返回错误
+			return nil, ErrReplayClosed
+		}
+		// 返回连接
+		return t, nil
+	}
+}
+
+// 读取下一条记录数据, 调用时说明上一条已处理完毕
+func (t *Transport) ReadMessage() ([]byte, error) {
+	// 上一条记录数据已处理完毕
+	if t.delivered {
+		// 清除标记
+		t.delivered = false
+		// 通知回放器
+		t.acks <- struct{}{}
+	}
+	// 事件选择
+	select {
+	// 记录数据
+	case data := <-t.frames:
+		// 标记待确认
+		t.delivered = true
+		// 返回
+		return data, nil
+	// 心跳响应
+	case data := <-t.pongs:
+		// 返回
+		return data, nil
+	// 已关闭
+	case <-t.done:
+		// 返回错误
+		return nil, ErrReplayClosed
+	}
+}
+
+// 截获客户端发出的请求, 心跳直接响应
+func (t *Transport) WriteMessage(data []byte) error {
+	// 心跳
+	if string(data) == "ping" {
+		// 事件选择
+		select {
+		// 响应 pong
+		case t.pongs <- []byte("pong"):
+		// 缓冲已满, 丢弃
+		default:
+		}
+		// 返回
+		return nil
+	}
+	// 上锁
+	t.mux.Lock()
+	// 函数结束前解锁
+	defer t.mux.Unlock()
+	// 已关闭
+	if t.closed {
+		// 返回错误
+		return ErrReplayClosed
+	}
+	// 复制数据
+	frame := append([]byte(nil), data...)
+	// 记录请求
+	t.sent = append(t.sent, frame)
+	// 写入记录器
+	if t.capture != nil {
+		// 记录
+		return t.capture.Record(recorder.DirOut, frame)
+	}
+	// 返回
+	return nil
+}
+
+// 关闭连接, 回放中的关闭只用于结束客户端
+func (t *Transport) Close() error {
+	// 上锁
+	t.mux.Lock()
+	// 函数结束前解锁
+	defer t.mux.Unlock()
+	// 未关闭
+	if !t.closed {
+		// 标记关闭
+		t.closed = true
+		// 关闭通知
+		close(t.done)
+	}
+	// 返回
+	return nil
+}
+
+// 截获的全部请求
+func (t *Transport) Sent() [][]byte {
+	// 上锁
+	t.mux.Lock()
+	// 函数结束前解锁
+	defer t.mux.Unlock()
+	// 返回副本
+	return append([][]byte(nil), t.sent...)
+}
+
+// 推送一条记录数据, 客户端处理完毕后返回, 连接已关闭时返回 false
+func (t *Transport) push(data []byte) bool {
+	// 事件选择
+	select {
+	// 推送数据
+	case t.frames <- data:
+	// 已关闭
+	case <-t.done:
+		// 返回
+		return false
+	}
+	// 事件选择
+	select {
+	// 处理完毕
+	case <-t.acks:
+		// 返回
+		return true
+	// 已关闭
+	case <-t.done:
+		// 返回
+		return false
+	}
+}
@@ -0,0 +1,63 @@
+package restapi
+
+import (
+	"net/url"
+
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 获取账户余额, ccy 为空时返回全部币种
+func (c *Client) GetBalance(ccy string) ([]Account, error) {
+	// 查询参数
+	query := url.Values{}
+	// 币种
+	addQuery(query, "ccy", ccy)
+	// 响应数据
+	var data []Account
+	// 发送请求
+	err := c.getPrivate("/api/v5/account/balance", query, &data)
+	// 返回
+	return data, err
+}
+
+// 获取持仓信息
+func (c *Client) GetPositions(instType, instId string) ([]Positions, error) {
+	// 查询参数
+	query := url.Values{}
+	// 产品类型
+	addQuery(query, "instType", instType)
+	// 产品 ID
+	addQuery(query, "instId", instId)
+	// 响应数据
+	var data []Positions
+	// 发送请求
+	err := c.getPrivate("/api/v5/account/positions", query, &data)
+	// 返回
+	return data, err
+}
+
+// 获取杠杆倍数
+func (c *Client) GetLeverage(instId, mgnMode string) ([]LeverageInfo, error) {
+	// 查询参数
+	query := url.Values{}
+	// 产品 ID
+	addQuery(query, "instId", instId)
+	// 保证金模式
+	addQuery(query, "mgnMode", mgnMode)
+	// 响应数据
+	var data []LeverageInfo
+	// 发送请求
+	err := c.getPrivate("/api/v5/account/leverage-info", query, &data)
+	// 返回
+	return data, err
+}
+
+// 获取账户配置, 包含持仓模式
+func (c *Client) GetAccountConfig() ([]AccountConfig, error) {
+	// 响应数据
+	var data []AccountConfig
+	// 发送请求
+	err := c.getPrivate("/api/v5/account/config", url.Values{}, &data)
+	// 返回
+	return data, err
+}
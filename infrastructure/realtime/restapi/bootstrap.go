@@ -0,0 +1,78 @@
+package restapi
+
+import (
+	. "github.com/wiger123/okex_v5_golang/database"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 用 REST 接口的账户, 持仓和挂单初始化 DataRepo, 不必等待首次推送; 应在订阅私有频道前调用, 否则较旧的 REST 数据会覆盖已收到的推送
+func BootstrapDataRepo(c *Client, dr *DataRepo, instType, instId string) error {
+	// 账户余额
+	accounts, err := c.GetBalance("")
+	// 请求失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 账户数据
+	var am AccountMessage
+	// 频道名
+	am.Arg.Channel = "account"
+	// 账户数据
+	am.Data = accounts
+	// 写入数据库
+	dr.HandleMessage(&am)
+
+	// 持仓信息
+	positions, err := c.GetPositions(instType, instId)
+	// 请求失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 持仓数据
+	var pm PositionsMessage
+	// 频道名
+	pm.Arg.Channel = "positions"
+	// 产品 ID
+	pm.Arg.InstId = instId
+	// 持仓数据
+	pm.Data = positions
+	// 写入数据库
+	dr.HandleMessage(&pm)
+
+	// 未成交订单
+	orders, err := c.GetOpenOrders(instType, instId)
+	// 请求失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 订单数据
+	var om OrdersMessage
+	// 频道名
+	om.Arg.Channel = "orders"
+	// 产品 ID
+	om.Arg.InstId = instId
+	// 订单数据
+	om.Data = orders
+	// 写入数据库
+	dr.HandleMessage(&om)
+	// 返回
+	return nil
+}
+
+// 用 REST 接口加载产品基础信息, instId 为空时加载该产品类型全部产品
+func LoadInstruments(c *Client, store *Instruments, instType, instId string) error {
+	// 产品信息
+	list, err := c.GetInstruments(instType, instId)
+	// 请求失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 更新缓存
+	store.Update(list)
+	// 返回
+	return nil
+}
@@ -0,0 +1,247 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/wiger123/okex_v5_golang/config"
+	. "github.com/wiger123/okex_v5_golang/utils"
+)
+
+// REST 接口错误: 交易所返回的非 0 code
+type APIError struct {
+	// 请求路径
+	Path string
+	// 错误码
+	Code string
+	// 错误消息
+	Msg string
+}
+
+// 错误信息
+func (e *APIError) Error() string {
+	// 字符串格式化
+	return fmt.Sprintf("OKX REST %v 失败 code: %v, msg: %v", e.Path, e.Code, e.Msg)
+}
+
+// REST 响应内容
+type response struct {
+	// 错误码
+	Code string `json:"code"`
+	// 错误消息
+	Msg string `json:"msg"`
+	// 数据
+	Data json.RawMessage `json:"data"`
+}
+
+// REST 客户端
+type Client struct {
+	// 接口地址
+	baseURL string
+	// HTTP 客户端
+	httpClient *http.Client
+	// 交易所时钟, 用于请求签名
+	clock *Clock
+	// 交易环境
+	env Environment
+	// 账户密钥, 只访问公共接口时可为空
+	creds Credentials
+}
+
+// 客户端选项
+type ClientOption func(c *Client)
+
+// 指定 HTTP 客户端
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	// 返回选项
+	return func(c *Client) {
+		// HTTP 客户端
+		c.httpClient = httpClient
+	}
+}
+
+// 指定交易所时钟, 可与 websocket 客户端共享
+func WithClock(clock *Clock) ClientOption {
+	// 返回选项
+	return func(c *Client) {
+		// 交易所时钟
+		c.clock = clock
+	}
+}
+
+// 指定账户密钥, 私有接口签名使用
+func WithCredentials(creds Credentials) ClientOption {
+	// 返回选项
+	return func(c *Client) {
+		// 账户密钥
+		c.creds = creds
+	}
+}
+
+// 指定交易环境, 模拟盘请求附带 x-simulated-trading 请求头
+func WithEnvironment(env Environment) ClientOption {
+	// 返回选项
+	return func(c *Client) {
+		// 交易环境
+		c.env = env
+	}
+}
+
+// 创建 REST 客户端
+func NewClient(baseURL string, opts ...ClientOption) *Client {
+	// 客户端初始化
+	c := &Client{
+		// 接口地址
+		baseURL: baseURL,
+		// HTTP 客户端
+		httpClient: &http.Client{Timeout: config.RestTimeout * time.Second},
+		// 交易所时钟
+		clock: NewClock(),
+		// 交易环境, 默认实盘
+		env: LiveEnvironment(),
+	}
+	// 逐个选项
+	for _, opt := range opts {
+		// 设置选项
+		opt(c)
+	}
+	// 返回
+	return c
+}
+
+// 公共接口 GET 请求
+func (c *Client) getPublic(path string, query url.Values, out interface{}) error {
+	// 发送请求
+	return c.do(http.MethodGet, path, query, nil, false, out)
+}
+
+// 私有接口 GET 请求
+func (c *Client) getPrivate(path string, query url.Values, out interface{}) error {
+	// 发送请求
+	return c.do(http.MethodGet, path, query, nil, true, out)
+}
+
+// 发送请求并解析 data 字段
+func (c *Client) do(method, path string, query url.Values, body interface{}, signed bool, out interface{}) error {
+	// 请求路径, 带查询参数
+	requestPath := path
+	// 有查询参数
+	if len(query) > 0 {
+		// 拼接查询参数
+		requestPath += "?" + query.Encode()
+	}
+	// 请求体
+	var payload []byte
+	// 有请求体
+	if body != nil {
+		// 转为 json 格式
+		data, err := json.Marshal(body)
+		// 转换失败
+		if err != nil {
+			// 返回错误
+			return err
+		}
+		// 请求体
+		payload = data
+	}
+	// 创建请求
+	req, err := http.NewRequest(method, c.baseURL+requestPath, bytes.NewReader(payload))
+	// 创建失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 请求体格式
+	req.Header.Set("Content-Type", "application/json")
+	// 交易环境请求头
+	for key, values := range c.env.Header() {
+		// 模拟盘标记
+		req.Header[key] = values
+	}
+	// 私有接口签名
+	if signed {
+		// 签名请求头
+		if err := c.sign(req, method, requestPath, string(payload)); err != nil {
+			// 返回错误
+			return err
+		}
+	}
+	// 发送请求
+	resp, err := c.httpClient.Do(req)
+	// 请求失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 函数结束前关闭响应
+	defer resp.Body.Close()
+	// 读取响应
+	data, err := ioutil.ReadAll(resp.Body)
+	// 读取失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 响应初始化
+	var r response
+	// 解析响应
+	if err := json.Unmarshal(data, &r); err != nil {
+		// 返回错误
+		return fmt.Errorf("OKX REST %v 响应解析失败, HTTP %v: %v", path, resp.StatusCode, err)
+	}
+	// 交易所返回错误
+	if r.Code != "0" {
+		// 返回错误
+		return &APIError{Path: path, Code: r.Code, Msg: r.Msg}
+	}
+	// 不需要数据
+	if out == nil {
+		// 返回
+		return nil
+	}
+	// 解析数据
+	return json.Unmarshal(r.Data, out)
+}
+
+// 私有接口签名: timestamp + method + requestPath + body
+func (c *Client) sign(req *http.Request, method, requestPath, body string) error {
+	// ISO 格式时间戳, 精确到毫秒
+	timestamp := c.clock.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	// 未设置密钥
+	if !c.creds.Valid() {
+		// 返回错误
+		return errors.New("OKX REST 私有接口未设置 API 密钥")
+	}
+	// HMAC SHA256
+	sign, err := c.creds.Sign(PreHashString(timestamp, method, requestPath, body))
+	// 签名失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// API Key
+	req.Header.Set("OK-ACCESS-KEY", c.creds.ApiKey)
+	// 签名字符串
+	req.Header.Set("OK-ACCESS-SIGN", sign)
+	// 时间戳
+	req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+	// API Passphrase
+	req.Header.Set("OK-ACCESS-PASSPHRASE", c.creds.PassPhrase)
+	// 返回
+	return nil
+}
+
+// 添加非空查询参数
+func addQuery(query url.Values, key, value string) {
+	// 非空参数
+	if value != "" {
+		// 添加参数
+		query.Set(key, value)
+	}
+}
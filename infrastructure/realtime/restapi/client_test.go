@@ -0,0 +1,240 @@
+package restapi
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/wiger123/okex_v5_golang/database"
+	. "github.com/wiger123/okex_v5_golang/utils"
+)
+
+// 测试用密钥
+var testCreds = Credentials{ApiKey: "test-key", SecretKey: "test-secret", PassPhrase: "test-pass"}
+
+// 捕获的请求
+type captured struct {
+	// 请求方法
+	method string
+	// 请求路径和查询参数
+	uri string
+	// 请求头
+	header http.Header
+	// 请求体
+	body string
+}
+
+// 启动返回固定响应的测试服务, 路径 -> 响应内容
+func newTestServer(t *testing.T, routes map[string]string) (*httptest.Server, *[]captured) {
+	// 捕获的请求
+	var reqs []captured
+	// 测试服务
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 请求体
+		body, _ := ioutil.ReadAll(r.Body)
+		// 记录请求
+		reqs = append(reqs, captured{method: r.Method, uri: r.URL.RequestURI(), header: r.Header.Clone(), body: string(body)})
+		// 响应内容
+		resp, ok := routes[r.URL.Path]
+		// 未知路径
+		if !ok {
+			// 返回错误
+			http.NotFound(w, r)
+			// 返回
+			return
+		}
+		// 返回响应
+		w.Write([]byte(resp))
+	}))
+	// 测试结束关闭
+	t.Cleanup(ts.Close)
+	// 返回
+	return ts, &reqs
+}
+
+func TestPrivateRequestSigningHeaders(t *testing.T) {
+	ts, reqs := newTestServer(t, map[string]string{"/api/v5/account/balance": `{"code":"0","msg":"","data":[]}`})
+	c := NewClient(ts.URL, WithCredentials(testCreds))
+	if _, err := c.GetBalance("BTC"); err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if len(*reqs) != 1 {
+		t.Fatalf("请求数目: %v", len(*reqs))
+	}
+	req := (*reqs)[0]
+	if req.method != http.MethodGet || req.uri != "/api/v5/account/balance?ccy=BTC" {
+		t.Fatalf("请求错误: %v %v", req.method, req.uri)
+	}
+	if req.header.Get("OK-ACCESS-KEY") != testCreds.ApiKey || req.header.Get("OK-ACCESS-PASSPHRASE") != testCreds.PassPhrase {
+		t.Fatalf("密钥请求头错误: %v", req.header)
+	}
+	// 时间戳为 ISO 格式, 精确到毫秒
+	timestamp := req.header.Get("OK-ACCESS-TIMESTAMP")
+	ts0, err := time.Parse("2006-01-02T15:04:05.000Z", timestamp)
+	if err != nil || time.Since(ts0) > time.Minute {
+		t.Fatalf("时间戳错误: %q, %v", timestamp, err)
+	}
+	// 签名: timestamp + method + requestPath(含查询参数) + body
+	want, _ := HmacSha256Base64Signer(timestamp+"GET/api/v5/account/balance?ccy=BTC", testCreds.SecretKey)
+	if req.header.Get("OK-ACCESS-SIGN") != want {
+		t.Fatalf("签名错误: %v, 期望: %v", req.header.Get("OK-ACCESS-SIGN"), want)
+	}
+	// 实盘不附带模拟盘请求头
+	if req.header.Get(SimulatedTradingHeader) != "" {
+		t.Fatalf("实盘附带了模拟盘请求头")
+	}
+}
+
+func TestSignUsesClockOffset(t *testing.T) {
+	ts, reqs := newTestServer(t, map[string]string{"/api/v5/account/config": `{"code":"0","msg":"","data":[]}`})
+	clock := NewClock()
+	// 交易所时间比本地快 1 小时
+	now := time.Now()
+	clock.Update(now, now, now.Add(time.Hour))
+	c := NewClient(ts.URL, WithCredentials(testCreds), WithClock(clock))
+	if _, err := c.GetAccountConfig(); err != nil {
+		t.Fatalf("GetAccountConfig: %v", err)
+	}
+	sent, err := time.Parse("2006-01-02T15:04:05.000Z", (*reqs)[0].header.Get("OK-ACCESS-TIMESTAMP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := sent.Sub(now); d < 59*time.Minute || d > 61*time.Minute {
+		t.Fatalf("时间戳未使用交易所时钟: 偏移 %v", d)
+	}
+}
+
+func TestPublicRequestNotSignedAndSimulatedHeader(t *testing.T) {
+	ts, reqs := newTestServer(t, map[string]string{"/api/v5/public/time": `{"code":"0","msg":"","data":[{"ts":"1597026383085"}]}`})
+	c := NewClient(ts.URL, WithEnvironment(DemoEnvironment()))
+	got, err := c.GetServerTime()
+	if err != nil {
+		t.Fatalf("GetServerTime: %v", err)
+	}
+	if got.UnixNano()/int64(time.Millisecond) != 1597026383085 {
+		t.Fatalf("系统时间错误: %v", got)
+	}
+	req := (*reqs)[0]
+	if req.header.Get("OK-ACCESS-SIGN") != "" || req.header.Get("OK-ACCESS-KEY") != "" {
+		t.Fatalf("公共接口不应签名: %v", req.header)
+	}
+	if req.header.Get(SimulatedTradingHeader) != "1" {
+		t.Fatalf("模拟盘缺少请求头: %v", req.header)
+	}
+}
+
+func TestPrivateRequestWithoutCredentials(t *testing.T) {
+	ts, reqs := newTestServer(t, nil)
+	c := NewClient(ts.URL)
+	if _, err := c.GetPositions("SWAP", ""); err == nil {
+		t.Fatal("期望未设置密钥错误")
+	}
+	if len(*reqs) != 0 {
+		t.Fatalf("不应发出请求: %v", *reqs)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	ts, _ := newTestServer(t, map[string]string{"/api/v5/account/balance": `{"code":"50113","msg":"Invalid Sign","data":[]}`})
+	c := NewClient(ts.URL, WithCredentials(testCreds))
+	_, err := c.GetBalance("")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "50113" || apiErr.Path != "/api/v5/account/balance" {
+		t.Fatalf("期望 APIError, 实际: %v", err)
+	}
+}
+
+func TestUndecodableResponse(t *testing.T) {
+	ts, _ := newTestServer(t, nil)
+	c := NewClient(ts.URL)
+	// 404 页面不是 json
+	if _, err := c.GetInstruments("SPOT", ""); err == nil {
+		t.Fatal("期望解析错误")
+	}
+}
+
+func TestEndpointsDecode(t *testing.T) {
+	ts, reqs := newTestServer(t, map[string]string{
+		"/api/v5/account/balance":       `{"code":"0","msg":"","data":[{"uTime":"1","details":[{"ccy":"USDT","cashBal":"100.5"}]}]}`,
+		"/api/v5/account/positions":     `{"code":"0","msg":"","data":[{"instId":"BTC-USDT-SWAP","posSide":"long","pos":"3","availPos":"2","avgPx":"30000.1"}]}`,
+		"/api/v5/account/leverage-info": `{"code":"0","msg":"","data":[{"instId":"BTC-USDT-SWAP","mgnMode":"cross","posSide":"","lever":"5"}]}`,
+		"/api/v5/account/config":        `{"code":"0","msg":"","data":[{"uid":"42","acctLv":"2","posMode":"long_short_mode","autoLoan":false}]}`,
+		"/api/v5/public/instruments":    `{"code":"0","msg":"","data":[{"instType":"SPOT","instId":"BTC-USDT","tickSz":"0.1","lotSz":"0.00000001","minSz":"0.00001","state":"live"}]}`,
+		"/api/v5/trade/orders-pending":  `{"code":"0","msg":"","data":[{"instId":"BTC-USDT","ordId":"7","clOrdId":"c7","px":"29000","sz":"0.5","state":"live"}]}`,
+		"/api/v5/trade/fills":           `{"code":"0","msg":"","data":[{"instType":"SPOT","instId":"BTC-USDT","tradeId":"9","ordId":"7"}]}`,
+	})
+	c := NewClient(ts.URL, WithCredentials(testCreds))
+
+	accounts, err := c.GetBalance("")
+	if err != nil || len(accounts) != 1 || len(accounts[0].Details) != 1 || accounts[0].Details[0].CashBal != "100.5" {
+		t.Fatalf("GetBalance: %+v, %v", accounts, err)
+	}
+	positions, err := c.GetPositions("SWAP", "BTC-USDT-SWAP")
+	if err != nil || len(positions) != 1 || positions[0].Pos.String() != "3" || positions[0].AvgPx.String() != "30000.1" || positions[0].PosSide != "long" {
+		t.Fatalf("GetPositions: %+v, %v", positions, err)
+	}
+	leverage, err := c.GetLeverage("BTC-USDT-SWAP", "cross")
+	if err != nil || len(leverage) != 1 || leverage[0].Lever != "5" {
+		t.Fatalf("GetLeverage: %+v, %v", leverage, err)
+	}
+	cfgs, err := c.GetAccountConfig()
+	if err != nil || len(cfgs) != 1 || cfgs[0].PosMode != "long_short_mode" || cfgs[0].Uid != "42" {
+		t.Fatalf("GetAccountConfig: %+v, %v", cfgs, err)
+	}
+	instruments, err := c.GetInstruments("SPOT", "BTC-USDT")
+	if err != nil || len(instruments) != 1 || instruments[0].TickSz.String() != "0.1" || instruments[0].LotSz.String() != "0.00000001" {
+		t.Fatalf("GetInstruments: %+v, %v", instruments, err)
+	}
+	orders, err := c.GetOpenOrders("SPOT", "BTC-USDT")
+	if err != nil || len(orders) != 1 || orders[0].ClOrdId != "c7" || orders[0].Px.String() != "29000" || orders[0].Sz.String() != "0.5" {
+		t.Fatalf("GetOpenOrders: %+v, %v", orders, err)
+	}
+	fills, err := c.GetFills("SPOT", "BTC-USDT")
+	if err != nil || len(fills) != 1 || fills[0].TradeId != "9" {
+		t.Fatalf("GetFills: %+v, %v", fills, err)
+	}
+
+	// 查询参数
+	want := []string{
+		"/api/v5/account/balance",
+		"/api/v5/account/positions?instId=BTC-USDT-SWAP&instType=SWAP",
+		"/api/v5/account/leverage-info?instId=BTC-USDT-SWAP&mgnMode=cross",
+		"/api/v5/account/config",
+		"/api/v5/public/instruments?instId=BTC-USDT&instType=SPOT",
+		"/api/v5/trade/orders-pending?instId=BTC-USDT&instType=SPOT",
+		"/api/v5/trade/fills?instId=BTC-USDT&instType=SPOT",
+	}
+	if len(*reqs) != len(want) {
+		t.Fatalf("请求数目: %v", len(*reqs))
+	}
+	for i, uri := range want {
+		if (*reqs)[i].uri != uri {
+			t.Errorf("请求 %v: %v, 期望: %v", i, (*reqs)[i].uri, uri)
+		}
+	}
+}
+
+func TestBootstrapDataRepo(t *testing.T) {
+	ts, _ := newTestServer(t, map[string]string{
+		"/api/v5/account/balance":      `{"code":"0","msg":"","data":[{"uTime":"1","details":[{"ccy":"USDT","cashBal":"100.5"}]}]}`,
+		"/api/v5/account/positions":    `{"code":"0","msg":"","data":[{"instId":"BTC-USDT-SWAP","posSide":"long","pos":"3"}]}`,
+		"/api/v5/trade/orders-pending": `{"code":"0","msg":"","data":[{"instId":"BTC-USDT-SWAP","ordId":"7","clOrdId":"c7","state":"live"}]}`,
+	})
+	c := NewClient(ts.URL, WithCredentials(testCreds))
+	dr := NewDataRepo()
+	if err := BootstrapDataRepo(c, dr, "SWAP", "BTC-USDT-SWAP"); err != nil {
+		t.Fatalf("BootstrapDataRepo: %v", err)
+	}
+	if _, usdt := dr.Balances(); usdt != 100.5 {
+		t.Fatalf("余额错误: %v", usdt)
+	}
+	if p := dr.Position("long"); p.Pos.String() != "3" {
+		t.Fatalf("持仓错误: %+v", p)
+	}
+	if orders := dr.OpenOrders(); len(orders) != 1 || orders[0].OrdId != "7" {
+		t.Fatalf("挂单错误: %+v", orders)
+	}
+}
@@ -0,0 +1,63 @@
+package restapi
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	. "github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 获取系统时间
+func (c *Client) GetServerTime() (time.Time, error) {
+	// 响应数据
+	var data []ServerTime
+	// 发送请求
+	if err := c.getPublic("/api/v5/public/time", nil, &data); err != nil {
+		// 返回错误
+		return time.Time{}, err
+	}
+	// 没有数据
+	if len(data) == 0 {
+		// 返回错误
+		return time.Time{}, errors.New("OKX REST 系统时间为空")
+	}
+	// 毫秒时间戳
+	ms := String2Int64(data[0].Ts)
+	// 返回时间
+	return time.Unix(0, ms*int64(time.Millisecond)), nil
+}
+
+// 获取产品基础信息, instId 为空时返回该产品类型全部产品
+func (c *Client) GetInstruments(instType, instId string) ([]Instrument, error) {
+	// 查询参数
+	query := url.Values{}
+	// 产品类型
+	addQuery(query, "instType", instType)
+	// 产品 ID
+	addQuery(query, "instId", instId)
+	// 响应数据
+	var data []Instrument
+	// 发送请求
+	err := c.getPublic("/api/v5/public/instruments", query, &data)
+	// 返回
+	return data, err
+}
+
+// 用系统时间接口同步交易所时钟
+func (c *Client) SyncClock() error {
+	// 发送时间
+	sent := time.Now()
+	// 系统时间
+	server, err := c.GetServerTime()
+	// 请求失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 更新偏移和往返时间
+	c.clock.Update(sent, time.Now(), server)
+	// 返回
+	return nil
+}
@@ -0,0 +1,39 @@
+package restapi
+
+import (
+	"net/url"
+
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 获取未成交订单列表
+func (c *Client) GetOpenOrders(instType, instId string) ([]Orders, error) {
+	// 查询参数
+	query := url.Values{}
+	// 产品类型
+	addQuery(query, "instType", instType)
+	// 产品 ID
+	addQuery(query, "instId", instId)
+	// 响应数据
+	var data []Orders
+	// 发送请求
+	err := c.getPrivate("/api/v5/trade/orders-pending", query, &data)
+	// 返回
+	return data, err
+}
+
+// 获取最近三天的成交明细
+func (c *Client) GetFills(instType, instId string) ([]Fill, error) {
+	// 查询参数
+	query := url.Values{}
+	// 产品类型
+	addQuery(query, "instType", instType)
+	// 产品 ID
+	addQuery(query, "instId", instId)
+	// 响应数据
+	var data []Fill
+	// 发送请求
+	err := c.getPrivate("/api/v5/trade/fills", query, &data)
+	// 返回
+	return data, err
+}
@@ -4,7 +4,7 @@ import (
 	"log"
 	"time"
 
-	. "github.com/wiger123/okex_v5_golang/config"
+	"github.com/wiger123/okex_v5_golang/config"
 	. "github.com/wiger123/okex_v5_golang/database"
 	. "github.com/wiger123/okex_v5_golang/utils"
 	. "github.com/wiger123/okex_v5_golang/wsdata/client"
@@ -13,38 +13,87 @@ import (
 
 // 印钞机策略
 func PrintMoney(c *OkxClient, dataRepo *DataRepo) {
+	// 只订阅成交和盘口事件, 行情推送写入后立即计算; 订单和账户变化不触发计算
+	sub := dataRepo.SubscribeEvents(config.EventBuffer, EventTrade, EventBook)
+	// 函数结束前取消订阅
+	defer sub.Close()
 	// 加载数据库
 	DatabaseLoader(dataRepo)
 	// 构建数据库
 	printMoneyData := NewPrintMoney()
-	// 策略循环
-	for {
+	// 每批事件判断一次
+	for waitEvents(sub) {
+		// 断线重连后行情数据已清空, 暂停策略等待数据重建
+		if !dataRepo.Ready() {
+			// 等待数据收集
+			DatabaseLoader(dataRepo)
+		}
+		// 盘口加权价格快照
+		printMoneyData.Prices = dataRepo.Book5AvgPrices()
+		// 加权价格不足
+		if len(printMoneyData.Prices) < config.NBook5sAvg {
+			// 等待下一批事件
+			continue
+		}
 		// 核心策略
 		PrintMoneyCore(*printMoneyData, dataRepo)
-		// 等待
-		time.Sleep(2 * time.Second)
 	}
 }
 
-// 数据库加载
+// 数据库加载: 收到成交或盘口事件时判断数据是否足够
 func DatabaseLoader(dataRepo *DataRepo) {
+	// 订阅成交和盘口事件, 只用于唤醒
+	sub := dataRepo.SubscribeEvents(config.EventBuffer, EventTrade, EventBook)
+	// 函数结束前取消订阅
+	defer sub.Close()
+	// 进度提示定时
+	ticker := time.NewTicker(500 * time.Millisecond)
+	// 函数结束前停止定时
+	defer ticker.Stop()
 	// 数据收集中, 等待启动
-	for {
-		// 判断交易数据数目 盘口数据数目
-		if len(dataRepo.TradeData) < Ntrade || len(dataRepo.Book5Data) < NBook5s {
+	for !dataRepo.Ready() {
+		// 等待事件或提示
+		select {
+		// 数据更新
+		case <-sub.Events():
+		// 进度提示
+		case <-ticker.C:
+			// 交易数据数目 盘口数据数目
+			nTrade, nBook5 := dataRepo.MarketDataLen()
 			// 提示
-			log.Printf("[普通提示] 基础数据正在收集中, 策略即将启动, 请等待: Trade: %v / %v, Book5: %v / %v", len(dataRepo.TradeData), Ntrade, len(dataRepo.Book5Data), NBook5s)
-		} else {
-			// 跳出循环
-			break
+			log.Printf("[普通提示] 基础数据正在收集中, 策略即将启动, 请等待: Trade: %v / %v, Book5: %v / %v", nTrade, config.Ntrade, nBook5, config.NBook5s)
 		}
-		// 等待
-		time.Sleep(500 * time.Millisecond)
 	}
 	// 策略启动
 	log.Printf("[成功提示] 策略启动")
 }
 
+// 等待下一批事件: 阻塞到有事件, 再取走已积压的事件, 每批只计算一次; 订阅关闭时返回 false
+func waitEvents(sub *Subscription) bool {
+	// 等待事件
+	if _, ok := <-sub.Events(); !ok {
+		// 订阅已关闭
+		return false
+	}
+	// 取走积压的事件
+	for {
+		// 非阻塞读取
+		select {
+		// 积压的事件
+		case _, ok := <-sub.Events():
+			// 订阅已关闭
+			if !ok {
+				// 返回
+				return false
+			}
+		// 没有积压
+		default:
+			// 返回
+			return true
+		}
+	}
+}
+
 // 加权交易量, 获取交易量时间
 func WeightVol(lastVol float64, lastTradeTime int64, dataRepo *DataRepo) (float64, int64) {
 	// 最新时间
@@ -55,16 +104,16 @@ func WeightVol(lastVol float64, lastTradeTime int64, dataRepo *DataRepo) (float6
 	var sumVol float64
 	// 初始化
 	sumVol = 0
-	// 循环
-	for i := 0; i < Ntrade; i++ {
+	// 逐条交易数据快照
+	for _, trade := range dataRepo.RecentTrades(config.Ntrade) {
 		// 时间戳
-		var time = String2Int64(dataRepo.TradeData[i].Ts)
+		var time = String2Int64(trade.Ts)
 		// 更新最新时间
 		newTradeTime = MaxInt64(newTradeTime, time)
 		// 交易时间大于上次交易时间
 		if time > lastTradeTime {
 			// 交易量累加
-			sumVol += String2Float64(dataRepo.TradeData[i].Sz)
+			sumVol += trade.Sz.Float64()
 		}
 	}
 	// 加权求和
@@ -77,22 +126,31 @@ func WeightVol(lastVol float64, lastTradeTime int64, dataRepo *DataRepo) (float6
 
 // 获取仓位数据, 平衡仓位
 func BalanceAccount(dataRepo *DataRepo) float64 {
+	// 最新盘口快照
+	book, ok := dataRepo.LatestBook()
+	// 没有盘口数据
+	if !ok {
+		// 返回
+		return 0
+	}
+	// Token 余额, USDT 余额
+	tokenAmt, usdtAmt := dataRepo.Balances()
 	// 仓位价值
-	tokenValue := dataRepo.TokenAmt * String2Float64(dataRepo.Book5Data[0].Bids[0][0])
+	tokenValue := tokenAmt * book.Bids[0][0].Float64()
 	// 仓位比例
-	res := tokenValue / (tokenValue + dataRepo.UsdtAmt)
+	res := tokenValue / (tokenValue + usdtAmt)
 	// 仓位小于平衡
-	if res < BalancePos-BalanceRel {
+	if res < config.BalancePos-config.BalanceRel {
 		// 挂小买单: Price: Bids[0] + 0.000 / 0.001 / 0.002  Size: 0.01
 		// 定时撤单
 	}
 	// 仓位大于平衡
-	if res > BalancePos+BalanceRel {
+	if res > config.BalancePos+config.BalanceRel {
 		// 挂小卖单: Price: Asks[0] - 0.000 / 0.001 / 0.002  Size: 0.01
 		// 定时撤单
 	}
 	// 仓位
-	log.Printf("[普通提示] Token: %v, Token 余额: %v, USDT: %v, USDT 余额: %v, 仓位占比: %v", TokenInstID, dataRepo.TokenAmt, UsdtInstID, dataRepo.UsdtAmt, res)
+	log.Printf("[普通提示] Token: %v, Token 余额: %v, USDT: %v, USDT 余额: %v, 仓位占比: %v", config.TokenInstID, tokenAmt, config.UsdtInstID, usdtAmt, res)
 	// 返回
 	return res
 }
@@ -100,10 +158,12 @@ func BalanceAccount(dataRepo *DataRepo) float64 {
 // 核心策略
 func PrintMoneyCore(printMoneyData PrintMoneyAttr, dataRepo *DataRepo) {
 	// 若当前有挂单
-	if len(dataRepo.OrdersData) > 0 {
+	if len(dataRepo.OpenOrders()) > 0 {
 		// 返回
 		return
 	}
+	// Token 余额, USDT 余额
+	tokenAmt, usdtAmt := dataRepo.Balances()
 	// 计数器
 	printMoneyData.NumTick++
 	// 获取加权交易量, 最近交易时间更新
@@ -111,7 +171,7 @@ func PrintMoneyCore(printMoneyData PrintMoneyAttr, dataRepo *DataRepo) {
 	// 平衡仓位
 	printMoneyData.P = BalanceAccount(dataRepo)
 	// 爆发价格
-	var burstPrice = printMoneyData.Prices[NBook5sAvg-1] * BurstThresholdPct
+	var burstPrice = printMoneyData.Prices[config.NBook5sAvg-1] * config.BurstThresholdPct
 	// 牛市变量
 	var bull = false
 	// 熊市变量
@@ -121,17 +181,17 @@ func PrintMoneyCore(printMoneyData PrintMoneyAttr, dataRepo *DataRepo) {
 	// 显示数据
 	log.Printf("[普通提示] 爆发价格: %v, 牛市变量: %v, 熊市变量: %v, 交易数量: %v", burstPrice, bull, bear, tradeAmount)
 	// 价格倒数 1
-	newPrice1 := printMoneyData.Prices[NBook5sAvg-1]
+	newPrice1 := printMoneyData.Prices[config.NBook5sAvg-1]
 	// 价格倒数 2
-	newPrice2 := printMoneyData.Prices[NBook5sAvg-2]
+	newPrice2 := printMoneyData.Prices[config.NBook5sAvg-2]
 	// 价格倒数 6 - 1 位最大值
-	maxLast6to1 := MaxSlice(printMoneyData.Prices[NBook5sAvg-6 : NBook5sAvg-1])
+	maxLast6to1 := MaxSlice(printMoneyData.Prices[config.NBook5sAvg-6 : config.NBook5sAvg-1])
 	// 价格倒数 6 - 1 位最小值
-	minLast6to1 := MinSlice(printMoneyData.Prices[NBook5sAvg-6 : NBook5sAvg-1])
+	minLast6to1 := MinSlice(printMoneyData.Prices[config.NBook5sAvg-6 : config.NBook5sAvg-1])
 	// 价格倒数 6 - 2 位最大值
-	maxLast6to2 := MaxSlice(printMoneyData.Prices[NBook5sAvg-6 : NBook5sAvg-2])
+	maxLast6to2 := MaxSlice(printMoneyData.Prices[config.NBook5sAvg-6 : config.NBook5sAvg-2])
 	// 价格倒数 6 - 2 位最小值
-	minLast6to2 := MinSlice(printMoneyData.Prices[NBook5sAvg-6 : NBook5sAvg-2])
+	minLast6to2 := MinSlice(printMoneyData.Prices[config.NBook5sAvg-6 : config.NBook5sAvg-2])
 	// 判断牛熊
 	if printMoneyData.NumTick > 2 &&
 		(newPrice1-maxLast6to1 > burstPrice ||
@@ -140,7 +200,7 @@ func PrintMoneyCore(printMoneyData PrintMoneyAttr, dataRepo *DataRepo) {
 		// 牛市变量
 		bull = true
 		// 交易数量
-		tradeAmount = dataRepo.UsdtAmt / printMoneyData.BidPrice * 0.99
+		tradeAmount = usdtAmt / printMoneyData.BidPrice * 0.99
 	} else if printMoneyData.NumTick > 2 &&
 		(newPrice1-minLast6to1 < -burstPrice ||
 			newPrice1-minLast6to2 < -burstPrice &&
@@ -148,13 +208,13 @@ func PrintMoneyCore(printMoneyData PrintMoneyAttr, dataRepo *DataRepo) {
 		// 熊市变量
 		bear = true
 		// 交易数量
-		tradeAmount = dataRepo.TokenAmt
+		tradeAmount = tokenAmt
 	}
 
 	// 缩减交易量: 历史交易量未达阈值
-	if printMoneyData.Vol < BurstThresholdVol {
+	if printMoneyData.Vol < config.BurstThresholdVol {
 		// 交易量
-		tradeAmount *= printMoneyData.Vol / BurstThresholdVol
+		tradeAmount *= printMoneyData.Vol / config.BurstThresholdVol
 	}
 	// 缩减交易量: 循环次数未达阈值
 	if printMoneyData.NumTick < 5 {
@@ -167,7 +227,7 @@ func PrintMoneyCore(printMoneyData PrintMoneyAttr, dataRepo *DataRepo) {
 		tradeAmount *= 0.8
 	}
 	// 非牛市熊市
-	if (!bull && !bear) || tradeAmount < MinStock {
+	if (!bull && !bear) || tradeAmount < config.MinStock {
 		// 返回
 		return
 	}
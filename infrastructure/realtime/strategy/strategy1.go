@@ -28,22 +28,12 @@ import (
 
 // 执行策略
 func OnStrategy1(c *OkxClient, dataRepo *DataRepo) {
-	// 循环
-	for {
-		// 判断交易数据数目 盘口数据数目
-		if len(dataRepo.TradeData) < config.Ntrade || len(dataRepo.Book5Data) < config.NBook5s {
-			// 提示
-			log.Printf("[普通提示] 基础数据正在收集中, 策略即将启动, 请等待: Trade: %v / %v, Book5: %v / %v", len(dataRepo.TradeData), config.Ntrade, len(dataRepo.Book5Data), config.NBook5s)
-		} else {
-			// 跳出循环
-			break
-		}
-		// 等待
-		time.Sleep(500 * time.Millisecond)
-	}
-
-	// 策略启动
-	log.Printf("[成功提示] 策略启动")
+	// 只订阅成交和盘口事件, 行情推送写入后立即计算; 自身订单和持仓变化不触发计算, 避免下单, 撤单互相唤醒
+	sub := dataRepo.SubscribeEvents(config.EventBuffer, EventTrade, EventBook)
+	// 函数结束前取消订阅
+	defer sub.Close()
+	// 等待数据收集
+	DatabaseLoader(dataRepo)
 
 	// 构建权重参数
 	var weightList []float64
@@ -82,8 +72,26 @@ func OnStrategy1(c *OkxClient, dataRepo *DataRepo) {
 	// 显示
 	log.Printf("[成功提示] 数据档位数: %v  每档数据量: %v", config.NumLevel, dataInterval)
 
-	// 循环
-	for {
+	// 每批事件计算一次
+	for waitEvents(sub) {
+		// 断线重连后行情数据已清空, 暂停策略等待数据重建
+		if !dataRepo.Ready() {
+			// 等待数据收集
+			DatabaseLoader(dataRepo)
+		}
+		// 交易数据快照: 本轮计算使用同一份数据, 不受推送影响
+		trades := dataRepo.RecentTrades(config.Ntrade)
+		// 最新盘口快照
+		book, ok := dataRepo.LatestBook()
+		// 数据不足, 可能刚被清空
+		if len(trades) < config.Ntrade || !ok {
+			// 等待下一批事件
+			continue
+		}
+		// 多仓快照
+		longPos := dataRepo.Position("long")
+		// 空仓快照
+		shortPos := dataRepo.Position("short")
 		// sell 权重
 		var sellWeight float64
 		// buy 权重
@@ -91,9 +99,9 @@ func OnStrategy1(c *OkxClient, dataRepo *DataRepo) {
 		// 计算买卖双方动向
 		for i := config.Ntrade - 1; i >= 0; i-- {
 			// 判断方向
-			if dataRepo.TradeData[i].Side == "buy" {
+			if trades[i].Side == "buy" {
 				// 量
-				var perSize, _ = strconv.ParseFloat(dataRepo.TradeData[i].Sz, 64)
+				var perSize = trades[i].Sz.Float64()
 				// 档位
 				var perLevel = int(math.Floor(float64(i) / float64(dataInterval)))
 				// 加权交易量
@@ -102,7 +110,7 @@ func OnStrategy1(c *OkxClient, dataRepo *DataRepo) {
 				buyWeight += perWeightSize
 			} else {
 				// 量
-				var perSize, _ = strconv.ParseFloat(dataRepo.TradeData[i].Sz, 64)
+				var perSize = trades[i].Sz.Float64()
 				// 档位
 				var perLevel = int(math.Floor(float64(i) / float64(dataInterval)))
 				// 加权交易量
@@ -122,51 +130,70 @@ func OnStrategy1(c *OkxClient, dataRepo *DataRepo) {
 			var cltId1 = GetRandString(config.ClOrdIdLength)
 			// 订单 ID
 			var cltId2 = GetRandString(config.ClOrdIdLength)
-			// 有空仓
-			if dataRepo.PositionsShortData.AvailPos != "" {
+			// 有可平的空仓
+			if shortPos.AvailPos.Sign() > 0 {
 				// 数量
-				var coverSize = dataRepo.PositionsShortData.AvailPos
+				var coverSize = shortPos.AvailPos
 				// 价格
-				var coverPrice = dataRepo.Book5Data[config.NBook5s-1].Bids[config.CoverShortLevel][0]
+				var coverPrice = book.Bids[config.CoverShortLevel][0]
 				// 平仓
-				var order1 = c.PostSingleOrder(config.InstID, config.TdMode, cltId1, "buy", "short", "post_only", coverSize, coverPrice)
-				// 添加订单
-				orders = append(orders, order1)
+				order1, err := c.NewOrder(config.InstID, TdMode(config.TdMode), SideBuy, OrdTypePostOnly, coverSize, WithPx(coverPrice), WithHedgePosSide(PosSideShort), WithClOrdId(cltId1), WithSnap())
+				// 订单参数错误
+				if err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 订单参数错误: %v", err)
+				} else {
+					// 添加订单
+					orders = append(orders, order1)
+				}
+			}
+			// 仍有未完成的订单 (含等待确认的本地订单) 时不开仓, 等待成交或定时撤单; 平仓不受限制
+			if len(dataRepo.OpenOrders()) == 0 {
+				// 数量
+				var postSize = DecimalFromFloat(postList[Min(int(math.Floor(sellWeight*10/config.MaxRef)), len(postList)-1)])
+				// 价格
+				var postPrice = book.Bids[config.BidsLevel][0]
+				// 开仓
+				order2, err := c.NewOrder(config.InstID, TdMode(config.TdMode), SideBuy, OrdTypePostOnly, postSize, WithPx(postPrice), WithHedgePosSide(PosSideLong), WithClOrdId(cltId2), WithQuoteSz(config.PostUnit == "usdt"), WithSnap())
+				// 订单参数错误
+				if err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 订单参数错误: %v", err)
+				} else {
+					// 添加订单
+					orders = append(orders, order2)
+				}
 			}
-			// 数量
-			var postSize = strconv.FormatFloat(postList[Min(int(math.Floor(sellWeight*10/config.MaxRef)), len(postList)-1)], 'f', config.FloatPrec, 64)
-			// 价格
-			var postPrice = dataRepo.Book5Data[config.NBook5s-1].Bids[config.BidsLevel][0]
-			// 开仓
-			var order2 = c.PostSingleOrder(config.InstID, config.TdMode, cltId2, "buy", "long", "post_only", postSize, postPrice)
-			// 添加订单
-			orders = append(orders, order2)
-			// 批量下单
-			c.PostOrders("posttest", "batch-orders", orders, dataRepo)
-			// 显示
-			// log.Printf("[成功提示] 平空  挂多: %v", postSize)
-			// 显示
-			// log.Printf("[成功提示] 挂单价格: %v", postPrice)
-
-			// 撤单定时
-			durationOfTime := time.Duration(config.TimeCancel) * time.Millisecond
-			// 取消订单函数
-			f := func() {
-				// 订单聚合
-				var corders []CancelOrder
-				// 撤销订单
-				var corder1 = c.CancelSingleOrder(config.InstID, "", cltId1)
-				// 撤销订单
-				var corder2 = c.CancelSingleOrder(config.InstID, "", cltId2)
-				// 添加订单
-				corders = append(corders, corder1)
-				// 添加订单
-				corders = append(corders, corder2)
-				// 批量撤单
-				c.CancelOrders("posttest", "batch-cancel-orders", corders, dataRepo)
+
+			// 判断订单长度
+			if len(orders) > 0 {
+				// 批量下单
+				c.PostOrders("batch-orders", orders, dataRepo)
+				// 显示
+				// log.Printf("[成功提示] 平空  挂多: %v", postSize)
+				// 显示
+				// log.Printf("[成功提示] 挂单价格: %v", postPrice)
+
+				// 撤单定时
+				durationOfTime := time.Duration(config.TimeCancel) * time.Millisecond
+				// 取消订单函数
+				f := func() {
+					// 订单聚合
+					var corders []CancelOrder
+					// 撤销订单
+					var corder1 = c.CancelSingleOrder(config.InstID, "", cltId1)
+					// 撤销订单
+					var corder2 = c.CancelSingleOrder(config.InstID, "", cltId2)
+					// 添加订单
+					corders = append(corders, corder1)
+					// 添加订单
+					corders = append(corders, corder2)
+					// 批量撤单
+					c.CancelOrders("batch-cancel-orders", corders, dataRepo)
+				}
+				// 计时器
+				time.AfterFunc(durationOfTime, f)
 			}
-			// 计时器
-			time.AfterFunc(durationOfTime, f)
 		}
 
 		// 挂空 平多
@@ -177,60 +204,76 @@ func OnStrategy1(c *OkxClient, dataRepo *DataRepo) {
 			var cltId1 = GetRandString(config.ClOrdIdLength)
 			// 订单 ID
 			var cltId2 = GetRandString(config.ClOrdIdLength)
-			// 有多仓
-			if dataRepo.PositionsLongData.AvailPos != "" {
+			// 有可平的多仓
+			if longPos.AvailPos.Sign() > 0 {
 				// 数量
-				var coverSize = dataRepo.PositionsLongData.AvailPos
+				var coverSize = longPos.AvailPos
 				// 价格
-				var coverPrice = dataRepo.Book5Data[config.NBook5s-1].Asks[config.CoverLongLevel][0]
+				var coverPrice = book.Asks[config.CoverLongLevel][0]
 				// 平仓
-				var order1 = c.PostSingleOrder(config.InstID, config.TdMode, cltId1, "sell", "long", "post_only", coverSize, coverPrice)
-				// 添加订单
-				orders = append(orders, order1)
+				order1, err := c.NewOrder(config.InstID, TdMode(config.TdMode), SideSell, OrdTypePostOnly, coverSize, WithPx(coverPrice), WithHedgePosSide(PosSideLong), WithClOrdId(cltId1), WithSnap())
+				// 订单参数错误
+				if err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 订单参数错误: %v", err)
+				} else {
+					// 添加订单
+					orders = append(orders, order1)
+				}
+			}
+			// 仍有未完成的订单 (含等待确认的本地订单) 时不开仓, 等待成交或定时撤单; 平仓不受限制
+			if len(dataRepo.OpenOrders()) == 0 {
+				// 数量
+				var postSize = DecimalFromFloat(postList[Min(int(math.Floor(sellWeight*10/config.MaxRef)), len(postList)-1)])
+				// 价格
+				var postPrice = book.Asks[config.AsksLevel][0]
+				// 开仓
+				order2, err := c.NewOrder(config.InstID, TdMode(config.TdMode), SideSell, OrdTypePostOnly, postSize, WithPx(postPrice), WithHedgePosSide(PosSideShort), WithClOrdId(cltId2), WithQuoteSz(config.PostUnit == "usdt"), WithSnap())
+				// 订单参数错误
+				if err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 订单参数错误: %v", err)
+				} else {
+					// 添加订单
+					orders = append(orders, order2)
+				}
 			}
-			// 数量
-			var postSize = strconv.FormatFloat(postList[Min(int(math.Floor(sellWeight*10/config.MaxRef)), len(postList)-1)], 'f', config.FloatPrec, 64)
-			// 价格
-			var postPrice = dataRepo.Book5Data[config.NBook5s-1].Asks[config.AsksLevel][0]
-			// 开仓
-			var order2 = c.PostSingleOrder(config.InstID, config.TdMode, cltId2, "sell", "short", "post_only", postSize, postPrice)
-			// 添加订单
-			orders = append(orders, order2)
-			// 批量下单
-			c.PostOrders("posttest", "batch-orders", orders, dataRepo)
-			// 显示
-			// log.Printf("[成功提示] 平多  挂空: %v", postSize)
-			// 显示
-			// log.Printf("[成功提示] 挂单价格: %v", postPrice)
-
-			// 撤单定时
-			durationOfTime := time.Duration(config.TimeCancel) * time.Millisecond
-			// 取消订单函数
-			f := func() {
-				// 订单聚合
-				var corders []CancelOrder
-				// 撤销订单
-				var corder1 = c.CancelSingleOrder(config.InstID, "", cltId1)
-				// 撤销订单
-				var corder2 = c.CancelSingleOrder(config.InstID, "", cltId2)
-				// 添加订单
-				corders = append(corders, corder1)
-				// 添加订单
-				corders = append(corders, corder2)
-				// 批量撤单
-				c.CancelOrders("posttest", "batch-cancel-orders", corders, dataRepo)
+
+			// 判断订单长度
+			if len(orders) > 0 {
+				// 批量下单
+				c.PostOrders("batch-orders", orders, dataRepo)
+				// 显示
+				// log.Printf("[成功提示] 平多  挂空: %v", postSize)
+				// 显示
+				// log.Printf("[成功提示] 挂单价格: %v", postPrice)
+
+				// 撤单定时
+				durationOfTime := time.Duration(config.TimeCancel) * time.Millisecond
+				// 取消订单函数
+				f := func() {
+					// 订单聚合
+					var corders []CancelOrder
+					// 撤销订单
+					var corder1 = c.CancelSingleOrder(config.InstID, "", cltId1)
+					// 撤销订单
+					var corder2 = c.CancelSingleOrder(config.InstID, "", cltId2)
+					// 添加订单
+					corders = append(corders, corder1)
+					// 添加订单
+					corders = append(corders, corder2)
+					// 批量撤单
+					c.CancelOrders("batch-cancel-orders", corders, dataRepo)
+				}
+				// 计时器
+				time.AfterFunc(durationOfTime, f)
 			}
-			// 计时器
-			time.AfterFunc(durationOfTime, f)
 		}
 
 		// 仓位信息
-		// log.Printf("[成功提示] 多仓信息: %v  空仓信息: %v", dataRepo.PositionsLongData, dataRepo.PositionsShortData)
+		// log.Printf("[成功提示] 多仓信息: %v  空仓信息: %v", longPos, shortPos)
 
 		// 挂单信息
-		// log.Printf("[成功提示] 挂单信息: %v", dataRepo.OrdersData)
-
-		// 等待
-		time.Sleep(100 * time.Millisecond)
+		// log.Printf("[成功提示] 挂单信息: %v", dataRepo.OpenOrders())
 	}
 }
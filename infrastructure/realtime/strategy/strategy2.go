@@ -35,22 +35,13 @@ import (
 **/
 
 // 执行策略
-func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
-	for {
-		// 判断交易数据数目 盘口数据数目
-		if len(dataRepo.TradeData) < config.Ntrade || len(dataRepo.Book5Data) < config.NBook5s {
-			// 提示
-			log.Printf("[普通提示] 基础数据正在收集中, 策略即将启动, 请等待: Trade: %v / %v, Book5: %v / %v", len(dataRepo.TradeData), config.Ntrade, len(dataRepo.Book5Data), config.NBook5s)
-		} else {
-			// 跳出循环
-			break
-		}
-		// 等待
-		time.Sleep(500 * time.Millisecond)
-	}
-
-	// 策略启动
-	log.Printf("[成功提示] 策略启动")
+func OnStrategy2(c *OkxClient, dataRepo *DataRepo) {
+	// 只订阅成交和盘口事件, 行情推送写入后立即计算; 自身订单和持仓变化不触发计算, 避免下单, 撤单互相唤醒
+	sub := dataRepo.SubscribeEvents(config.EventBuffer, EventTrade, EventBook)
+	// 函数结束前取消订阅
+	defer sub.Close()
+	// 等待数据收集
+	DatabaseLoader(dataRepo)
 
 	// 构建权重参数
 	var weightList []float64
@@ -89,8 +80,26 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 	// 显示
 	log.Printf("[成功提示] 数据档位数: %v  每档数据量: %v", config.NumLevel, dataInterval)
 
-	// 循环
-	for {
+	// 每批事件计算一次
+	for waitEvents(sub) {
+		// 断线重连后行情数据已清空, 暂停策略等待数据重建
+		if !dataRepo.Ready() {
+			// 等待数据收集
+			DatabaseLoader(dataRepo)
+		}
+		// 交易数据快照: 本轮计算使用同一份数据, 不受推送影响
+		trades := dataRepo.RecentTrades(config.Ntrade)
+		// 最新盘口快照
+		book, ok := dataRepo.LatestBook()
+		// 数据不足, 可能刚被清空
+		if len(trades) < config.Ntrade || !ok {
+			// 等待下一批事件
+			continue
+		}
+		// 多仓快照
+		longPos := dataRepo.Position("long")
+		// 空仓快照
+		shortPos := dataRepo.Position("short")
 		// sell 权重
 		var sellWeight float64
 		// buy 权重
@@ -98,9 +107,9 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 		// 计算买卖双方动向
 		for i := config.Ntrade - 1; i >= 0; i-- {
 			// 判断方向
-			if dataRepo.TradeData[i].Side == "buy" {
+			if trades[i].Side == "buy" {
 				// 量
-				var perSize, _ = strconv.ParseFloat(dataRepo.TradeData[i].Sz, 64)
+				var perSize = trades[i].Sz.Float64()
 				// 档位
 				var perLevel = int(math.Floor(float64(i) / float64(dataInterval)))
 				// 加权交易量
@@ -109,7 +118,7 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 				buyWeight += perWeightSize
 			} else {
 				// 量
-				var perSize, _ = strconv.ParseFloat(dataRepo.TradeData[i].Sz, 64)
+				var perSize = trades[i].Sz.Float64()
 				// 档位
 				var perLevel = int(math.Floor(float64(i) / float64(dataInterval)))
 				// 加权交易量
@@ -122,17 +131,19 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 		// log.Printf("[成功提示] 买单加权量: %v  卖单加权量: %v", buyWeight, sellWeight)
 
 		// 若有多单盈利或趋势上涨: 平多
-		if (dataRepo.PositionsLongData.AvailPos != "" && dataRepo.PositionsLongData.AvailPos != "0") && (buyWeight-config.CoverRatio*sellWeight > 0 && buyWeight > config.CoverMinTradeVolume) {
+		if longPos.AvailPos.Sign() > 0 && (buyWeight-config.CoverRatio*sellWeight > 0 && buyWeight > config.CoverMinTradeVolume) {
 			// Ask 0 档
-			var askGate, _ = strconv.ParseFloat(dataRepo.Book5Data[config.NBook5s-1].Asks[0][0], 64)
+			var askGate = book.Asks[0][0].Float64()
 			// Bid 0 档
-			var bidGate, _ = strconv.ParseFloat(dataRepo.Book5Data[config.NBook5s-1].Bids[0][0], 64)
+			var bidGate = book.Bids[0][0].Float64()
 			// 实际均价
 			var midPrice = (askGate + bidGate) / 2.0
 			// 开仓价格
-			var avgPrice, _ = strconv.ParseFloat(dataRepo.PositionsLongData.AvgPx, 64)
+			var avgPrice = longPos.AvgPx.Float64()
+			// 产品信息, 合约类型决定收益率算法
+			inst, _ := c.Instrument(config.InstID)
 			// 收益率
-			var profit = GetProfitRatio(avgPrice, midPrice, config.Leverage, "long")
+			var profit = GetProfitRatio(avgPrice, midPrice, config.Leverage, "long", inst.CtType)
 			// 止盈 止损
 			if profit > config.StopProfit || profit < config.StopLoss {
 				// 订单 ID
@@ -140,20 +151,26 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 				// 订单聚合
 				var orders []PostOrder
 				// 数量
-				var coverSize = dataRepo.PositionsLongData.AvailPos
+				var coverSize = longPos.AvailPos
 				// 价格
-				var coverPrice = dataRepo.Book5Data[config.NBook5s-1].Asks[config.CoverLongLevel][0]
+				var coverPrice = book.Asks[config.CoverLongLevel][0]
 				// 平仓
-				var order1 = c.PostSingleOrder(config.InstID, config.TdMode, cltId1, "sell", "long", config.OrdType, coverSize, coverPrice)
-				// 添加订单
-				orders = append(orders, order1)
+				order1, err := c.NewOrder(config.InstID, TdMode(config.TdMode), SideSell, OrdType(config.OrdType), coverSize, WithPx(coverPrice), WithHedgePosSide(PosSideLong), WithClOrdId(cltId1), WithSnap())
+				// 订单参数错误
+				if err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 订单参数错误: %v", err)
+				} else {
+					// 添加订单
+					orders = append(orders, order1)
+				}
 
 				// 判断订单长度
 				if len(orders) > 0 {
 					// 显示信息
 					// log.Printf("[普通提示] 多单止盈: %v", orders)
 					// 批量下单
-					c.PostOrders("posttest", "batch-orders", orders, dataRepo)
+					c.PostOrders("batch-orders", orders, dataRepo)
 					// 撤单定时
 					durationOfTime := time.Duration(config.TimeCancel) * time.Millisecond
 					// 取消订单函数
@@ -165,7 +182,7 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 						// 添加订单
 						corders = append(corders, corder1)
 						// 批量撤单
-						c.CancelOrders("posttest", "batch-cancel-orders", corders, dataRepo)
+						c.CancelOrders("batch-cancel-orders", corders, dataRepo)
 					}
 					// 计时器
 					time.AfterFunc(durationOfTime, f)
@@ -174,17 +191,19 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 		}
 
 		// 若有空单盈利或趋势下跌: 平空
-		if (dataRepo.PositionsShortData.AvailPos != "" && dataRepo.PositionsShortData.Pos != "0") && (sellWeight-config.CoverRatio*buyWeight > 0 && sellWeight > config.CoverMinTradeVolume) {
+		if (shortPos.AvailPos.Sign() > 0 && !shortPos.Pos.IsZero()) && (sellWeight-config.CoverRatio*buyWeight > 0 && sellWeight > config.CoverMinTradeVolume) {
 			// Ask 0 档
-			var askGate, _ = strconv.ParseFloat(dataRepo.Book5Data[config.NBook5s-1].Asks[0][0], 64)
+			var askGate = book.Asks[0][0].Float64()
 			// Bid 0 档
-			var bidGate, _ = strconv.ParseFloat(dataRepo.Book5Data[config.NBook5s-1].Bids[0][0], 64)
+			var bidGate = book.Bids[0][0].Float64()
 			// 实际均价
 			var midPrice = (askGate + bidGate) / 2.0
 			// 开仓价格
-			var avgPrice, _ = strconv.ParseFloat(dataRepo.PositionsShortData.AvgPx, 64)
+			var avgPrice = shortPos.AvgPx.Float64()
+			// 产品信息, 合约类型决定收益率算法
+			inst, _ := c.Instrument(config.InstID)
 			// 收益率
-			var profit = GetProfitRatio(avgPrice, midPrice, config.Leverage, "short")
+			var profit = GetProfitRatio(avgPrice, midPrice, config.Leverage, "short", inst.CtType)
 			// 止盈 止损
 			if profit > config.StopProfit || profit < config.StopLoss {
 				// 订单 ID
@@ -192,20 +211,26 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 				// 订单聚合
 				var orders []PostOrder
 				// 数量
-				var coverSize = dataRepo.PositionsShortData.AvailPos
+				var coverSize = shortPos.AvailPos
 				// 价格
-				var coverPrice = dataRepo.Book5Data[config.NBook5s-1].Bids[config.CoverShortLevel][0]
+				var coverPrice = book.Bids[config.CoverShortLevel][0]
 				// 平仓
-				var order1 = c.PostSingleOrder(config.InstID, config.TdMode, cltId1, "buy", "short", config.OrdType, coverSize, coverPrice)
-				// 添加订单
-				orders = append(orders, order1)
+				order1, err := c.NewOrder(config.InstID, TdMode(config.TdMode), SideBuy, OrdType(config.OrdType), coverSize, WithPx(coverPrice), WithHedgePosSide(PosSideShort), WithClOrdId(cltId1), WithSnap())
+				// 订单参数错误
+				if err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 订单参数错误: %v", err)
+				} else {
+					// 添加订单
+					orders = append(orders, order1)
+				}
 
 				// 判断订单长度
 				if len(orders) > 0 {
 					// 显示信息
 					// log.Printf("[普通提示] 空单止盈: %v", orders)
 					// 批量下单
-					c.PostOrders("posttest", "batch-orders", orders, dataRepo)
+					c.PostOrders("batch-orders", orders, dataRepo)
 					// 撤单定时
 					durationOfTime := time.Duration(config.TimeCancel) * time.Millisecond
 					// 取消订单函数
@@ -217,7 +242,7 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 						// 添加订单
 						corders = append(corders, corder1)
 						// 批量撤单
-						c.CancelOrders("posttest", "batch-cancel-orders", corders, dataRepo)
+						c.CancelOrders("batch-cancel-orders", corders, dataRepo)
 					}
 					// 计时器
 					time.AfterFunc(durationOfTime, f)
@@ -233,31 +258,43 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 			var cltId1 = GetRandString(config.ClOrdIdLength)
 			// 订单 ID
 			var cltId2 = GetRandString(config.ClOrdIdLength)
-			// 有空仓
-			if dataRepo.PositionsShortData.AvailPos != "" {
+			// 有可平的空仓
+			if shortPos.AvailPos.Sign() > 0 {
 				// 数量
-				var coverSize = dataRepo.PositionsShortData.AvailPos
+				var coverSize = shortPos.AvailPos
 				// 价格
-				var coverPrice = dataRepo.Book5Data[config.NBook5s-1].Bids[config.CoverShortLevel][0]
+				var coverPrice = book.Bids[config.CoverShortLevel][0]
 				// 平仓
-				var order1 = c.PostSingleOrder(config.InstID, config.TdMode, cltId1, "buy", "short", config.OrdType, coverSize, coverPrice)
-				// 添加订单
-				orders = append(orders, order1)
+				order1, err := c.NewOrder(config.InstID, TdMode(config.TdMode), SideBuy, OrdType(config.OrdType), coverSize, WithPx(coverPrice), WithHedgePosSide(PosSideShort), WithClOrdId(cltId1), WithSnap())
+				// 订单参数错误
+				if err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 订单参数错误: %v", err)
+				} else {
+					// 添加订单
+					orders = append(orders, order1)
+				}
 			}
 
 			// 若有订单或持仓则不挂单
-			if (dataRepo.PositionsLongData.Pos == "" || dataRepo.PositionsLongData.Pos == "0") && len(dataRepo.OrdersData) == 0 {
+			if longPos.Pos.IsZero() && len(dataRepo.OpenOrders()) == 0 {
 				// 数量
-				var postSize = strconv.FormatFloat(postList[Min(int(math.Floor(sellWeight*10/config.MaxRef)), len(postList)-1)], 'f', config.FloatPrec, 64)
+				var postSize = DecimalFromFloat(postList[Min(int(math.Floor(sellWeight*10/config.MaxRef)), len(postList)-1)])
 				// 价格
-				var postPrice = dataRepo.Book5Data[config.NBook5s-1].Bids[config.BidsLevel][0]
+				var postPrice = book.Bids[config.BidsLevel][0]
 				// 开仓
-				var order2 = c.PostSingleOrder(config.InstID, config.TdMode, cltId2, "buy", "long", config.OrdType, postSize, postPrice)
-				// 添加订单
-				orders = append(orders, order2)
+				order2, err := c.NewOrder(config.InstID, TdMode(config.TdMode), SideBuy, OrdType(config.OrdType), postSize, WithPx(postPrice), WithHedgePosSide(PosSideLong), WithClOrdId(cltId2), WithQuoteSz(config.PostUnit == "usdt"), WithSnap())
+				// 订单参数错误
+				if err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 订单参数错误: %v", err)
+				} else {
+					// 添加订单
+					orders = append(orders, order2)
+				}
 			} else {
 				// 显示不下单原因
-				// log.Printf("[普通提示] 未下单 仓位数据: %v  订单数据: %v", dataRepo.PositionsLongData, dataRepo.OrdersData)
+				// log.Printf("[普通提示] 未下单 仓位数据: %v  订单数据: %v", longPos, dataRepo.OpenOrders())
 			}
 
 			// 判断订单长度
@@ -265,7 +302,7 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 				// 显示信息
 				// log.Printf("[普通提示] 挂多平空: %v", orders)
 				// 批量下单
-				c.PostOrders("posttest", "batch-orders", orders, dataRepo)
+				c.PostOrders("batch-orders", orders, dataRepo)
 				// 撤单定时
 				durationOfTime := time.Duration(config.TimeCancel) * time.Millisecond
 				// 取消订单函数
@@ -281,7 +318,7 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 					// 添加订单
 					corders = append(corders, corder2)
 					// 批量撤单
-					c.CancelOrders("posttest", "batch-cancel-orders", corders, dataRepo)
+					c.CancelOrders("batch-cancel-orders", corders, dataRepo)
 				}
 				// 计时器
 				time.AfterFunc(durationOfTime, f)
@@ -301,31 +338,43 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 			var cltId1 = GetRandString(config.ClOrdIdLength)
 			// 订单 ID
 			var cltId2 = GetRandString(config.ClOrdIdLength)
-			// 有多仓
-			if dataRepo.PositionsLongData.AvailPos != "" {
+			// 有可平的多仓
+			if longPos.AvailPos.Sign() > 0 {
 				// 数量
-				var coverSize = dataRepo.PositionsLongData.AvailPos
+				var coverSize = longPos.AvailPos
 				// 价格
-				var coverPrice = dataRepo.Book5Data[config.NBook5s-1].Asks[config.CoverLongLevel][0]
+				var coverPrice = book.Asks[config.CoverLongLevel][0]
 				// 平仓
-				var order1 = c.PostSingleOrder(config.InstID, config.TdMode, cltId1, "sell", "long", config.OrdType, coverSize, coverPrice)
-				// 添加订单
-				orders = append(orders, order1)
+				order1, err := c.NewOrder(config.InstID, TdMode(config.TdMode), SideSell, OrdType(config.OrdType), coverSize, WithPx(coverPrice), WithHedgePosSide(PosSideLong), WithClOrdId(cltId1), WithSnap())
+				// 订单参数错误
+				if err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 订单参数错误: %v", err)
+				} else {
+					// 添加订单
+					orders = append(orders, order1)
+				}
 			}
 
 			// 若有订单或持仓则不挂单
-			if (dataRepo.PositionsShortData.Pos == "" || dataRepo.PositionsShortData.Pos == "0") && len(dataRepo.OrdersData) == 0 {
+			if shortPos.Pos.IsZero() && len(dataRepo.OpenOrders()) == 0 {
 				// 数量
-				var postSize = strconv.FormatFloat(postList[Min(int(math.Floor(sellWeight*10/config.MaxRef)), len(postList)-1)], 'f', config.FloatPrec, 64)
+				var postSize = DecimalFromFloat(postList[Min(int(math.Floor(sellWeight*10/config.MaxRef)), len(postList)-1)])
 				// 价格
-				var postPrice = dataRepo.Book5Data[config.NBook5s-1].Asks[config.AsksLevel][0]
+				var postPrice = book.Asks[config.AsksLevel][0]
 				// 开仓
-				var order2 = c.PostSingleOrder(config.InstID, config.TdMode, cltId2, "sell", "short", config.OrdType, postSize, postPrice)
-				// 添加订单
-				orders = append(orders, order2)
+				order2, err := c.NewOrder(config.InstID, TdMode(config.TdMode), SideSell, OrdType(config.OrdType), postSize, WithPx(postPrice), WithHedgePosSide(PosSideShort), WithClOrdId(cltId2), WithQuoteSz(config.PostUnit == "usdt"), WithSnap())
+				// 订单参数错误
+				if err != nil {
+					// 错误提示
+					log.Printf("[错误提示] 订单参数错误: %v", err)
+				} else {
+					// 添加订单
+					orders = append(orders, order2)
+				}
 			} else {
 				// 显示不下单原因
-				// log.Printf("[普通提示] 未下单 仓位数据: %v  订单数据: %v", dataRepo.PositionsLongData, dataRepo.OrdersData)
+				// log.Printf("[普通提示] 未下单 仓位数据: %v  订单数据: %v", longPos, dataRepo.OpenOrders())
 			}
 
 			// 判断订单长度
@@ -333,7 +382,7 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 				// 显示信息
 				// log.Printf("[普通提示] 挂空平多: %v", orders)
 				// 批量下单
-				c.PostOrders("posttest", "batch-orders", orders, dataRepo)
+				c.PostOrders("batch-orders", orders, dataRepo)
 				// 撤单定时
 				durationOfTime := time.Duration(config.TimeCancel) * time.Millisecond
 				// 取消订单函数
@@ -349,7 +398,7 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 					// 添加订单
 					corders = append(corders, corder2)
 					// 批量撤单
-					c.CancelOrders("posttest", "batch-cancel-orders", corders, dataRepo)
+					c.CancelOrders("batch-cancel-orders", corders, dataRepo)
 				}
 				// 计时器
 				time.AfterFunc(durationOfTime, f)
@@ -362,12 +411,9 @@ func OnStrategy2(c *OkxClient, dataRepo *DataRepo) { // 循环
 		}
 
 		// 仓位信息
-		// log.Printf("[成功提示] 多仓信息: %v  空仓信息: %v", dataRepo.PositionsLongData, dataRepo.PositionsShortData)
+		// log.Printf("[成功提示] 多仓信息: %v  空仓信息: %v", longPos, shortPos)
 
 		// 挂单信息
-		// log.Printf("[成功提示] 挂单信息: %v", dataRepo.OrdersData)
-
-		// 等待
-		time.Sleep(100 * time.Millisecond)
+		// log.Printf("[成功提示] 挂单信息: %v", dataRepo.OpenOrders())
 	}
 }
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// 往返时间平滑系数
+const rttSmoothing = 0.2
+
+// 时钟偏移平滑系数: 往返时间偏大的样本只部分采纳
+const offsetSmoothing = 0.1
+
+// 交易所时钟: 记录本地时钟与交易所服务器的偏移和往返时间
+// REST 对时请求和 websocket ping/pong 的往返时间差异较大, 分开平滑, 偏移样本只与 REST 往返时间比较
+type Clock struct {
+	// 并发锁
+	mux sync.RWMutex
+	// 服务器时间减本地时间
+	offset time.Duration
+	// REST 对时请求的平滑往返时间
+	restRTT time.Duration
+	// websocket ping/pong 的平滑往返时间
+	pingRTT time.Duration
+	// 是否已同步
+	synced bool
+}
+
+// 创建时钟, 同步前偏移为 0
+func NewClock() *Clock {
+	// 返回时钟
+	return &Clock{}
+}
+
+// 校正后的当前时间
+func (c *Clock) Now() time.Time {
+	// 上锁
+	c.mux.RLock()
+	// 函数结束前解锁
+	defer c.mux.RUnlock()
+	// 本地时间加偏移
+	return time.Now().Add(c.offset)
+}
+
+// 服务器时间减本地时间
+func (c *Clock) Offset() time.Duration {
+	// 上锁
+	c.mux.RLock()
+	// 函数结束前解锁
+	defer c.mux.RUnlock()
+	// 返回偏移
+	return c.offset
+}
+
+// websocket ping/pong 的平滑往返时间
+func (c *Clock) RTT() time.Duration {
+	// 上锁
+	c.mux.RLock()
+	// 函数结束前解锁
+	defer c.mux.RUnlock()
+	// 返回往返时间
+	return c.pingRTT
+}
+
+// REST 对时请求的平滑往返时间
+func (c *Clock) RestRTT() time.Duration {
+	// 上锁
+	c.mux.RLock()
+	// 函数结束前解锁
+	defer c.mux.RUnlock()
+	// 返回往返时间
+	return c.restRTT
+}
+
+// 是否已与服务器同步
+func (c *Clock) Synced() bool {
+	// 上锁
+	c.mux.RLock()
+	// 函数结束前解锁
+	defer c.mux.RUnlock()
+	// 返回状态
+	return c.synced
+}
+
+// 用一次请求更新偏移: sent 发送时间, recv 收到时间, server 服务器时间
+func (c *Clock) Update(sent, recv, server time.Time) {
+	// 往返时间
+	rtt := recv.Sub(sent)
+	// 假设服务器在往返中点记录时间
+	offset := server.Sub(sent.Add(rtt / 2))
+	// 上锁
+	c.mux.Lock()
+	// 函数结束前解锁
+	defer c.mux.Unlock()
+	// 首次同步或往返时间不大于 REST 平均值, 样本可信, 直接采纳
+	if !c.synced || rtt <= c.restRTT {
+		// 采纳偏移
+		c.offset = offset
+	} else {
+		// 部分采纳
+		c.offset += time.Duration(float64(offset-c.offset) * offsetSmoothing)
+	}
+	// 更新 REST 往返时间
+	smoothRTT(&c.restRTT, rtt)
+	// 标记同步
+	c.synced = true
+}
+
+// 记录一次 websocket ping/pong 往返时间, 不影响偏移样本的取舍
+func (c *Clock) ObserveRTT(rtt time.Duration) {
+	// 上锁
+	c.mux.Lock()
+	// 函数结束前解锁
+	defer c.mux.Unlock()
+	// 更新往返时间
+	smoothRTT(&c.pingRTT, rtt)
+}
+
+// 指数平滑往返时间, 需在持有锁时调用
+func smoothRTT(avg *time.Duration, rtt time.Duration) {
+	// 首次记录
+	if *avg == 0 {
+		// 直接采纳
+		*avg = rtt
+		// 返回
+		return
+	}
+	// 指数平滑
+	*avg += time.Duration(float64(rtt-*avg) * rttSmoothing)
+}
+
+// 交易所毫秒时间戳到校正后当前时间的延迟, 时间戳无效时返回 false
+func (c *Clock) Latency(ts string) (time.Duration, bool) {
+	// 毫秒时间戳
+	ms := String2Int64(ts)
+	// 时间戳无效
+	if ms <= 0 {
+		// 返回
+		return 0, false
+	}
+	// 延迟
+	return c.Now().Sub(time.Unix(0, ms*int64(time.Millisecond))), true
+}
+
+// 延迟统计: 累计一段时间内的平均和最大延迟, 可并发调用
+type LatencyStats struct {
+	// 并发锁
+	mux sync.Mutex
+	// 样本数
+	count int64
+	// 延迟总和
+	sum time.Duration
+	// 最大延迟
+	max time.Duration
+}
+
+// 创建延迟统计
+func NewLatencyStats() *LatencyStats {
+	// 返回统计
+	return &LatencyStats{}
+}
+
+// 记录一次延迟
+func (s *LatencyStats) Observe(d time.Duration) {
+	// 上锁
+	s.mux.Lock()
+	// 函数结束前解锁
+	defer s.mux.Unlock()
+	// 样本数
+	s.count++
+	// 延迟总和
+	s.sum += d
+	// 最大延迟
+	if s.count == 1 || d > s.max {
+		// 更新
+		s.max = d
+	}
+}
+
+// 取出统计结果并重置: 平均延迟, 最大延迟, 样本数
+func (s *LatencyStats) Take() (time.Duration, time.Duration, int64) {
+	// 上锁
+	s.mux.Lock()
+	// 函数结束前解锁
+	defer s.mux.Unlock()
+	// 没有样本
+	if s.count == 0 {
+		// 返回
+		return 0, 0, 0
+	}
+	// 平均延迟, 最大延迟, 样本数
+	avg, max, n := s.sum/time.Duration(s.count), s.max, s.count
+	// 重置
+	s.count, s.sum, s.max = 0, 0, 0
+	// 返回
+	return avg, max, n
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClockUpdate(t *testing.T) {
+	c := NewClock()
+	base := time.Unix(1700000000, 0)
+	// 首次同步直接采纳: 服务器在往返中点记录时间
+	c.Update(base, base.Add(100*time.Millisecond), base.Add(50*time.Millisecond+time.Second))
+	if !c.Synced() || c.Offset() != time.Second || c.RestRTT() != 100*time.Millisecond {
+		t.Fatalf("首次同步: offset %v, rtt %v", c.Offset(), c.RestRTT())
+	}
+	// 往返时间偏大的样本只部分采纳
+	c.Update(base, base.Add(300*time.Millisecond), base.Add(150*time.Millisecond+2*time.Second))
+	if got := c.Offset(); got != time.Second+100*time.Millisecond {
+		t.Fatalf("部分采纳: offset %v", got)
+	}
+}
+
+func TestClockPingRTTDoesNotAffectOffset(t *testing.T) {
+	c := NewClock()
+	base := time.Unix(1700000000, 0)
+	c.Update(base, base.Add(100*time.Millisecond), base.Add(50*time.Millisecond+time.Second))
+	// websocket ping 往返时间远小于 REST 请求
+	for i := 0; i < 20; i++ {
+		c.ObserveRTT(time.Millisecond)
+	}
+	if c.RTT() != time.Millisecond || c.RestRTT() != 100*time.Millisecond {
+		t.Fatalf("往返时间混合: ping %v, rest %v", c.RTT(), c.RestRTT())
+	}
+	// 往返时间不大于 REST 平均值的样本仍直接采纳
+	c.Update(base, base.Add(80*time.Millisecond), base.Add(40*time.Millisecond+2*time.Second))
+	if got := c.Offset(); got != 2*time.Second {
+		t.Fatalf("可信样本未直接采纳: offset %v", got)
+	}
+}
+
+func TestClockLatency(t *testing.T) {
+	c := NewClock()
+	base := time.Now()
+	// 服务器时钟比本地快 1 秒
+	c.Update(base, base, base.Add(time.Second))
+	ts := strconv.FormatInt(c.Now().Add(-200*time.Millisecond).UnixNano()/int64(time.Millisecond), 10)
+	d, ok := c.Latency(ts)
+	if !ok || d < 200*time.Millisecond || d > 300*time.Millisecond {
+		t.Fatalf("延迟按校正时钟计算: %v, %v", d, ok)
+	}
+	for _, ts := range []string{"", "abc", "0"} {
+		if _, ok := c.Latency(ts); ok {
+			t.Errorf("无效时间戳 %q 期望 false", ts)
+		}
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	s := NewLatencyStats()
+	if _, _, n := s.Take(); n != 0 {
+		t.Fatalf("空统计样本数: %v", n)
+	}
+	for _, d := range []time.Duration{10, 30, 20} {
+		s.Observe(d * time.Millisecond)
+	}
+	avg, max, n := s.Take()
+	if avg != 20*time.Millisecond || max != 30*time.Millisecond || n != 3 {
+		t.Fatalf("统计结果: %v %v %v", avg, max, n)
+	}
+	// 取出后重置
+	if _, _, n := s.Take(); n != 0 {
+		t.Fatalf("重置后样本数: %v", n)
+	}
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// API 密钥: 每个账户或子账户一份
+type Credentials struct {
+	// API Key
+	ApiKey string `json:"apiKey"`
+	// API Secret Key
+	SecretKey string `json:"secretKey"`
+	// API Passphrase
+	PassPhrase string `json:"passphrase"`
+}
+
+// 密钥是否完整
+func (c Credentials) Valid() bool {
+	// 三项均不为空
+	return c.ApiKey != "" && c.SecretKey != "" && c.PassPhrase != ""
+}
+
+// 用 Secret Key 签名
+func (c Credentials) Sign(message string) (string, error) {
+	// HMAC SHA256
+	return HmacSha256Base64Signer(message, c.SecretKey)
+}
+
+// 日志中只显示 API Key 前 4 位, 不显示 Secret Key 和 Passphrase
+func (c Credentials) String() string {
+	// API Key 前缀
+	prefix := c.ApiKey
+	// 截取前 4 位
+	if len(prefix) > 4 {
+		// 截取
+		prefix = prefix[:4]
+	}
+	// 字符串格式化
+	return fmt.Sprintf("Credentials{ApiKey: %v****}", prefix)
+}
+
+// 从环境变量读取密钥: PREFIX_API_KEY, PREFIX_SECRET_KEY, PREFIX_PASSPHRASE
+func CredentialsFromEnv(prefix string) (Credentials, error) {
+	// 变量名前缀
+	prefix = strings.ToUpper(prefix)
+	// 读取密钥
+	c := Credentials{
+		// API Key
+		ApiKey: os.Getenv(prefix + "_API_KEY"),
+		// API Secret Key
+		SecretKey: os.Getenv(prefix + "_SECRET_KEY"),
+		// API Passphrase
+		PassPhrase: os.Getenv(prefix + "_PASSPHRASE"),
+	}
+	// 密钥不完整
+	if !c.Valid() {
+		// 返回错误
+		return Credentials{}, fmt.Errorf("环境变量 %v_API_KEY / %v_SECRET_KEY / %v_PASSPHRASE 未设置完整", prefix, prefix, prefix)
+	}
+	// 返回
+	return c, nil
+}
+
+// 从 json 文件读取密钥, 文件不能被其他用户读写
+func CredentialsFromFile(path string) (Credentials, error) {
+	// 文件信息
+	info, err := os.Stat(path)
+	// 读取失败
+	if err != nil {
+		// 返回错误
+		return Credentials{}, err
+	}
+	// 权限检查, Windows 不支持 Unix 权限位
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		// 返回错误
+		return Credentials{}, fmt.Errorf("密钥文件 %v 权限为 %v, 需限制为仅本人可读写 (chmod 600)", path, info.Mode().Perm())
+	}
+	// 读取文件
+	data, err := ioutil.ReadFile(path)
+	// 读取失败
+	if err != nil {
+		// 返回错误
+		return Credentials{}, err
+	}
+	// 密钥初始化
+	var c Credentials
+	// 解析文件
+	if err := json.Unmarshal(data, &c); err != nil {
+		// 返回错误
+		return Credentials{}, fmt.Errorf("密钥文件 %v 解析失败: %v", path, err)
+	}
+	// 密钥不完整
+	if !c.Valid() {
+		// 返回错误
+		return Credentials{}, errors.New("密钥文件 " + path + " 缺少 apiKey / secretKey / passphrase")
+	}
+	// 返回
+	return c, nil
+}
@@ -0,0 +1,490 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// 定点小数: 数值为 coef * 10^-scale, 用于价格和数量的精确计算
+// 零值为空值, 对应 OKX 的空字符串, 参与运算时按 0 处理
+type Decimal struct {
+	// 有效数字, nil 表示空值
+	coef *big.Int
+	// 小数位数, 不小于 0
+	scale int32
+}
+
+// 十进制
+var bigTen = big.NewInt(10)
+
+// 解析时指数和小数位数的上限, 避免 "1e999999999" 之类的输入分配巨大的整数
+const maxDecimalExp = 100
+
+// 除数为 0
+var ErrDivByZero = errors.New("小数除数为 0")
+
+// 10 的 n 次方; 解析时限制了指数, Round 和 Div 限制了保留位数, n 只随运算的小数位数增长
+func pow10(n int32) *big.Int {
+	// 返回
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// 解析十进制字符串, 支持 "-1.25", "0.010", "1e-5"; 指数和小数位数绝对值不超过 100
+func NewDecimal(s string) (Decimal, error) {
+	// 原始字符串
+	in := s
+	// 指数
+	var exp int64
+	// 科学计数法
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		// 解析指数
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		// 解析失败或超出上限
+		if err != nil || e > maxDecimalExp || e < -maxDecimalExp {
+			// 返回错误
+			return Decimal{}, fmt.Errorf("无效小数 %q", in)
+		}
+		// 指数
+		exp = e
+		// 尾数部分
+		s = s[:i]
+	}
+	// 是否为负数
+	neg := false
+	// 符号位
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		// 负数
+		neg = s[0] == '-'
+		// 去掉符号
+		s = s[1:]
+	}
+	// 整数部分, 小数部分
+	intPart, fracPart := s, ""
+	// 小数点
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		// 拆分
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	// 全部数字
+	digits := intPart + fracPart
+	// 没有数字
+	if digits == "" {
+		// 返回错误
+		return Decimal{}, fmt.Errorf("无效小数 %q", in)
+	}
+	// 逐个字符
+	for i := 0; i < len(digits); i++ {
+		// 非数字
+		if digits[i] < '0' || digits[i] > '9' {
+			// 返回错误
+			return Decimal{}, fmt.Errorf("无效小数 %q", in)
+		}
+	}
+	// 有效数字
+	coef, _ := new(big.Int).SetString(digits, 10)
+	// 负数
+	if neg {
+		// 取反
+		coef.Neg(coef)
+	}
+	// 小数位数
+	scale := int64(len(fracPart)) - exp
+	// 超出范围
+	if scale > maxDecimalExp || scale < -maxDecimalExp {
+		// 返回错误
+		return Decimal{}, fmt.Errorf("无效小数 %q", in)
+	}
+	// 负的小数位数转为整数
+	if scale < 0 {
+		// 乘以 10 的幂
+		coef.Mul(coef, pow10(int32(-scale)))
+		// 小数位数
+		scale = 0
+	}
+	// 返回
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// 解析十进制字符串, 失败时 panic, 仅用于常量
+func MustDecimal(s string) Decimal {
+	// 解析
+	d, err := NewDecimal(s)
+	// 解析失败
+	if err != nil {
+		// 抛出
+		panic(err)
+	}
+	// 返回
+	return d
+}
+
+// 整数转小数
+func DecimalFromInt(v int64) Decimal {
+	// 返回
+	return Decimal{coef: big.NewInt(v)}
+}
+
+// 浮点数转小数, 取能还原该浮点数的最短十进制表示, NaN, Inf 和超出小数位数上限的值返回空值
+func DecimalFromFloat(f float64) Decimal {
+	// 非有限值
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		// 返回空值
+		return Decimal{}
+	}
+	// 最短表示必定可以解析
+	d, _ := NewDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	// 返回
+	return d
+}
+
+// 是否为空值
+func (d Decimal) IsEmpty() bool {
+	// 返回
+	return d.coef == nil
+}
+
+// 有效数字, 空值为 0
+func (d Decimal) int() *big.Int {
+	// 空值
+	if d.coef == nil {
+		// 返回 0
+		return new(big.Int)
+	}
+	// 返回
+	return d.coef
+}
+
+// 按更大的小数位数取有效数字
+func (d Decimal) rescale(scale int32) *big.Int {
+	// 位数相同
+	if scale == d.scale {
+		// 返回
+		return d.int()
+	}
+	// 乘以 10 的幂
+	return new(big.Int).Mul(d.int(), pow10(scale-d.scale))
+}
+
+// 小数位数
+func (d Decimal) Scale() int32 {
+	// 返回
+	return d.scale
+}
+
+// 符号: -1, 0, 1
+func (d Decimal) Sign() int {
+	// 返回
+	return d.int().Sign()
+}
+
+// 是否为 0, 空值也为 0
+func (d Decimal) IsZero() bool {
+	// 返回
+	return d.Sign() == 0
+}
+
+// 比较大小: d < o 返回 -1, 相等返回 0, d > o 返回 1
+func (d Decimal) Cmp(o Decimal) int {
+	// 对齐小数位数
+	scale := max32(d.scale, o.scale)
+	// 返回
+	return d.rescale(scale).Cmp(o.rescale(scale))
+}
+
+// 数值是否相等, "1.0" 与 "1" 相等
+func (d Decimal) Equal(o Decimal) bool {
+	// 返回
+	return d.Cmp(o) == 0
+}
+
+// 是否小于
+func (d Decimal) LessThan(o Decimal) bool {
+	// 返回
+	return d.Cmp(o) < 0
+}
+
+// 是否大于
+func (d Decimal) GreaterThan(o Decimal) bool {
+	// 返回
+	return d.Cmp(o) > 0
+}
+
+// 加法
+func (d Decimal) Add(o Decimal) Decimal {
+	// 对齐小数位数
+	scale := max32(d.scale, o.scale)
+	// 返回
+	return Decimal{coef: new(big.Int).Add(d.rescale(scale), o.rescale(scale)), scale: scale}
+}
+
+// 减法
+func (d Decimal) Sub(o Decimal) Decimal {
+	// 对齐小数位数
+	scale := max32(d.scale, o.scale)
+	// 返回
+	return Decimal{coef: new(big.Int).Sub(d.rescale(scale), o.rescale(scale)), scale: scale}
+}
+
+// 乘法
+func (d Decimal) Mul(o Decimal) Decimal {
+	// 返回
+	return Decimal{coef: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// 除法, 结果保留 places 位小数 (不超过 100), 四舍五入; 除数为 0 或空值时返回 ErrDivByZero
+func (d Decimal) Div(o Decimal, places int32) (Decimal, error) {
+	// 除数为 0
+	if o.Sign() == 0 {
+		// 返回错误
+		return Decimal{}, ErrDivByZero
+	}
+	// 小数位数在 0 到上限之间
+	places = min32(max32(places, 0), maxDecimalExp)
+	// 被除数: d.coef * 10^(o.scale + places)
+	num := new(big.Int).Mul(d.int(), pow10(o.scale+places))
+	// 除数: o.coef * 10^d.scale
+	den := new(big.Int).Mul(o.int(), pow10(d.scale))
+	// 返回
+	return Decimal{coef: quoRound(num, den, roundHalfUp), scale: places}, nil
+}
+
+// 取反
+func (d Decimal) Neg() Decimal {
+	// 返回
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// 绝对值
+func (d Decimal) Abs() Decimal {
+	// 返回
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// 保留 places 位小数 (不超过 100), 四舍五入
+func (d Decimal) Round(places int32) Decimal {
+	// 小数位数在 0 到上限之间
+	places = min32(max32(places, 0), maxDecimalExp)
+	// 位数不足时补 0
+	if places >= d.scale {
+		// 返回
+		return Decimal{coef: d.rescale(places), scale: places}
+	}
+	// 返回
+	return Decimal{coef: quoRound(d.int(), pow10(d.scale-places), roundHalfUp), scale: places}
+}
+
+// 按最小变动单位四舍五入, 如 tickSz, lotSz; 结果与 step 小数位数一致
+func (d Decimal) RoundTo(step Decimal) Decimal {
+	// 返回
+	return d.toStep(step, roundHalfUp)
+}
+
+// 按最小变动单位向下取整
+func (d Decimal) FloorTo(step Decimal) Decimal {
+	// 返回
+	return d.toStep(step, roundFloor)
+}
+
+// 按最小变动单位向上取整
+func (d Decimal) CeilTo(step Decimal) Decimal {
+	// 返回
+	return d.toStep(step, roundCeil)
+}
+
+// 是否为最小变动单位的整数倍
+func (d Decimal) IsMultipleOf(step Decimal) bool {
+	// 非正数单位
+	if step.Sign() <= 0 {
+		// 返回
+		return false
+	}
+	// 对齐小数位数
+	scale := max32(d.scale, step.scale)
+	// 余数
+	rem := new(big.Int).Rem(d.rescale(scale), step.rescale(scale))
+	// 返回
+	return rem.Sign() == 0
+}
+
+// 按最小变动单位取整, step 非正数时原样返回
+func (d Decimal) toStep(step Decimal, mode roundMode) Decimal {
+	// 非正数单位
+	if step.Sign() <= 0 {
+		// 返回
+		return d
+	}
+	// 对齐小数位数
+	scale := max32(d.scale, step.scale)
+	// 单位个数
+	n := quoRound(d.rescale(scale), step.rescale(scale), mode)
+	// 返回
+	return Decimal{coef: n.Mul(n, step.int()), scale: step.scale}
+}
+
+// 转为浮点数, 仅用于统计计算
+func (d Decimal) Float64() float64 {
+	// 解析
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	// 返回
+	return f
+}
+
+// 十进制字符串, 保留原有小数位数, 空值为空字符串
+func (d Decimal) String() string {
+	// 空值
+	if d.coef == nil {
+		// 返回
+		return ""
+	}
+	// 绝对值数字
+	digits := new(big.Int).Abs(d.coef).String()
+	// 符号
+	sign := ""
+	// 负数
+	if d.coef.Sign() < 0 {
+		// 负号
+		sign = "-"
+	}
+	// 整数
+	if d.scale == 0 {
+		// 返回
+		return sign + digits
+	}
+	// 位数不足时补 0
+	if len(digits) <= int(d.scale) {
+		// 补 0
+		digits = strings.Repeat("0", int(d.scale)-len(digits)+1) + digits
+	}
+	// 小数点位置
+	point := len(digits) - int(d.scale)
+	// 返回
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// 转为 OKX 字符串格式
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	// 返回
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// 解析 OKX 字符串格式, 也接受 json 数字; 空字符串和 null 为空值
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	// 原始内容
+	s := string(data)
+	// null
+	if s == "null" {
+		// 空值
+		*d = Decimal{}
+		// 返回
+		return nil
+	}
+	// 字符串
+	if strings.HasPrefix(s, "\"") {
+		// 去掉引号
+		if err := json.Unmarshal(data, &s); err != nil {
+			// 返回错误
+			return err
+		}
+	}
+	// 空字符串
+	if s == "" {
+		// 空值
+		*d = Decimal{}
+		// 返回
+		return nil
+	}
+	// 解析
+	v, err := NewDecimal(s)
+	// 解析失败
+	if err != nil {
+		// 返回错误
+		return err
+	}
+	// 赋值
+	*d = v
+	// 返回
+	return nil
+}
+
+// 取整方式
+type roundMode int
+
+// 取整方式枚举
+const (
+	// 四舍五入, 0.5 远离 0
+	roundHalfUp roundMode = iota
+	// 向下取整
+	roundFloor
+	// 向上取整
+	roundCeil
+)
+
+// 整数除法并按取整方式取整
+func quoRound(num, den *big.Int, mode roundMode) *big.Int {
+	// 商和余数, 商向 0 截断
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	// 整除
+	if r.Sign() == 0 {
+		// 返回
+		return q
+	}
+	// 精确商的符号
+	sign := int64(num.Sign() * den.Sign())
+	// 取整方式
+	switch mode {
+	// 向下取整: 负数商再减 1
+	case roundFloor:
+		// 负数
+		if sign < 0 {
+			// 减 1
+			q.Sub(q, big.NewInt(1))
+		}
+	// 向上取整: 正数商再加 1
+	case roundCeil:
+		// 正数
+		if sign > 0 {
+			// 加 1
+			q.Add(q, big.NewInt(1))
+		}
+	// 四舍五入: 余数两倍不小于除数时远离 0
+	default:
+		// 余数两倍
+		r2 := new(big.Int).Abs(r)
+		// 乘 2
+		r2.Lsh(r2, 1)
+		// 不小于除数
+		if r2.Cmp(new(big.Int).Abs(den)) >= 0 {
+			// 远离 0
+			q.Add(q, big.NewInt(sign))
+		}
+	}
+	// 返回
+	return q
+}
+
+// 取大
+func max32(a, b int32) int32 {
+	// a 比 b 大
+	if a > b {
+		// 返回 a
+		return a
+	}
+	// 否则返回 b
+	return b
+}
+
+// 取小
+func min32(a, b int32) int32 {
+	// a 比 b 小
+	if a < b {
+		// 返回 a
+		return a
+	}
+	// 否则返回 b
+	return b
+}
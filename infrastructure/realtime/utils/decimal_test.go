@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNewDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "0", want: "0"},
+		{in: "1.25", want: "1.25"},
+		{in: "-1.25", want: "-1.25"},
+		{in: "+3", want: "3"},
+		{in: "0.010", want: "0.010"},
+		{in: ".5", want: "0.5"},
+		{in: "5.", want: "5"},
+		{in: "1e-5", want: "0.00001"},
+		{in: "1.5E3", want: "1500"},
+		{in: "-2.5e-2", want: "-0.025"},
+		{in: "1e100", want: "1" + zeros(100)},
+		{in: "1e-100", want: "0." + zeros(99) + "1"},
+		{in: "", err: true},
+		{in: "-", err: true},
+		{in: ".", err: true},
+		{in: "abc", err: true},
+		{in: "1.2.3", err: true},
+		{in: "1e", err: true},
+		{in: "1e1.5", err: true},
+		{in: "1e101", err: true},
+		{in: "1e-101", err: true},
+		{in: "1e999999999", err: true},
+		{in: "1e-999999999", err: true},
+		{in: "1e99999999999999999999", err: true},
+		{in: "0." + zeros(100) + "1", err: true},
+	}
+	for _, tt := range tests {
+		d, err := NewDecimal(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("NewDecimal(%q) = %v, 期望错误", tt.in, d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewDecimal(%q): %v", tt.in, err)
+			continue
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("NewDecimal(%q) = %v, 期望 %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// n 个 0
+func zeros(n int) string {
+	// 结果
+	b := make([]byte, n)
+	// 逐位
+	for i := range b {
+		// 0
+		b[i] = '0'
+	}
+	// 返回
+	return string(b)
+}
+
+func TestDecimalString(t *testing.T) {
+	tests := []struct {
+		d    Decimal
+		want string
+	}{
+		{d: Decimal{}, want: ""},
+		{d: DecimalFromInt(0), want: "0"},
+		{d: DecimalFromInt(-42), want: "-42"},
+		{d: MustDecimal("0.001"), want: "0.001"},
+		{d: MustDecimal("-0.001"), want: "-0.001"},
+		{d: MustDecimal("100.00"), want: "100.00"},
+		{d: DecimalFromFloat(0.1), want: "0.1"},
+		{d: DecimalFromFloat(-1234.5678), want: "-1234.5678"},
+		{d: DecimalFromFloat(math.NaN()), want: ""},
+		{d: DecimalFromFloat(math.Inf(1)), want: ""},
+	}
+	for _, tt := range tests {
+		if got := tt.d.String(); got != tt.want {
+			t.Errorf("String() = %q, 期望 %q", got, tt.want)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		a, b               string
+		add, sub, mul, cmp string
+	}{
+		{a: "0.1", b: "0.2", add: "0.3", sub: "-0.1", mul: "0.02", cmp: "-1"},
+		{a: "1.50", b: "1.5", add: "3.00", sub: "0.00", mul: "2.250", cmp: "0"},
+		{a: "-2", b: "0.25", add: "-1.75", sub: "-2.25", mul: "-0.50", cmp: "-1"},
+		{a: "100", b: "-0.001", add: "99.999", sub: "100.001", mul: "-0.100", cmp: "1"},
+	}
+	for _, tt := range tests {
+		a, b := MustDecimal(tt.a), MustDecimal(tt.b)
+		if got := a.Add(b).String(); got != tt.add {
+			t.Errorf("%v + %v = %v, 期望 %v", tt.a, tt.b, got, tt.add)
+		}
+		if got := a.Sub(b).String(); got != tt.sub {
+			t.Errorf("%v - %v = %v, 期望 %v", tt.a, tt.b, got, tt.sub)
+		}
+		if got := a.Mul(b).String(); got != tt.mul {
+			t.Errorf("%v * %v = %v, 期望 %v", tt.a, tt.b, got, tt.mul)
+		}
+		if got := DecimalFromInt(int64(a.Cmp(b))).String(); got != tt.cmp {
+			t.Errorf("Cmp(%v, %v) = %v, 期望 %v", tt.a, tt.b, got, tt.cmp)
+		}
+	}
+	// 空值按 0 处理
+	if got := (Decimal{}).Add(MustDecimal("1.5")).String(); got != "1.5" {
+		t.Errorf("空值 + 1.5 = %v", got)
+	}
+	if !MustDecimal("1.0").Equal(DecimalFromInt(1)) {
+		t.Error("1.0 应等于 1")
+	}
+}
+
+func TestDecimalDiv(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		places int32
+		want   string
+	}{
+		{a: "1", b: "3", places: 4, want: "0.3333"},
+		{a: "2", b: "3", places: 4, want: "0.6667"},
+		{a: "-2", b: "3", places: 2, want: "-0.67"},
+		{a: "1", b: "8", places: 2, want: "0.13"},
+		{a: "-1", b: "8", places: 2, want: "-0.13"},
+		{a: "10", b: "0.25", places: 0, want: "40"},
+		{a: "0.001", b: "1000", places: 6, want: "0.000001"},
+		{a: "7", b: "2", places: -3, want: "4"},
+	}
+	for _, tt := range tests {
+		got, err := MustDecimal(tt.a).Div(MustDecimal(tt.b), tt.places)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("%v / %v (%v 位) = %v, %v, 期望 %v", tt.a, tt.b, tt.places, got, err, tt.want)
+		}
+	}
+	// 除数为 0 或空值
+	for _, den := range []Decimal{DecimalFromInt(0), MustDecimal("0.000"), {}} {
+		if _, err := DecimalFromInt(1).Div(den, 2); !errors.Is(err, ErrDivByZero) {
+			t.Errorf("1 / %q: 期望 ErrDivByZero, 实际 %v", den.String(), err)
+		}
+	}
+	// 保留位数超出上限时按上限计算
+	got, err := DecimalFromInt(1).Div(DecimalFromInt(3), math.MaxInt32)
+	if err != nil || got.Scale() != maxDecimalExp {
+		t.Errorf("保留位数上限: %v 位, %v", got.Scale(), err)
+	}
+}
+
+func TestDecimalRounding(t *testing.T) {
+	tests := []struct {
+		in, step                 string
+		round, floor, ceil, half string
+	}{
+		{in: "1.2345", step: "0.01", round: "1.23", floor: "1.23", ceil: "1.24", half: "1.235"},
+		{in: "1.235", step: "0.01", round: "1.24", floor: "1.23", ceil: "1.24", half: "1.235"},
+		{in: "-1.235", step: "0.01", round: "-1.24", floor: "-1.24", ceil: "-1.23", half: "-1.235"},
+		{in: "7", step: "5", round: "5", floor: "5", ceil: "10", half: "7.000"},
+		{in: "0.00012", step: "0.0001", round: "0.0001", floor: "0.0001", ceil: "0.0002", half: "0.000"},
+	}
+	for _, tt := range tests {
+		d, step := MustDecimal(tt.in), MustDecimal(tt.step)
+		if got := d.RoundTo(step).String(); got != tt.round {
+			t.Errorf("RoundTo(%v, %v) = %v, 期望 %v", tt.in, tt.step, got, tt.round)
+		}
+		if got := d.FloorTo(step).String(); got != tt.floor {
+			t.Errorf("FloorTo(%v, %v) = %v, 期望 %v", tt.in, tt.step, got, tt.floor)
+		}
+		if got := d.CeilTo(step).String(); got != tt.ceil {
+			t.Errorf("CeilTo(%v, %v) = %v, 期望 %v", tt.in, tt.step, got, tt.ceil)
+		}
+		if got := d.Round(3).String(); got != tt.half {
+			t.Errorf("Round(%v, 3) = %v, 期望 %v", tt.in, got, tt.half)
+		}
+	}
+	if !MustDecimal("0.30").IsMultipleOf(MustDecimal("0.1")) || MustDecimal("0.35").IsMultipleOf(MustDecimal("0.1")) {
+		t.Error("IsMultipleOf 错误")
+	}
+	// 非正数单位原样返回
+	if got := MustDecimal("1.234").FloorTo(Decimal{}).String(); got != "1.234" {
+		t.Errorf("FloorTo 空单位 = %v", got)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	type payload struct {
+		Px Decimal `json:"px"`
+		Sz Decimal `json:"sz"`
+	}
+	tests := []struct {
+		in, px, sz, out string
+	}{
+		{in: `{"px":"0.0100","sz":"12"}`, px: "0.0100", sz: "12", out: `{"px":"0.0100","sz":"12"}`},
+		{in: `{"px":"","sz":null}`, px: "", sz: "", out: `{"px":"","sz":""}`},
+		{in: `{"px":1.5,"sz":-3}`, px: "1.5", sz: "-3", out: `{"px":"1.5","sz":"-3"}`},
+		{in: `{"px":"1e-3"}`, px: "0.001", sz: "", out: `{"px":"0.001","sz":""}`},
+	}
+	for _, tt := range tests {
+		var p payload
+		if err := json.Unmarshal([]byte(tt.in), &p); err != nil {
+			t.Errorf("Unmarshal(%v): %v", tt.in, err)
+			continue
+		}
+		if p.Px.String() != tt.px || p.Sz.String() != tt.sz {
+			t.Errorf("Unmarshal(%v) = %v, %v", tt.in, p.Px, p.Sz)
+		}
+		out, err := json.Marshal(p)
+		if err != nil || string(out) != tt.out {
+			t.Errorf("Marshal(%v) = %s, %v, 期望 %v", tt.in, out, err, tt.out)
+		}
+	}
+	for _, in := range []string{`{"px":"abc"}`, `{"px":"1e999999999"}`, `{"px":true}`} {
+		var p payload
+		if err := json.Unmarshal([]byte(in), &p); err == nil {
+			t.Errorf("Unmarshal(%v) 期望错误", in)
+		}
+	}
+}
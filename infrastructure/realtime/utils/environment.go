@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/wiger123/okex_v5_golang/config"
+)
+
+// 交易环境名称
+const (
+	// 实盘
+	EnvLive = "live"
+	// 模拟盘
+	EnvDemo = "demo"
+	// 自定义地址
+	EnvCustom = "custom"
+)
+
+// 模拟盘请求头
+const SimulatedTradingHeader = "x-simulated-trading"
+
+// 交易环境: 连接地址和是否为模拟盘
+type Environment struct {
+	// 环境名称
+	Name string
+	// 公共频道地址
+	PublicURL string
+	// 私有频道地址
+	PrivateURL string
+	// REST 接口地址
+	RestURL string
+	// 是否为模拟盘
+	Simulated bool
+}
+
+// 按名称获取交易环境, 地址来自配置
+func NewEnvironment(name string) (Environment, error) {
+	// 环境判断
+	switch name {
+	// 实盘
+	case EnvLive:
+		// 返回
+		return LiveEnvironment(), nil
+	// 模拟盘
+	case EnvDemo:
+		// 返回
+		return DemoEnvironment(), nil
+	// 自定义地址
+	case EnvCustom:
+		// 返回
+		return Environment{
+			// 环境名称
+			Name: EnvCustom,
+			// 公共频道地址
+			PublicURL: config.CustomPublicURL,
+			// 私有频道地址
+			PrivateURL: config.CustomPrivateURL,
+			// REST 接口地址
+			RestURL: config.CustomRestURL,
+			// 是否为模拟盘
+			Simulated: config.CustomSimulated,
+		}, nil
+	}
+	// 未知环境
+	return Environment{}, fmt.Errorf("未知交易环境: %v, 可选 %v / %v / %v", name, EnvLive, EnvDemo, EnvCustom)
+}
+
+// 实盘环境
+func LiveEnvironment() Environment {
+	// 返回
+	return Environment{
+		// 环境名称
+		Name: EnvLive,
+		// 公共频道地址
+		PublicURL: config.PublicURL,
+		// 私有频道地址
+		PrivateURL: config.PrivateURL,
+		// REST 接口地址
+		RestURL: config.RestURL,
+	}
+}
+
+// 模拟盘环境
+func DemoEnvironment() Environment {
+	// 返回
+	return Environment{
+		// 环境名称
+		Name: EnvDemo,
+		// 公共频道地址
+		PublicURL: config.DemoPublicURL,
+		// 私有频道地址
+		PrivateURL: config.DemoPrivateURL,
+		// REST 接口地址
+		RestURL: config.DemoRestURL,
+		// 是否为模拟盘
+		Simulated: true,
+	}
+}
+
+// 连接和请求需附带的请求头, 模拟盘附带 x-simulated-trading: 1
+func (e Environment) Header() http.Header {
+	// 请求头
+	header := http.Header{}
+	// 模拟盘
+	if e.Simulated {
+		// 模拟盘标记
+		header.Set(SimulatedTradingHeader, "1")
+	}
+	// 返回
+	return header
+}
@@ -4,7 +4,6 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
-	"log"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -63,9 +62,7 @@ func HmacSha256Base64Signer(message string, secretKey string) (string, error) {
 	_, err := mac.Write([]byte(message))
 	// 发送错误
 	if err != nil {
-		// 错误提示
-		log.Fatalf("[错误提示] HMAC SHA256 加密失败: %v", err)
-		// 返回
+		// 返回错误, 由调用方处理
 		return "", err
 	}
 	// 返回加密签名
@@ -104,19 +101,31 @@ func GetRandString(length int) string {
 	return StringWithCharset(length, charset)
 }
 
-// 收益率计算
-func GetProfitRatio(avgPx, nowPx, lever float64, posSide string) float64 {
+// 收益率计算 (百分比): ctType 为 inverse 时按反向合约计算, 其余按正向合约和币币计算
+func GetProfitRatio(avgPx, nowPx, lever float64, posSide, ctType string) float64 {
+	// 价格无效
+	if avgPx <= 0 || nowPx <= 0 {
+		// 返回
+		return 0
+	}
+	// 基准价格: 正向为开仓均价
+	basePx := avgPx
+	// 反向合约: 收益率 = (1 / 开仓均价 - 1 / 当前价格) * 开仓均价 = 价差 / 当前价格
+	if ctType == "inverse" {
+		// 基准价格为当前价格
+		basePx = nowPx
+	}
 	// 收益率
 	var pr float64
 	// 多
 	if posSide == "long" {
 		// 计算收益率
-		pr = (nowPx - avgPx) / avgPx * lever * 100
+		pr = (nowPx - avgPx) / basePx * lever * 100
 	}
 	// 空
 	if posSide == "short" {
 		// 计算收益率
-		pr = (avgPx - nowPx) / avgPx * lever * 100
+		pr = (avgPx - nowPx) / basePx * lever * 100
 	}
 	// 返回结果
 	return pr
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGetProfitRatio(t *testing.T) {
+	tests := []struct {
+		name         string
+		avgPx, nowPx float64
+		lever        float64
+		posSide      string
+		ctType       string
+		want         float64
+	}{
+		{name: "正向多盈利", avgPx: 20000, nowPx: 22000, lever: 10, posSide: "long", ctType: "linear", want: 100},
+		{name: "正向多亏损", avgPx: 20000, nowPx: 19000, lever: 10, posSide: "long", ctType: "linear", want: -50},
+		{name: "正向空盈利", avgPx: 20000, nowPx: 19000, lever: 10, posSide: "short", ctType: "linear", want: 50},
+		{name: "币币按正向计算", avgPx: 20000, nowPx: 22000, lever: 1, posSide: "long", ctType: "", want: 10},
+		// 反向合约按当前价格计算: 2000 / 22000 * 10 * 100
+		{name: "反向多盈利", avgPx: 20000, nowPx: 22000, lever: 10, posSide: "long", ctType: "inverse", want: 2000.0 / 22000 * 1000},
+		{name: "反向空亏损", avgPx: 20000, nowPx: 22000, lever: 10, posSide: "short", ctType: "inverse", want: -2000.0 / 22000 * 1000},
+		{name: "反向空盈利", avgPx: 20000, nowPx: 19000, lever: 10, posSide: "short", ctType: "inverse", want: 1000.0 / 19000 * 1000},
+		{name: "未知方向", avgPx: 20000, nowPx: 22000, lever: 10, posSide: "net", ctType: "linear", want: 0},
+		{name: "价格无效", avgPx: 0, nowPx: 22000, lever: 10, posSide: "long", ctType: "linear", want: 0},
+	}
+	for _, tt := range tests {
+		got := GetProfitRatio(tt.avgPx, tt.nowPx, tt.lever, tt.posSide, tt.ctType)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%v: GetProfitRatio = %v, 期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -8,8 +9,9 @@ import (
 	"sync"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/wiger123/okex_v5_golang/config"
+	. "github.com/wiger123/okex_v5_golang/database"
+	"github.com/wiger123/okex_v5_golang/recorder"
 	. "github.com/wiger123/okex_v5_golang/utils"
 	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
 )
@@ -29,16 +31,81 @@ type Arg struct {
 // 信息处理
 type MessageHandler func(m PushMessage)
 
+// 连接状态
+type ConnState int
+
+// 连接状态枚举
+const (
+	// 连接断开, 正在重连
+	StateDisconnected ConnState = iota
+	// 重连成功, 已恢复登录和订阅
+	StateReconnected
+)
+
+// 连接状态处理
+type StateHandler func(s ConnState)
+
+// 错误处理, 由调用方按错误类别决定重试, 撤单或退出
+type ErrorHandler func(err error)
+
 // 客户端
 type OkxClient struct {
+	// 消息标识计数器, 原子操作需要 64 位对齐, 放在首位
+	opCounter int64
+	// 消息标识随机前缀
+	idPrefix string
 	// 并发锁
 	mux sync.RWMutex
+	// 发送锁: 保证同一时刻只有一个协程写连接, 记录顺序与发送顺序一致, 不阻塞读取和状态查询
+	writeMux sync.Mutex
+	// websocket 地址
+	url string
+	// 建立连接
+	dialer Dialer
 	// websocket 连接
-	conn *websocket.Conn
+	conn Transport
+	// 是否已登录, 重连后需要重新登录
+	loggedIn bool
+	// 是否已关闭, 关闭后不再重连
+	closed bool
+	// 客户端生命周期, Shutdown 时取消, 用于中断限频排队等待
+	ctx context.Context
+	// 取消生命周期
+	cancel context.CancelFunc
 	// 频道订阅参数
 	channels []Arg
 	// 信息处理字典
 	handlers map[string]MessageHandler
+	// 频道注册表, 用于解析推送信息
+	registry *Registry
+	// 连接状态处理列表
+	stateHandlers []StateHandler
+	// 错误处理列表
+	errorHandlers []ErrorHandler
+	// 事件等待列表
+	eventWaiters map[int]chan *EventMessage
+	// 事件等待编号
+	nextWaiter int
+	// 等待响应的订单操作
+	pendingOps map[string]*PendingOp
+	// 最近一次收到数据的时间
+	lastRecv time.Time
+	// 已发送 ping 的时间, 收到 pong 后清零
+	pingSentAt time.Time
+	// 账户持仓模式, 用于校验订单持仓方向
+	posMode PosMode
+	// 订单操作限频器
+	limiter *RateLimiter
+	// 产品信息缓存, 用于下单精度校验, 为空时不校验
+	instruments *Instruments
+	// 交易所时钟, 用于登录签名和延迟统计
+	clock *Clock
+	// 原始数据记录器, 为空时不记录
+	recorder *recorder.Recorder
+	// 交易环境
+	env Environment
+	// 账户密钥, 公共频道可为空
+	creds Credentials
 }
 
 // 账户信息
@@ -69,51 +136,174 @@ type SubscribeRequest struct {
 	Args []Arg `json:"args"`
 }
 
-// Websocket 信息内容
-type MessageProfile struct {
-	// 参数
-	Arg struct {
-		// 频道名
-		Channel string `json:"channel"`
-		// 产品 ID
-		InstID string `json:"instId"`
-	} `json:"arg"`
+// 客户端选项
+type ClientOption func(c *OkxClient)
+
+// 指定建立连接的方式, 默认使用 websocket
+func WithDialer(dialer Dialer) ClientOption {
+	// 返回选项
+	return func(c *OkxClient) {
+		// 建立连接
+		c.dialer = dialer
+	}
+}
+
+// 指定订单操作限频器, 默认排队等待
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	// 返回选项
+	return func(c *OkxClient) {
+		// 限频器
+		c.limiter = limiter
+	}
+}
+
+// 指定交易所时钟, 多个客户端可共享同一时钟
+func WithClock(clock *Clock) ClientOption {
+	// 返回选项
+	return func(c *OkxClient) {
+		// 交易所时钟
+		c.clock = clock
+	}
+}
+
+// 指定账户密钥, 私有频道登录使用
+func WithCredentials(creds Credentials) ClientOption {
+	// 返回选项
+	return func(c *OkxClient) {
+		// 账户密钥
+		c.creds = creds
+	}
+}
+
+// 指定交易环境, 模拟盘连接附带 x-simulated-trading 请求头
+func WithEnvironment(env Environment) ClientOption {
+	// 返回选项
+	return func(c *OkxClient) {
+		// 交易环境
+		c.env = env
+	}
+}
+
+// 记录全部收发的原始数据, 客户端关闭时一并关闭记录器
+func WithRecorder(r *recorder.Recorder) ClientOption {
+	// 返回选项
+	return func(c *OkxClient) {
+		// 原始数据记录器
+		c.recorder = r
+	}
+}
+
+// 交易环境
+func (c *OkxClient) Environment() Environment {
+	// 返回交易环境
+	return c.env
+}
+
+// 交易所时钟, 用于延迟统计
+func (c *OkxClient) Clock() *Clock {
+	// 返回时钟
+	return c.clock
 }
 
 // 创建新的客户端
-func NewOkxClient(url string) (*OkxClient, error) {
-	// 发起 websocket 连接
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+func NewOkxClient(url string, opts ...ClientOption) (*OkxClient, error) {
+	// 客户端初始化
+	c := &OkxClient{
+		// 消息标识随机前缀
+		idPrefix: GetRandString(8),
+		// 最近一次收到数据的时间
+		lastRecv: time.Now(),
+		// 账户持仓模式, 默认买卖模式, 登录后按账户配置设置
+		posMode: PosModeNet,
+		// websocket 地址
+		url: url,
+		// 交易环境, 默认实盘
+		env: LiveEnvironment(),
+		// 订单操作限频器
+		limiter: NewRateLimiter(LimitQueue),
+		// 交易所时钟
+		clock: NewClock(),
+		// 信息处理
+		handlers: make(map[string]MessageHandler),
+		// 频道注册表
+		registry: DefaultRegistry(),
+		// 事件等待
+		eventWaiters: make(map[int]chan *EventMessage),
+		// 等待响应的订单操作
+		pendingOps: make(map[string]*PendingOp),
+	}
+	// 客户端生命周期
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	// 逐个选项
+	for _, opt := range opts {
+		// 设置选项
+		opt(c)
+	}
+	// 未指定建立方式, 按交易环境附带请求头
+	if c.dialer == nil {
+		// 建立连接
+		c.dialer = DialWebsocketWithHeader(c.env.Header())
+	}
+	// 发起连接
+	conn, err := c.dialer(url)
 	// 报错
 	if err != nil {
 		// 错误提示
-		log.Fatalf("[错误提示] OKX 客户端登录失败: %v", err)
+		log.Printf("[错误提示] OKX 客户端连接失败: %v", err)
 		// 返回错误
-		return nil, err
+		return nil, &Error{Kind: KindConnection, Op: "dial", Err: err}
 	}
+	// 连接通道
+	c.conn = conn
 	// 成功提示
-	log.Printf("[成功提示] OKX 客户端登录成功")
+	log.Printf("[成功提示] OKX 客户端连接成功")
 	// 成功返回客户端
-	return &OkxClient{
-		// 连接通道
-		conn: conn,
-		// 信息处理
-		handlers: make(map[string]MessageHandler),
-	}, nil
+	return c, nil
 }
 
 // 登陆
 func (c *OkxClient) Login() error {
-	// 时间戳
-	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	// 登录请求
+	request, err := c.loginRequest()
+	// 错误提示
+	if err != nil {
+		// 返回
+		return err
+	}
+	// 发送登录请求
+	err = c.send(request)
+	// 错误提示
+	if err != nil {
+		// 错误提示
+		log.Printf("[错误提示] OKX 私有频道登陆失败: %v", err)
+		// 返回
+		return err
+	}
+	// 记录登录状态, 重连后自动重新登录
+	c.setLoggedIn(true)
+	// 成功提示
+	log.Printf("[成功提示] OKX 私有频道登录请求已发送, 交易环境: %v", c.env.Name)
+	// 返回
+	return nil
+}
+
+// 生成登录请求
+func (c *OkxClient) loginRequest() (*LoginRequest, error) {
+	// 时间戳: 使用校正后的交易所时间, 本地时钟偏移不影响登录
+	timestamp := strconv.FormatInt(c.clock.Now().Unix(), 10)
 	// request 路径
 	message := PreHashString(timestamp, "GET", "/users/self/verify", "")
+	// 未设置密钥
+	if !c.creds.Valid() {
+		// 返回
+		return nil, &Error{Kind: KindAuth, Op: "login", Err: ErrNoCredentials}
+	}
 	// HMAC SHA256
-	sign, err := HmacSha256Base64Signer(message, config.SecretKey)
+	sign, err := c.creds.Sign(message)
 	// 错误提示
 	if err != nil {
 		// 返回
-		return err
+		return nil, &Error{Kind: KindAuth, Op: "login", Err: err}
 	}
 	// 登录参数设置
 	request := &LoginRequest{
@@ -123,9 +313,9 @@ func (c *OkxClient) Login() error {
 		Args: []LoginArg{
 			{
 				// API Key
-				APIKey: config.ApiKey,
+				APIKey: c.creds.ApiKey,
 				// API Passphrase
-				Passphrase: config.PassPhrase,
+				Passphrase: c.creds.PassPhrase,
 				// 时间戳
 				Timestamp: timestamp,
 				// 签名字符串
@@ -133,25 +323,29 @@ func (c *OkxClient) Login() error {
 			},
 		},
 	}
-	// 发送登录请求
-	err = c.send(request)
-	// 错误提示
-	if err != nil {
-		// 错误提示
-		log.Fatalf("[错误提示] OKX 私有频道登陆失败: %v", err)
-		// 返回
-		return err
-	}
-	// 成功提示
-	log.Printf("[成功提示] OKX 私有频道登录成功")
 	// 返回
-	return nil
+	return request, nil
 }
 
 // 订阅频道参数初始化
 func (c *OkxClient) Subscribe(channel, instType, uly, instID string, handler MessageHandler) {
+	// 添加订阅频道
+	c.addChannel(Arg{
+		// 频道名
+		Channel: channel,
+		// 产品类型
+		InstType: instType,
+		// 标的指数
+		Uly: uly,
+		// 产品 ID
+		InstID: instID,
+	}, handler)
+}
+
+// 运行中立即订阅频道, 可与读取循环并发调用, 重连后自动重新订阅
+func (c *OkxClient) SubscribeNow(channel, instType, uly, instID string, handler MessageHandler) error {
 	// 订阅频道参数
-	c.channels = append(c.channels, Arg{
+	arg := Arg{
 		// 频道名
 		Channel: channel,
 		// 产品类型
@@ -160,129 +354,559 @@ func (c *OkxClient) Subscribe(channel, instType, uly, instID string, handler Mes
 		Uly: uly,
 		// 产品 ID
 		InstID: instID,
+	}
+	// 添加订阅频道, 已订阅时只更新信息处理
+	if !c.addChannel(arg, handler) {
+		// 返回
+		return nil
+	}
+	// 发送订阅请求, 失败时频道仍保留在重连订阅列表中
+	return c.send(&SubscribeRequest{
+		// 操作
+		Op: "subscribe",
+		// 订阅频道列表
+		Args: []Arg{arg},
 	})
+}
+
+// 运行中取消订阅频道, 可与读取循环并发调用
+func (c *OkxClient) Unsubscribe(channel, instType, uly, instID string) error {
+	// 订阅频道参数
+	arg := Arg{
+		// 频道名
+		Channel: channel,
+		// 产品类型
+		InstType: instType,
+		// 标的指数
+		Uly: uly,
+		// 产品 ID
+		InstID: instID,
+	}
+	// 上锁
+	c.mux.Lock()
+	// 保留的频道列表
+	channels := make([]Arg, 0, len(c.channels))
+	// 是否找到频道
+	found := false
+	// 是否仍有同名频道使用信息处理器
+	shared := false
+	// 逐个频道
+	for _, val := range c.channels {
+		// 找到频道
+		if val == arg {
+			// 标记
+			found = true
+			// 跳过
+			continue
+		}
+		// 同一信息处理器
+		if val.Channel == channel && val.InstID == instID {
+			// 标记
+			shared = true
+		}
+		// 保留频道
+		channels = append(channels, val)
+	}
+	// 更新频道列表
+	c.channels = channels
+	// 无其他频道使用时删除信息处理器
+	if found && !shared {
+		// 删除信息处理器
+		delete(c.handlers, c.channelKey(channel, instID))
+	}
+	// 解锁
+	c.mux.Unlock()
+	// 未订阅该频道
+	if !found {
+		// 返回
+		return nil
+	}
+	// 发送取消订阅请求
+	return c.send(&SubscribeRequest{
+		// 操作
+		Op: "unsubscribe",
+		// 取消订阅频道列表
+		Args: []Arg{arg},
+	})
+}
+
+// 重新订阅已订阅的频道, 信息处理器保留, 用于获取全量推送重建本地数据
+func (c *OkxClient) Resubscribe(channel, instID string) error {
+	// 上锁
+	c.mux.RLock()
+	// 匹配的频道
+	var args []Arg
+	// 逐个频道
+	for _, val := range c.channels {
+		// 频道名和产品 ID 相同
+		if val.Channel == channel && val.InstID == instID {
+			// 添加频道
+			args = append(args, val)
+		}
+	}
+	// 解锁
+	c.mux.RUnlock()
+	// 未订阅该频道
+	if len(args) == 0 {
+		// 返回
+		return nil
+	}
+	// 普通提示
+	log.Printf("[普通提示] 重新订阅频道: %v", c.channelKey(channel, instID))
+	// 发送取消订阅请求
+	if err := c.send(&SubscribeRequest{Op: "unsubscribe", Args: args}); err != nil {
+		// 返回错误
+		return err
+	}
+	// 发送订阅请求
+	return c.send(&SubscribeRequest{Op: "subscribe", Args: args})
+}
+
+// 添加订阅频道和信息处理器, 返回频道是否为新增
+func (c *OkxClient) addChannel(arg Arg, handler MessageHandler) bool {
+	// 上锁
+	c.mux.Lock()
+	// 函数结束前解锁
+	defer c.mux.Unlock()
 	// 信息处理字典赋值
-	c.handlers[c.channelKey(channel, instID)] = handler
+	c.handlers[c.channelKey(arg.Channel, arg.InstID)] = handler
+	// 逐个频道
+	for _, val := range c.channels {
+		// 已订阅
+		if val == arg {
+			// 返回
+			return false
+		}
+	}
+	// 订阅频道参数
+	c.channels = append(c.channels, arg)
+	// 返回
+	return true
 }
 
 // 读取 Websocket 数据并处理
-func (c *OkxClient) ReadWebsocket() {
+func (c *OkxClient) ReadWebsocket() error {
 	// 读取 websocket 数据
-	_, data, err := c.conn.ReadMessage()
+	data, err := c.currentConn().ReadMessage()
 
 	// 读取信息失败
 	if err != nil {
 		// 错误提示
 		log.Printf("[错误提示] Websocket 数据读取失败: %v", err)
+		// 返回错误, 由读取循环负责重连
+		return &Error{Kind: KindConnection, Op: "read", Err: err}
 	}
+	// 记录收到的数据
+	c.record(recorder.DirIn, data)
 
-	// 初始化解析数据
-	var profile MessageProfile
-	// 解析数据
-	if err := json.Unmarshal(data, &profile); err != nil {
-		// 错误提示
-		log.Printf("[错误提示] Websocket 数据解析失败: %v", err)
-	}
-
-	// 初始化数据处理接口
-	var message PushMessage
-	// 数据分类处理
-	switch profile.Arg.Channel {
-	// 交易数据
-	case "trades":
-		// 交易数据初始化
-		var tm TradeMessage
-		// 数据解析
-		json.Unmarshal(data, &tm)
-		// 数据内容
-		message = &tm
-	// 盘口数据
-	case "books5":
-		// 盘口数据初始化
-		var bm Book5Message
-		// 数据解析
-		json.Unmarshal(data, &bm)
-		// 数据内容
-		message = &bm
-	// 账户数据
-	case "account":
-		// 账户数据初始化
-		var am AccountMessage
-		// 数据解析
-		json.Unmarshal(data, &am)
-		// 数据内容
-		message = &am
-	// 持仓数据
-	case "positions":
-		// 持仓数据初始化
-		var pm PositionsMessage
-		// 数据解析
-		json.Unmarshal(data, &pm)
-		// 数据内容
-		message = &pm
-	// 订单数据
-	case "orders":
-		// 订单数据初始化
-		var om OrdersMessage
-		// 数据解析
-		json.Unmarshal(data, &om)
-		// 数据内容
-		message = &om
-	// 其他情况
-	default:
+	// 记录收到数据, 收到 pong 直接返回
+	if c.markReceived(data) {
+		// 返回
+		return nil
+	}
+
+	// 处理数据
+	return c.handleFrame(data)
+}
+
+// 解析单条 Websocket 数据并分发给信息处理器
+func (c *OkxClient) handleFrame(data []byte) error {
+	// 初始化信息帧
+	var f frame
+	// 解析数据, 每帧只解析一次
+	if err := json.Unmarshal(data, &f); err != nil {
+		// 返回错误
+		return decodeError(data, err)
+	}
+	// 事件推送: 登录, 订阅, 错误
+	if f.Event != "" {
+		// 事件数据
+		em, err := f.event()
+		// 解析失败
+		if err != nil {
+			// 返回错误
+			return decodeError(data, err)
+		}
+		// 分发事件
+		c.dispatchEvent(em)
+		// 返回
+		return nil
+	}
+	// 订单操作响应: 下单, 撤单
+	if f.Op != "" && f.Id != "" {
+		// 响应数据
+		resp, err := f.opResponse()
+		// 解析失败
+		if err != nil {
+			// 返回错误
+			return decodeError(data, err)
+		}
+		// 分发响应
+		c.dispatchOpResponse(resp)
+		// 返回
+		return nil
+	}
+	// 频道名
+	channel, err := f.channel()
+	// 解析失败
+	if err != nil {
+		// 返回错误
+		return decodeError(data, err)
+	}
+	// 查找频道注册项
+	entry, ok := c.registry.lookup(channel)
+	// 未注册的频道
+	if !ok {
 		// 普通提示
 		// log.Printf("[普通提示] Websocket 请求响应: %v", string(data))
 		// 返回
-		return
+		return nil
+	}
+	// 创建推送信息
+	message := entry.factory()
+	// 支持按帧头解析
+	if decoder, ok := message.(FrameDecoder); ok {
+		// 解析频道参数和推送数据
+		err = decoder.DecodeFrame(f.Arg, f.Action, f.Data)
+	} else {
+		// 按整帧解析
+		err = json.Unmarshal(data, message)
+	}
+	// 数据解析失败
+	if err != nil {
+		// 返回错误
+		return decodeError(data, err)
 	}
 
 	// 提取频道名称和产品 ID
 	channel, instID := message.ChannelAndInstID()
 	// 频道名称和产品 ID 字符串格式化
 	channelKey := c.channelKey(channel, instID)
+	// 上锁
+	c.mux.RLock()
 	// 选取指定信息处理器
 	handler := c.handlers[channelKey]
+	// 解锁
+	c.mux.RUnlock()
+	// 订阅时未指定信息处理器, 使用频道默认处理器
+	if handler == nil {
+		// 频道默认处理器
+		handler = entry.handler
+	}
 	// 未找到信息处理器
 	if handler == nil {
 		// 普通提示
 		log.Printf("[普通提示] 未知信息处理器: %v", channelKey)
 		// 返回
-		return
+		return nil
 	}
 
 	// 处理信息
 	handler(message)
+	// 返回
+	return nil
 }
 
-// 循环读取 Websocket 数据
+// 推送数据解析错误
+func decodeError(data []byte, err error) error {
+	// 返回错误
+	return &Error{Kind: KindDecode, Op: "decode", Err: fmt.Errorf("%v, 数据: %s", err, data)}
+}
+
+// 循环读取 Websocket 数据, 连接断开时自动重连
 func (c *OkxClient) ReadWebsocketLoop() {
+	// 心跳保活
+	go c.keepAlive()
 	// 循环
 	for {
-		c.ReadWebsocket()
+		// 读取数据
+		err := c.ReadWebsocket()
+		// 读取成功
+		if err == nil {
+			// 继续读取
+			continue
+		}
+		// 客户端已关闭
+		if c.isClosed() {
+			// 退出循环
+			return
+		}
+		// 通知错误
+		c.reportError(err)
+		// 解析失败只丢弃该条数据, 连接仍可用
+		if IsKind(err, KindDecode) {
+			// 继续读取
+			continue
+		}
+		// 断线重连
+		c.reconnect()
+	}
+}
+
+// 断线重连: 指数退避重新拨号, 恢复登录和订阅
+func (c *OkxClient) reconnect() {
+	// 等待中的订单操作不会再收到响应
+	c.failPending(ErrConnectionLost)
+	// 通知连接断开
+	c.notifyState(StateDisconnected)
+	// 重连等待时间
+	delay := config.ReconnectMinDelay * time.Millisecond
+	// 循环重连
+	for attempt := 1; ; attempt++ {
+		// 客户端已关闭
+		if c.isClosed() {
+			// 返回
+			return
+		}
+		// 等待
+		time.Sleep(delay)
+		// 重新拨号
+		err := c.redial()
+		// 重连成功
+		if err == nil {
+			// 成功提示
+			log.Printf("[成功提示] OKX 客户端第 %v 次重连成功", attempt)
+			// 通知重连成功
+			c.notifyState(StateReconnected)
+			// 返回
+			return
+		}
+		// 错误提示
+		log.Printf("[错误提示] OKX 客户端第 %v 次重连失败: %v", attempt, err)
+		// 通知错误
+		c.reportError(err)
+		// 鉴权错误重试无效, 关闭客户端, 停止重连
+		if IsKind(err, KindAuth) {
+			// 错误提示
+			log.Printf("[错误提示] OKX 客户端重连鉴权失败, 停止重连")
+			// 关闭客户端
+			c.Shutdown()
+			// 返回
+			return
+		}
+		// 等待时间翻倍
+		delay *= 2
+		// 不超过最大等待时间
+		if delay > config.ReconnectMaxDelay*time.Millisecond {
+			// 最大等待时间
+			delay = config.ReconnectMaxDelay * time.Millisecond
+		}
+	}
+}
+
+// 重新拨号, 替换连接, 并重放登录和订阅
+func (c *OkxClient) redial() error {
+	// 发起连接
+	conn, err := c.dialer(c.url)
+	// 报错
+	if err != nil {
+		// 返回错误
+		return &Error{Kind: KindConnection, Op: "dial", Err: err}
+	}
+
+	// 上锁
+	c.mux.Lock()
+	// 关闭旧连接
+	if c.conn != nil {
+		// 关闭
+		c.conn.Close()
+	}
+	// 替换连接
+	c.conn = conn
+	// 重置心跳计时
+	c.lastRecv = time.Now()
+	// 清除未响应的 ping
+	c.pingSentAt = time.Time{}
+	// 是否需要重新登录
+	loggedIn := c.loggedIn
+	// 是否需要重新订阅
+	hasChannels := len(c.channels) > 0
+	// 解锁
+	c.mux.Unlock()
+
+	// 私有频道重新登录
+	if loggedIn {
+		// 登录请求
+		request, err := c.loginRequest()
+		// 错误提示
+		if err != nil {
+			// 返回错误
+			return err
+		}
+		// 发送登录请求
+		if err := c.send(request); err != nil {
+			// 返回错误
+			return err
+		}
+		// 等待登录结果, 登录成功前订阅私有频道会被拒绝
+		if err := c.awaitLogin(conn); err != nil {
+			// 返回错误
+			return err
+		}
+	}
+
+	// 重新订阅频道
+	if hasChannels {
+		// 返回订阅结果
+		return c.subscribeAll()
+	}
+	// 返回
+	return nil
+}
+
+// 同步读取数据直到登录事件返回, 期间收到的推送数据照常分发
+func (c *OkxClient) awaitLogin(conn Transport) error {
+	// 超时关闭连接, 读取将返回错误
+	timer := time.AfterFunc(config.ReconnectLoginTimeout*time.Second, func() {
+		// 关闭连接
+		conn.Close()
+	})
+	// 函数结束前取消超时
+	defer timer.Stop()
+	// 循环读取
+	for {
+		// 读取 websocket 数据
+		data, err := conn.ReadMessage()
+		// 读取失败
+		if err != nil {
+			// 返回错误
+			return &Error{Kind: KindConnection, Op: "read", Err: err}
+		}
+		// 记录收到的数据
+		c.record(recorder.DirIn, data)
+		// 记录收到数据, 收到 pong 继续读取
+		if c.markReceived(data) {
+			// 跳过
+			continue
+		}
+		// 事件数据初始化
+		var em EventMessage
+		// 解析数据, 无法解析时不能确认登录结果
+		if err := json.Unmarshal(data, &em); err != nil {
+			// 返回错误
+			return decodeError(data, err)
+		}
+		// 登录失败
+		if em.Event == "error" || (em.Event == "login" && em.IsError()) {
+			// 返回错误
+			return newEventError("login", &em)
+		}
+		// 登录成功
+		if em.Event == "login" {
+			// 返回
+			return nil
+		}
+		// 其他数据照常处理
+		if err := c.handleFrame(data); err != nil {
+			// 通知错误
+			c.reportError(err)
+		}
+	}
+}
+
+// 注册连接状态处理, 策略可在断线时暂停, 重连后重建状态
+func (c *OkxClient) OnStateChange(handler StateHandler) {
+	// 上锁
+	c.mux.Lock()
+	// 函数结束前解锁
+	defer c.mux.Unlock()
+	// 添加处理
+	c.stateHandlers = append(c.stateHandlers, handler)
+}
+
+// 通知连接状态变化
+func (c *OkxClient) notifyState(s ConnState) {
+	// 上锁
+	c.mux.RLock()
+	// 复制处理列表
+	handlers := append([]StateHandler(nil), c.stateHandlers...)
+	// 解锁
+	c.mux.RUnlock()
+	// 逐个通知
+	for _, handler := range handlers {
+		// 处理
+		handler(s)
+	}
+}
+
+// 注册错误处理, 连接, 鉴权, 订单被拒绝, 限频, 解析错误都会通知
+func (c *OkxClient) OnError(handler ErrorHandler) {
+	// 上锁
+	c.mux.Lock()
+	// 函数结束前解锁
+	defer c.mux.Unlock()
+	// 添加处理
+	c.errorHandlers = append(c.errorHandlers, handler)
+}
+
+// 通知错误处理
+func (c *OkxClient) reportError(err error) {
+	// 上锁
+	c.mux.RLock()
+	// 复制处理列表
+	handlers := append([]ErrorHandler(nil), c.errorHandlers...)
+	// 解锁
+	c.mux.RUnlock()
+	// 逐个通知
+	for _, handler := range handlers {
+		// 处理
+		handler(err)
 	}
 }
 
+// 获取当前连接
+func (c *OkxClient) currentConn() Transport {
+	// 上锁
+	c.mux.RLock()
+	// 函数结束前解锁
+	defer c.mux.RUnlock()
+	// 返回连接
+	return c.conn
+}
+
+// 客户端是否已关闭
+func (c *OkxClient) isClosed() bool {
+	// 上锁
+	c.mux.RLock()
+	// 函数结束前解锁
+	defer c.mux.RUnlock()
+	// 返回状态
+	return c.closed
+}
+
 // 发起 Websocket 连接, 解析数据, 并执行数据存储
 func (c *OkxClient) Run() error {
 	// 订阅 channel
-	if err := c.send(&SubscribeRequest{
-		// 操作
-		Op: "subscribe",
-		// 订阅频道列表
-		Args: c.channels,
-	}); err != nil {
+	if err := c.subscribeAll(); err != nil {
 		// 错误提示
-		log.Fatalf("[错误提示] Websocket 订阅失败: %v", err)
+		log.Printf("[错误提示] Websocket 订阅失败: %v", err)
 		// 返回错误
 		return err
 	}
 	// 成功提示
-	log.Printf("[成功提示] Websocket 订阅成功")
+	log.Printf("[成功提示] Websocket 订阅请求已发送")
 
 	// 返回
 	return nil
 }
 
+// 发送全部频道订阅请求
+func (c *OkxClient) subscribeAll() error {
+	// 上锁
+	c.mux.RLock()
+	// 复制订阅频道列表
+	channels := append([]Arg(nil), c.channels...)
+	// 解锁
+	c.mux.RUnlock()
+	// 发送订阅请求
+	return c.send(&SubscribeRequest{
+		// 操作
+		Op: "subscribe",
+		// 订阅频道列表
+		Args: channels,
+	})
+}
+
 // 发送请求
 func (c *OkxClient) send(message interface{}) error {
 	// 显示
@@ -291,18 +915,44 @@ func (c *OkxClient) send(message interface{}) error {
 	data, err := json.Marshal(message)
 	// 解析错误
 	if err != nil {
-		// 错误提示
-		log.Fatalf("[错误提示] 频道参数转为 json 格式失败: %v", err)
 		// 返回错误
-		return err
+		return &Error{Kind: KindDecode, Op: "encode", Err: err}
 	}
+	// 发送数据
+	return c.sendRaw(data)
+}
 
-	// 上锁
-	c.mux.Lock()
+// 发送原始文本数据
+func (c *OkxClient) sendRaw(data []byte) error {
+	// 当前连接
+	conn := c.currentConn()
+	// 发送上锁
+	c.writeMux.Lock()
 	// 函数结束前解锁
-	defer c.mux.Unlock()
+	defer c.writeMux.Unlock()
+	// 记录发出的数据
+	c.record(recorder.DirOut, data)
 	// 发送请求
-	return c.conn.WriteMessage(websocket.TextMessage, data)
+	if err := conn.WriteMessage(data); err != nil {
+		// 返回错误
+		return &Error{Kind: KindConnection, Op: "write", Err: err}
+	}
+	// 返回
+	return nil
+}
+
+// 记录原始数据, 记录失败不影响收发
+func (c *OkxClient) record(dir string, data []byte) {
+	// 未开启记录
+	if c.recorder == nil {
+		// 返回
+		return
+	}
+	// 记录数据
+	if err := c.recorder.Record(dir, data); err != nil {
+		// 错误提示
+		log.Printf("[错误提示] 原始数据记录失败: %v", err)
+	}
 }
 
 // 频道 Key 值格式化
@@ -311,10 +961,30 @@ func (c *OkxClient) channelKey(channel, instID string) string {
 	return fmt.Sprintf("channel:%v, instID:%v", channel, instID)
 }
 
-// 关闭客户端
+// 关闭客户端, 重复调用无效
 func (c *OkxClient) Shutdown() {
+	// 上锁
+	c.mux.Lock()
+	// 已关闭
+	if c.closed {
+		// 解锁
+		c.mux.Unlock()
+		// 返回
+		return
+	}
+	// 标记关闭, 读取循环不再重连
+	c.closed = true
+	// 中断限频排队等待
+	c.cancel()
 	// 关闭连接
 	c.conn.Close()
+	// 解锁
+	c.mux.Unlock()
+	// 写完记录文件
+	if c.recorder != nil {
+		// 关闭记录器
+		c.recorder.Close()
+	}
 	// 成功提示
 	log.Printf("[成功提示] OKX 客户端连接关闭")
 }
@@ -0,0 +1,645 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wiger123/okex_v5_golang/config"
+	. "github.com/wiger123/okex_v5_golang/database"
+	. "github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 测试用密钥
+var testCreds = Credentials{ApiKey: "test-key", SecretKey: "test-secret", PassPhrase: "test-pass"}
+
+// 创建使用内存连接的客户端
+func newMemClient(t *testing.T, opts ...ClientOption) (*OkxClient, *MemoryTransport) {
+	// 内存连接
+	mt := NewMemoryTransport()
+	// 创建客户端
+	c, err := NewOkxClient("mem://test", append([]ClientOption{WithDialer(mt.Dialer())}, opts...)...)
+	// 创建失败
+	if err != nil {
+		// 终止测试
+		t.Fatalf("NewOkxClient: %v", err)
+	}
+	// 测试结束关闭连接
+	t.Cleanup(func() { mt.Close() })
+	// 返回
+	return c, mt
+}
+
+// 等待客户端发出一条请求
+func nextSent(t *testing.T, mt *MemoryTransport) []byte {
+	// 事件选择
+	select {
+	// 收到请求
+	case data := <-mt.Outbound():
+		// 返回
+		return data
+	// 超时
+	case <-time.After(time.Second):
+		// 终止测试
+		t.Fatal("没有发出请求")
+	}
+	// 不会执行
+	return nil
+}
+
+func TestReadWebsocketRoutesToChannelHandler(t *testing.T) {
+	c, mt := newMemClient(t)
+	// 收到的推送
+	got := make(chan *TradeMessage, 1)
+	// 订阅交易频道
+	c.Subscribe("trades", "", "", "BTC-USDT", func(m PushMessage) { got <- m.(*TradeMessage) })
+	// 其他产品的推送不应分发给该处理器
+	c.Subscribe("trades", "", "", "ETH-USDT", func(m PushMessage) { t.Errorf("ETH-USDT 处理器收到推送: %+v", m) })
+
+	mt.PushString(`{"arg":{"channel":"trades","instId":"BTC-USDT"},"data":[{"instId":"BTC-USDT","tradeId":"1","px":"100.5","sz":"2","side":"buy","ts":"1"}]}`)
+	if err := c.ReadWebsocket(); err != nil {
+		t.Fatalf("ReadWebsocket: %v", err)
+	}
+	select {
+	case m := <-got:
+		if len(m.Data) != 1 || m.Data[0].Px.String() != "100.5" || m.Data[0].Sz.String() != "2" {
+			t.Fatalf("推送数据错误: %+v", m.Data)
+		}
+	default:
+		t.Fatal("处理器未收到推送")
+	}
+}
+
+func TestReadWebsocketIgnoresUnknownChannelAndPong(t *testing.T) {
+	c, mt := newMemClient(t)
+	// 未注册的频道和 pong 均不报错
+	for _, frame := range []string{`{"arg":{"channel":"no-such-channel"},"data":[]}`, "pong"} {
+		mt.PushString(frame)
+		if err := c.ReadWebsocket(); err != nil {
+			t.Fatalf("%v: %v", frame, err)
+		}
+	}
+}
+
+func TestReadWebsocketDecodeError(t *testing.T) {
+	c, mt := newMemClient(t)
+	// 无法解析的数据
+	mt.PushString(`{"arg":`)
+	err := c.ReadWebsocket()
+	if KindOf(err) != KindDecode {
+		t.Fatalf("期望 KindDecode, 实际: %v", err)
+	}
+}
+
+func TestReadWebsocketConnectionError(t *testing.T) {
+	c, mt := newMemClient(t)
+	// 连接关闭后读取失败
+	mt.Close()
+	err := c.ReadWebsocket()
+	if KindOf(err) != KindConnection {
+		t.Fatalf("期望 KindConnection, 实际: %v", err)
+	}
+}
+
+func TestLoginSignsRequest(t *testing.T) {
+	c, mt := newMemClient(t, WithCredentials(testCreds))
+	if err := c.Login(); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	// 解析登录请求
+	var req LoginRequest
+	if err := json.Unmarshal(nextSent(t, mt), &req); err != nil {
+		t.Fatalf("登录请求解析失败: %v", err)
+	}
+	if req.Op != "login" || len(req.Args) != 1 {
+		t.Fatalf("登录请求错误: %+v", req)
+	}
+	arg := req.Args[0]
+	if arg.APIKey != testCreds.ApiKey || arg.Passphrase != testCreds.PassPhrase {
+		t.Fatalf("登录参数错误: %+v", arg)
+	}
+	// 按请求中的时间戳重新计算签名
+	want, err := HmacSha256Base64Signer(arg.Timestamp+"GET/users/self/verify", testCreds.SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg.Sign != want {
+		t.Fatalf("签名错误: %v, 期望: %v", arg.Sign, want)
+	}
+}
+
+func TestLoginWithoutCredentials(t *testing.T) {
+	c, mt := newMemClient(t)
+	// 未设置密钥时不发送请求
+	if err := c.Login(); KindOf(err) != KindAuth {
+		t.Fatalf("期望 KindAuth, 实际: %v", err)
+	}
+	if len(mt.Sent()) != 0 {
+		t.Fatalf("不应发出请求: %q", mt.Sent())
+	}
+}
+
+// 构建测试订单
+func testOrder(t *testing.T, c *OkxClient, clOrdId string) PostOrder {
+	// 构建订单
+	order, err := c.NewOrder("BTC-USDT", TdModeCash, SideBuy, OrdTypeLimit, DecimalFromInt(1), WithPx(DecimalFromInt(100)), WithClOrdId(clOrdId))
+	// 构建失败
+	if err != nil {
+		// 终止测试
+		t.Fatalf("NewOrder: %v", err)
+	}
+	// 返回
+	return order
+}
+
+// 订单簿中的订单
+func findOrder(dr *DataRepo, clOrdId string) (Orders, bool) {
+	// 逐个订单
+	for _, o := range dr.OpenOrders() {
+		// 找到订单
+		if o.ClOrdId == clOrdId {
+			// 返回
+			return o, true
+		}
+	}
+	// 未找到
+	return Orders{}, false
+}
+
+func TestPostOrdersAcceptedKeepsLocalOrder(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	pending, err := c.PostOrders("batch-orders", []PostOrder{testOrder(t, c, "a1"), testOrder(t, c, "a2")}, dr)
+	if err != nil {
+		t.Fatalf("PostOrders: %v", err)
+	}
+	// 发出的请求
+	var msg PostOrderMessage
+	if err := json.Unmarshal(nextSent(t, mt), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Id != pending.Id || msg.Op != "batch-orders" || len(msg.Args) != 2 {
+		t.Fatalf("请求错误: %+v", msg)
+	}
+	// 等待确认期间为本地订单
+	if o, ok := findOrder(dr, "a1"); !ok || o.State != "local" {
+		t.Fatalf("缺少本地订单: %+v", dr.OpenOrders())
+	}
+
+	// 交易所确认
+	mt.PushString(`{"id":"` + pending.Id + `","op":"batch-orders","code":"0","msg":"","data":[{"clOrdId":"a1","ordId":"11","sCode":"0","sMsg":""},{"clOrdId":"a2","ordId":"12","sCode":"0","sMsg":""}]}`)
+	if err := c.ReadWebsocket(); err != nil {
+		t.Fatalf("ReadWebsocket: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := pending.Wait(ctx); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	// 本地订单记录订单 ID
+	if o, ok := findOrder(dr, "a2"); !ok || o.OrdId != "12" {
+		t.Fatalf("本地订单未记录订单 ID: %+v", dr.OpenOrders())
+	}
+}
+
+func TestPostOrdersRejectedRemovesLocalOrder(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	pending, err := c.PostOrders("batch-orders", []PostOrder{testOrder(t, c, "b1"), testOrder(t, c, "b2")}, dr)
+	if err != nil {
+		t.Fatalf("PostOrders: %v", err)
+	}
+	nextSent(t, mt)
+
+	// 部分成功: b1 被拒绝
+	mt.PushString(`{"id":"` + pending.Id + `","op":"batch-orders","code":"2","msg":"","data":[{"clOrdId":"b1","ordId":"","sCode":"51008","sMsg":"余额不足"},{"clOrdId":"b2","ordId":"22","sCode":"0","sMsg":""}]}`)
+	if err := c.ReadWebsocket(); err != nil {
+		t.Fatalf("ReadWebsocket: %v", err)
+	}
+	<-pending.Done()
+	if _, ok := findOrder(dr, "b1"); ok {
+		t.Fatalf("被拒绝的订单未删除: %+v", dr.OpenOrders())
+	}
+	if _, ok := findOrder(dr, "b2"); !ok {
+		t.Fatalf("成功的订单被删除: %+v", dr.OpenOrders())
+	}
+}
+
+func TestPostOrdersWholeRequestRejected(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	pending, err := c.PostOrders("order", []PostOrder{testOrder(t, c, "c1")}, dr)
+	if err != nil {
+		t.Fatalf("PostOrders: %v", err)
+	}
+	nextSent(t, mt)
+
+	// 整个请求被拒绝
+	mt.PushString(`{"id":"` + pending.Id + `","op":"order","code":"60012","msg":"Invalid request","data":[]}`)
+	if err := c.ReadWebsocket(); err != nil {
+		t.Fatalf("ReadWebsocket: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := pending.Wait(ctx); err == nil {
+		t.Fatal("期望请求错误")
+	}
+	if len(dr.OpenOrders()) != 0 {
+		t.Fatalf("本地订单未删除: %+v", dr.OpenOrders())
+	}
+}
+
+func TestPostOrdersSendFailureRemovesLocalOrder(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	order := testOrder(t, c, "d1")
+	// 连接关闭后发送失败
+	mt.Close()
+	if _, err := c.PostOrders("order", []PostOrder{order}, dr); err == nil {
+		t.Fatal("期望发送失败")
+	}
+	if len(dr.OpenOrders()) != 0 {
+		t.Fatalf("本地订单未删除: %+v", dr.OpenOrders())
+	}
+}
+
+// 写入阻塞的连接
+type blockingTransport struct {
+	*MemoryTransport
+	// 写入开始通知
+	writing chan struct{}
+	// 放行写入
+	release chan struct{}
+}
+
+// 通知写入开始后阻塞, 直到放行
+func (t *blockingTransport) WriteMessage(data []byte) error {
+	t.writing <- struct{}{}
+	<-t.release
+	return t.MemoryTransport.WriteMessage(data)
+}
+
+func TestSendDoesNotHoldClientLock(t *testing.T) {
+	mt := NewMemoryTransport()
+	bt := &blockingTransport{MemoryTransport: mt, writing: make(chan struct{}, 1), release: make(chan struct{})}
+	c, err := NewOkxClient("mem://test", WithDialer(func(url string) (Transport, error) { return bt, nil }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.Close()
+
+	sent := make(chan error, 1)
+	go func() { sent <- c.sendRaw([]byte("ping")) }()
+	<-bt.writing
+
+	// 写入阻塞期间可以读取状态和处理推送
+	done := make(chan struct{})
+	go func() {
+		c.isClosed()
+		c.SetPosMode(PosModeLongShort)
+		c.markReceived([]byte("pong"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("写入期间客户端锁被占用")
+	}
+
+	close(bt.release)
+	if err := <-sent; err != nil {
+		t.Fatalf("sendRaw: %v", err)
+	}
+}
+
+func TestPostOrdersConnectionLostRemovesLocalOrder(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	pending, err := c.PostOrders("order", []PostOrder{testOrder(t, c, "e1")}, dr)
+	if err != nil {
+		t.Fatalf("PostOrders: %v", err)
+	}
+	nextSent(t, mt)
+	// 未收到响应时连接断开
+	c.failPending(ErrConnectionLost)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := pending.Wait(ctx); KindOf(err) != KindConnection {
+		t.Fatalf("期望 KindConnection, 实际: %v", err)
+	}
+	if len(dr.OpenOrders()) != 0 {
+		t.Fatalf("本地订单未删除: %+v", dr.OpenOrders())
+	}
+}
+
+func TestEmptyBatchesNotSent(t *testing.T) {
+	c, mt := newMemClient(t, WithRateLimiter(NewRateLimiter(LimitReject)))
+	dr := NewDataRepo()
+	// 没有订单时不发送请求, 也不占用限频令牌
+	for i := 0; i < config.RateLimitOpRequests+1; i++ {
+		if pending, err := c.PostOrders("batch-orders", nil, dr); pending != nil || err != nil {
+			t.Fatalf("PostOrders 期望 nil, nil, 实际: %v, %v", pending, err)
+		}
+		if pending, err := c.CancelOrders("batch-cancel-orders", nil, dr); pending != nil || err != nil {
+			t.Fatalf("CancelOrders 期望 nil, nil, 实际: %v, %v", pending, err)
+		}
+		if pending, err := c.AmendOrders("batch-amend-orders", nil, dr); pending != nil || err != nil {
+			t.Fatalf("AmendOrders 期望 nil, nil, 实际: %v, %v", pending, err)
+		}
+	}
+	if len(mt.Sent()) != 0 {
+		t.Fatalf("不应发出请求: %q", mt.Sent())
+	}
+}
+
+func TestPostOrdersValidatesHandBuiltOrders(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	// 未经 NewOrder 构建: 买卖模式下指定开平仓方向
+	order := testOrder(t, c, "f1")
+	order.TdMode = string(TdModeCross)
+	order.PosSide = string(PosSideLong)
+	_, err := c.PostOrders("order", []PostOrder{order}, dr)
+	var ie *InvalidOrderError
+	if !errors.As(err, &ie) || ie.ClOrdId != "f1" {
+		t.Fatalf("期望 InvalidOrderError, 实际: %v", err)
+	}
+	if len(mt.Sent()) != 0 || len(dr.OpenOrders()) != 0 {
+		t.Fatalf("不合规的订单不应发送: %q, %+v", mt.Sent(), dr.OpenOrders())
+	}
+}
+
+func TestCancelOrdersSendFailureKeepsLocalOrder(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	if _, err := c.PostOrders("order", []PostOrder{testOrder(t, c, "g1")}, dr); err != nil {
+		t.Fatalf("PostOrders: %v", err)
+	}
+	nextSent(t, mt)
+	// 撤单请求未发出, 下单结果未知, 保留本地订单
+	mt.Close()
+	if _, err := c.CancelOrders("cancel-order", []CancelOrder{c.CancelSingleOrder("BTC-USDT", "", "g1")}, dr); err == nil {
+		t.Fatal("期望发送失败")
+	}
+	if _, ok := findOrder(dr, "g1"); !ok {
+		t.Fatalf("撤单失败后本地订单被删除: %+v", dr.OpenOrders())
+	}
+}
+
+func TestCancelOrdersRemovesLocalOrderAfterSend(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	if _, err := c.PostOrders("order", []PostOrder{testOrder(t, c, "h1")}, dr); err != nil {
+		t.Fatalf("PostOrders: %v", err)
+	}
+	nextSent(t, mt)
+	if _, err := c.CancelOrders("cancel-order", []CancelOrder{c.CancelSingleOrder("BTC-USDT", "", "h1")}, dr); err != nil {
+		t.Fatalf("CancelOrders: %v", err)
+	}
+	nextSent(t, mt)
+	if _, ok := findOrder(dr, "h1"); ok {
+		t.Fatalf("撤单发出后本地订单未删除: %+v", dr.OpenOrders())
+	}
+}
+
+func TestAwaitLoginDecodeError(t *testing.T) {
+	c, _ := newMemClient(t)
+	conn := NewMemoryTransport()
+	defer conn.Close()
+	// 无法解析的登录响应
+	conn.PushString(`{"event":`)
+	if err := c.awaitLogin(conn); KindOf(err) != KindDecode {
+		t.Fatalf("期望 KindDecode, 实际: %v", err)
+	}
+}
+
+func TestReconnectStopsOnAuthError(t *testing.T) {
+	first, second := NewMemoryTransport(), NewMemoryTransport()
+	defer second.Close()
+	dials := 0
+	c, err := NewOkxClient("mem://test", WithCredentials(testCreds), WithDialer(func(url string) (Transport, error) {
+		dials++
+		if dials == 1 {
+			return first, nil
+		}
+		return second, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 已登录的私有频道客户端
+	c.mux.Lock()
+	c.loggedIn = true
+	c.mux.Unlock()
+	// 重连后登录被拒绝
+	second.PushString(`{"event":"error","code":"60009","msg":"Login failed."}`)
+	var errs []error
+	c.OnError(func(err error) { errs = append(errs, err) })
+
+	done := make(chan struct{})
+	go func() {
+		c.reconnect()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("鉴权失败后仍在重连")
+	}
+	if dials != 2 || !c.isClosed() {
+		t.Fatalf("鉴权失败后应关闭客户端: dials %v, closed %v", dials, c.isClosed())
+	}
+	if len(errs) != 1 || KindOf(errs[0]) != KindAuth {
+		t.Fatalf("期望通知一次 KindAuth 错误, 实际: %v", errs)
+	}
+}
+
+// 订单频道推送挂单, 价格 100, 数量 1
+func pushLiveOrders(t *testing.T, dr *DataRepo, clOrdIds ...string) {
+	// 订单数据
+	var data []string
+	// 逐个订单
+	for i, id := range clOrdIds {
+		// 添加订单
+		data = append(data, `{"instId":"BTC-USDT","ordId":"`+strconv.Itoa(100+i)+`","clOrdId":"`+id+`","px":"100","sz":"1","state":"live"}`)
+	}
+	// 推送数据
+	var m OrdersMessage
+	// 解析推送
+	if err := json.Unmarshal([]byte(`{"arg":{"channel":"orders"},"data":[`+strings.Join(data, ",")+`]}`), &m); err != nil {
+		// 终止测试
+		t.Fatal(err)
+	}
+	// 更新数据库
+	dr.HandleMessage(&m)
+}
+
+func TestAmendOrdersMatchesResultsByOrderId(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	pushLiveOrders(t, dr, "m1", "m2")
+	args := []AmendOrder{
+		c.AmendSingleOrder("BTC-USDT", "", "m1", Decimal{}, DecimalFromInt(101)),
+		c.AmendSingleOrder("BTC-USDT", "", "m2", Decimal{}, DecimalFromInt(102)),
+	}
+	pending, err := c.AmendOrders("batch-amend-orders", args, dr)
+	if err != nil {
+		t.Fatalf("AmendOrders: %v", err)
+	}
+	nextSent(t, mt)
+	// 响应顺序与请求相反, 且缺少 m1, 另有一条无对应请求的结果
+	mt.PushString(`{"id":"` + pending.Id + `","op":"batch-amend-orders","code":"0","msg":"","data":[{"clOrdId":"zz","ordId":"999","sCode":"0","sMsg":""},{"clOrdId":"m2","ordId":"101","sCode":"0","sMsg":""}]}`)
+	if err := c.ReadWebsocket(); err != nil {
+		t.Fatalf("ReadWebsocket: %v", err)
+	}
+	<-pending.Done()
+	if o, _ := findOrder(dr, "m1"); o.Px.String() != "100" {
+		t.Fatalf("未确认的订单被修改: %+v", o)
+	}
+	if o, _ := findOrder(dr, "m2"); o.Px.String() != "102" {
+		t.Fatalf("确认的订单未修改: %+v", o)
+	}
+}
+
+// 改单 m1 价格改为 101, 返回请求
+func amendM1(t *testing.T, c *OkxClient, mt *MemoryTransport, dr *DataRepo) (*PendingOp, AmendOrder) {
+	// 改单
+	pending, err := c.AmendOrders("amend-order", []AmendOrder{c.AmendSingleOrder("BTC-USDT", "100", "m1", Decimal{}, DecimalFromInt(101))}, dr)
+	// 改单失败
+	if err != nil {
+		// 终止测试
+		t.Fatalf("AmendOrders: %v", err)
+	}
+	// 发出的请求
+	var msg AmendOrderMessage
+	// 解析请求
+	if err := json.Unmarshal(nextSent(t, mt), &msg); err != nil {
+		// 终止测试
+		t.Fatal(err)
+	}
+	// 请求参数
+	if len(msg.Args) != 1 || msg.Args[0].ReqId == "" || msg.Args[0].NewPx != "101" {
+		// 终止测试
+		t.Fatalf("改单请求错误: %+v", msg)
+	}
+	// 返回
+	return pending, msg.Args[0]
+}
+
+// 推送改单确认
+func ackAmend(t *testing.T, c *OkxClient, mt *MemoryTransport, pending *PendingOp, arg AmendOrder, sCode string) {
+	// 交易所确认
+	mt.PushString(`{"id":"` + pending.Id + `","op":"amend-order","code":"` + sCode + `","msg":"","data":[{"clOrdId":"m1","ordId":"100","reqId":"` + arg.ReqId + `","sCode":"` + sCode + `","sMsg":""}]}`)
+	// 读取确认
+	if err := c.ReadWebsocket(); err != nil {
+		// 终止测试
+		t.Fatalf("ReadWebsocket: %v", err)
+	}
+	// 等待确认处理完成
+	<-pending.Done()
+}
+
+// 订单频道推送改单结果
+func pushAmendResult(t *testing.T, dr *DataRepo, arg AmendOrder, px, amendResult string) {
+	// 推送数据
+	var m OrdersMessage
+	// 解析推送
+	if err := json.Unmarshal([]byte(`{"arg":{"channel":"orders"},"data":[{"instId":"BTC-USDT","ordId":"100","clOrdId":"m1","px":"`+px+`","sz":"1","state":"live","reqId":"`+arg.ReqId+`","amendResult":"`+amendResult+`"}]}`), &m); err != nil {
+		// 终止测试
+		t.Fatal(err)
+	}
+	// 更新数据库
+	dr.HandleMessage(&m)
+}
+
+func TestAmendOrderSuccess(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	pushLiveOrders(t, dr, "m1")
+	pending, arg := amendM1(t, c, mt, dr)
+	ackAmend(t, c, mt, pending, arg, "0")
+	if o, _ := findOrder(dr, "m1"); o.Px.String() != "101" {
+		t.Fatalf("确认后未修改本地订单: %+v", o)
+	}
+	pushAmendResult(t, dr, arg, "101", "0")
+	if o, _ := findOrder(dr, "m1"); o.Px.String() != "101" || o.AmendResult != "0" {
+		t.Fatalf("改单成功推送后订单错误: %+v", o)
+	}
+}
+
+func TestAmendOrderRejected(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	pushLiveOrders(t, dr, "m1")
+	pending, arg := amendM1(t, c, mt, dr)
+	// 交易所拒绝改单
+	ackAmend(t, c, mt, pending, arg, "51503")
+	if o, _ := findOrder(dr, "m1"); o.Px.String() != "100" {
+		t.Fatalf("被拒绝的改单修改了本地订单: %+v", o)
+	}
+}
+
+func TestAmendResultFailedRestoresOrder(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	pushLiveOrders(t, dr, "m1")
+	pending, arg := amendM1(t, c, mt, dr)
+	ackAmend(t, c, mt, pending, arg, "0")
+	// 确认后订单频道推送改单失败, 恢复原价格
+	pushAmendResult(t, dr, arg, "100", "-1")
+	if o, _ := findOrder(dr, "m1"); o.Px.String() != "100" {
+		t.Fatalf("改单失败后未恢复原订单: %+v", o)
+	}
+}
+
+func TestAmendResultFailedBeforeAck(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	pushLiveOrders(t, dr, "m1")
+	pending, arg := amendM1(t, c, mt, dr)
+	// 改单失败推送先于确认到达, 确认不再修改本地订单
+	pushAmendResult(t, dr, arg, "100", "-1")
+	ackAmend(t, c, mt, pending, arg, "0")
+	if o, _ := findOrder(dr, "m1"); o.Px.String() != "100" {
+		t.Fatalf("改单失败后确认修改了本地订单: %+v", o)
+	}
+}
+
+func TestCancelAllOrdersIncludesAcceptedLocalOrders(t *testing.T) {
+	c, mt := newMemClient(t)
+	dr := NewDataRepo()
+	pending, err := c.PostOrders("batch-orders", []PostOrder{testOrder(t, c, "d1"), testOrder(t, c, "d2")}, dr)
+	if err != nil {
+		t.Fatalf("PostOrders: %v", err)
+	}
+	nextSent(t, mt)
+	// 只确认 d1, 订单推送尚未到达, d2 仍未确认
+	mt.PushString(`{"id":"` + pending.Id + `","op":"batch-orders","code":"0","msg":"","data":[{"clOrdId":"d1","ordId":"11","sCode":"0","sMsg":""}]}`)
+	if err := c.ReadWebsocket(); err != nil {
+		t.Fatalf("ReadWebsocket: %v", err)
+	}
+	<-pending.Done()
+	if o, ok := findOrder(dr, "d1"); !ok || o.State != "local" || o.OrdId != "11" {
+		t.Fatalf("d1 应为已确认的本地订单: %+v", dr.OpenOrders())
+	}
+
+	if err := c.CancelAllOrders("BTC-USDT", dr); err != nil {
+		t.Fatalf("CancelAllOrders: %v", err)
+	}
+	var msg CancelOrderMessage
+	if err := json.Unmarshal(nextSent(t, mt), &msg); err != nil {
+		t.Fatal(err)
+	}
+	// 已确认的本地订单被撤销, 未确认的订单没有订单 ID, 不发送
+	if msg.Op != "batch-cancel-orders" || len(msg.Args) != 1 {
+		t.Fatalf("撤单请求错误: %+v", msg)
+	}
+	if a := msg.Args[0]; a.InstId != "BTC-USDT" || a.OrdId != "11" || a.ClOrdId != "d1" {
+		t.Fatalf("撤单参数错误: %+v", a)
+	}
+}
@@ -0,0 +1,268 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 未设置账户密钥, 错误类别为 KindAuth
+var ErrNoCredentials = errors.New("未设置 API 密钥")
+
+// 错误类别, 由调用方决定重试, 撤单或退出
+type ErrorKind int
+
+// 错误类别
+const (
+	// 未分类
+	KindUnknown ErrorKind = iota
+	// 连接错误: 拨号, 读写失败, 连接断开
+	KindConnection
+	// 鉴权错误: 签名或登录失败
+	KindAuth
+	// 请求被拒绝: 订单参数错误, 交易所拒绝订单或订阅
+	KindRejected
+	// 限频: 客户端限频或交易所限频
+	KindRateLimited
+	// 编解码错误: 请求转 json 或推送数据解析失败
+	KindDecode
+)
+
+// 错误类别名称
+func (k ErrorKind) String() string {
+	// 类别判断
+	switch k {
+	// 连接错误
+	case KindConnection:
+		// 返回
+		return "connection"
+	// 鉴权错误
+	case KindAuth:
+		// 返回
+		return "auth"
+	// 请求被拒绝
+	case KindRejected:
+		// 返回
+		return "rejected"
+	// 限频
+	case KindRateLimited:
+		// 返回
+		return "rate limited"
+	// 编解码错误
+	case KindDecode:
+		// 返回
+		return "decode"
+	}
+	// 未分类
+	return "unknown"
+}
+
+// 客户端错误: 带类别的底层错误
+type Error struct {
+	// 错误类别
+	Kind ErrorKind
+	// 出错的操作
+	Op string
+	// 底层错误
+	Err error
+}
+
+// 错误信息
+func (e *Error) Error() string {
+	// 字符串格式化
+	return fmt.Sprintf("OKX %v 失败 (%v): %v", e.Op, e.Kind, e.Err)
+}
+
+// 底层错误
+func (e *Error) Unwrap() error {
+	// 返回
+	return e.Err
+}
+
+// 获取错误类别, 非客户端错误返回 KindUnknown
+func KindOf(err error) ErrorKind {
+	// 客户端错误
+	var ce *Error
+	// 带类别的错误
+	if errors.As(err, &ce) {
+		// 返回类别
+		return ce.Kind
+	}
+	// 事件错误
+	var ee *EventError
+	// 登录或订阅被拒绝
+	if errors.As(err, &ee) {
+		// 返回类别
+		return ee.Kind()
+	}
+	// 订单操作错误
+	var oe *OpError
+	// 交易所拒绝
+	if errors.As(err, &oe) {
+		// 返回类别
+		return oe.Kind()
+	}
+	// 限频错误
+	var re *RateLimitError
+	// 客户端限频
+	if errors.As(err, &re) {
+		// 返回类别
+		return KindRateLimited
+	}
+	// 订单参数错误
+	var ie *InvalidOrderError
+	// 发送前校验失败, 或单次请求超过限频容量
+	if errors.As(err, &ie) || errors.Is(err, ErrExceedsCapacity) {
+		// 返回类别
+		return KindRejected
+	}
+	// 连接断开
+	if errors.Is(err, ErrConnectionLost) || errors.Is(err, ErrTransportClosed) {
+		// 返回类别
+		return KindConnection
+	}
+	// 未分类
+	return KindUnknown
+}
+
+// 判断错误类别
+func IsKind(err error, kind ErrorKind) bool {
+	// 比较类别
+	return err != nil && KindOf(err) == kind
+}
+
+// 交易所事件错误: 登录或订阅被拒绝
+type EventError struct {
+	// 请求操作
+	Op string
+	// 错误码
+	Code string
+	// 错误消息
+	Msg string
+}
+
+// 错误信息
+func (e *EventError) Error() string {
+	// 字符串格式化
+	return fmt.Sprintf("OKX %v 失败 code: %v, msg: %v", e.Op, e.Code, e.Msg)
+}
+
+// 错误类别: 登录失败为鉴权错误, 其他为请求被拒绝
+func (e *EventError) Kind() ErrorKind {
+	// 登录失败
+	if e.Op == "login" {
+		// 返回
+		return KindAuth
+	}
+	// 请求被拒绝
+	return KindRejected
+}
+
+// 由事件推送生成错误
+func newEventError(op string, em *EventMessage) *EventError {
+	// 返回错误
+	return &EventError{
+		// 请求操作
+		Op: op,
+		// 错误码
+		Code: em.Code,
+		// 错误消息
+		Msg: em.Msg,
+	}
+}
+
+// 订单操作错误: 交易所拒绝整个请求或全部订单
+type OpError struct {
+	// 业务操作
+	Op string
+	// 消息的唯一标识
+	Id string
+	// 错误码
+	Code string
+	// 错误消息
+	Msg string
+	// 逐个订单的操作结果
+	Data []OpResult
+}
+
+// 错误信息
+func (e *OpError) Error() string {
+	// 字符串格式化
+	return fmt.Sprintf("OKX %v 失败 id: %v, code: %v, msg: %v", e.Op, e.Id, e.Code, e.Msg)
+}
+
+// 错误类别: 交易所限频为限频错误, 其他为请求被拒绝
+func (e *OpError) Kind() ErrorKind {
+	// 请求限频
+	if e.Code == exchangeRateLimitCode {
+		// 返回
+		return KindRateLimited
+	}
+	// 逐个订单结果
+	for _, result := range e.Data {
+		// 订单限频
+		if result.SCode == exchangeRateLimitCode {
+			// 返回
+			return KindRateLimited
+		}
+	}
+	// 请求被拒绝
+	return KindRejected
+}
+
+// 交易所限频错误码
+const exchangeRateLimitCode = "50011"
+
+// 由订单操作响应生成错误, 全部成功或部分成功时返回 nil
+func newOpError(op string, resp *OpResponse) error {
+	// 全部成功或部分成功, 逐个订单结果由调用方检查
+	if resp.Code == "0" || resp.Code == "2" {
+		// 返回
+		return nil
+	}
+	// 返回错误
+	return &OpError{
+		// 业务操作
+		Op: op,
+		// 消息的唯一标识
+		Id: resp.Id,
+		// 错误码
+		Code: resp.Code,
+		// 错误消息
+		Msg: resp.Msg,
+		// 逐个订单的操作结果
+		Data: resp.Data,
+	}
+}
+
+// 订单参数错误: 发送前校验失败
+type InvalidOrderError struct {
+	// 用户提供的订单 ID
+	ClOrdId string
+	// 错误原因
+	Reason string
+}
+
+// 错误信息
+func (e *InvalidOrderError) Error() string {
+	// 字符串格式化
+	return fmt.Sprintf("订单参数错误 clOrdId: %v, %v", e.ClOrdId, e.Reason)
+}
+
+// 限频错误: 超出客户端限频且策略为拒绝
+type RateLimitError struct {
+	// 业务操作
+	Op string
+	// 超限的产品 ID, 为空时为业务操作请求数超限
+	InstId string
+	// 获取令牌需要等待的时间
+	Wait time.Duration
+}
+
+// 错误信息
+func (e *RateLimitError) Error() string {
+	// 字符串格式化
+	return fmt.Sprintf("%v 超出限频 instId: %v, 需等待 %v", e.Op, e.InstId, e.Wait)
+}
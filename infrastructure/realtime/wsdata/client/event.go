@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"log"
+
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 事件等待缓冲长度
+const eventWaiterBuffer = 64
+
+// 登录并等待交易所返回登录结果, 需要先启动 ReadWebsocketLoop
+func (c *OkxClient) LoginAndWait(ctx context.Context) error {
+	// 注册事件等待
+	events, done := c.waitEvents()
+	// 函数结束前取消等待
+	defer done()
+	// 发送登录请求
+	if err := c.Login(); err != nil {
+		// 返回错误
+		return err
+	}
+	// 等待登录事件
+	for {
+		// 事件选择
+		select {
+		// 超时或取消
+		case <-ctx.Done():
+			// 返回错误
+			return ctx.Err()
+		// 收到事件
+		case em := <-events:
+			// 登录失败
+			if em.Event == "error" || (em.Event == "login" && em.IsError()) {
+				// 登录状态重置, 重连时不再重放登录
+				c.setLoggedIn(false)
+				// 返回错误
+				return newEventError("login", em)
+			}
+			// 登录成功
+			if em.Event == "login" {
+				// 成功提示
+				log.Printf("[成功提示] OKX 私有频道登录确认")
+				// 返回
+				return nil
+			}
+		}
+	}
+}
+
+// 发送全部频道订阅并等待交易所逐个确认, 需要先启动 ReadWebsocketLoop
+func (c *OkxClient) SubscribeAndWait(ctx context.Context) error {
+	// 注册事件等待
+	events, done := c.waitEvents()
+	// 函数结束前取消等待
+	defer done()
+
+	// 上锁
+	c.mux.RLock()
+	// 待确认频道集合
+	pending := make(map[string]bool, len(c.channels))
+	// 逐个频道
+	for _, arg := range c.channels {
+		// 添加待确认频道
+		pending[c.channelKey(arg.Channel, arg.InstID)] = true
+	}
+	// 解锁
+	c.mux.RUnlock()
+
+	// 发送订阅请求
+	if err := c.subscribeAll(); err != nil {
+		// 返回错误
+		return err
+	}
+	// 等待全部频道确认
+	for len(pending) > 0 {
+		// 事件选择
+		select {
+		// 超时或取消
+		case <-ctx.Done():
+			// 返回错误
+			return ctx.Err()
+		// 收到事件
+		case em := <-events:
+			// 订阅失败
+			if em.IsError() {
+				// 返回错误
+				return newEventError("subscribe", em)
+			}
+			// 订阅成功
+			if em.Event == "subscribe" {
+				// 移除已确认频道
+				delete(pending, c.channelKey(em.Arg.Channel, em.Arg.InstID))
+			}
+		}
+	}
+	// 成功提示
+	log.Printf("[成功提示] Websocket 订阅成功")
+	// 返回
+	return nil
+}
+
+// 注册事件等待, 返回事件通道和取消函数
+func (c *OkxClient) waitEvents() (<-chan *EventMessage, func()) {
+	// 事件通道
+	events := make(chan *EventMessage, eventWaiterBuffer)
+	// 上锁
+	c.mux.Lock()
+	// 等待编号
+	id := c.nextWaiter
+	// 编号自增
+	c.nextWaiter++
+	// 添加等待
+	c.eventWaiters[id] = events
+	// 解锁
+	c.mux.Unlock()
+	// 取消函数
+	done := func() {
+		// 上锁
+		c.mux.Lock()
+		// 删除等待
+		delete(c.eventWaiters, id)
+		// 解锁
+		c.mux.Unlock()
+	}
+	// 返回
+	return events, done
+}
+
+// 分发事件给全部等待者
+func (c *OkxClient) dispatchEvent(em *EventMessage) {
+	// 错误事件
+	if em.IsError() {
+		// 错误提示
+		log.Printf("[错误提示] OKX 事件 %v 错误 code: %v, msg: %v", em.Event, em.Code, em.Msg)
+	}
+	// 上锁
+	c.mux.RLock()
+	// 函数结束前解锁
+	defer c.mux.RUnlock()
+	// 逐个分发
+	for _, events := range c.eventWaiters {
+		// 非阻塞发送, 等待者处理不及时则丢弃
+		select {
+		// 发送事件
+		case events <- em:
+		// 通道已满
+		default:
+		}
+	}
+}
+
+// 设置登录状态
+func (c *OkxClient) setLoggedIn(loggedIn bool) {
+	// 上锁
+	c.mux.Lock()
+	// 函数结束前解锁
+	defer c.mux.Unlock()
+	// 设置状态
+	c.loggedIn = loggedIn
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"log"
+	"time"
+
+	"github.com/wiger123/okex_v5_golang/config"
+)
+
+// 心跳检查间隔
+const heartbeatTick = time.Second
+
+// 心跳保活: 连接空闲时发送 ping, 超时未收到 pong 则关闭连接触发重连
+func (c *OkxClient) keepAlive() {
+	// 定时器
+	ticker := time.NewTicker(heartbeatTick)
+	// 函数结束前停止定时器
+	defer ticker.Stop()
+	// 循环检查
+	for range ticker.C {
+		// 客户端已关闭
+		if c.isClosed() {
+			// 返回
+			return
+		}
+		// 当前时间
+		now := time.Now()
+		// 上锁
+		c.mux.RLock()
+		// 最近收到数据时间
+		lastRecv := c.lastRecv
+		// ping 发送时间
+		pingSentAt := c.pingSentAt
+		// 当前连接
+		conn := c.conn
+		// 解锁
+		c.mux.RUnlock()
+
+		// 已发送 ping, 检查是否超时
+		if !pingSentAt.IsZero() {
+			// 未超时
+			if now.Sub(pingSentAt) < config.PongTimeout*time.Second {
+				// 继续等待
+				continue
+			}
+			// 错误提示
+			log.Printf("[错误提示] 等待 pong 超时, 强制重连")
+			// 上锁
+			c.mux.Lock()
+			// 清除 ping 状态
+			c.pingSentAt = time.Time{}
+			// 解锁
+			c.mux.Unlock()
+			// 关闭连接, 读取循环将收到错误并重连
+			conn.Close()
+			// 继续检查
+			continue
+		}
+
+		// 连接未空闲
+		if now.Sub(lastRecv) < config.PingInterval*time.Second {
+			// 继续检查
+			continue
+		}
+		// ping 发送时间
+		sentAt := time.Now()
+		// 上锁
+		c.mux.Lock()
+		// 发送前记录 ping 发送时间, pong 可能在发送返回前到达
+		c.pingSentAt = sentAt
+		// 解锁
+		c.mux.Unlock()
+		// 发送 ping
+		if err := c.sendRaw([]byte("ping")); err != nil {
+			// 错误提示
+			log.Printf("[错误提示] 发送 ping 失败: %v", err)
+			// 上锁
+			c.mux.Lock()
+			// 未发出的 ping 不等待 pong, 期间已被 pong 清除或重新发送时不修改
+			if c.pingSentAt.Equal(sentAt) {
+				// 清除 ping 状态
+				c.pingSentAt = time.Time{}
+			}
+			// 解锁
+			c.mux.Unlock()
+		}
+	}
+}
+
+// 记录收到数据, 返回是否为 pong
+func (c *OkxClient) markReceived(data []byte) bool {
+	// 是否为 pong
+	isPong := string(data) == "pong"
+	// 上锁
+	c.mux.Lock()
+	// 函数结束前解锁
+	defer c.mux.Unlock()
+	// 记录收到时间
+	c.lastRecv = time.Now()
+	// 收到 pong
+	if isPong {
+		// 记录往返时间
+		if !c.pingSentAt.IsZero() {
+			// 往返时间
+			c.clock.ObserveRTT(c.lastRecv.Sub(c.pingSentAt))
+		}
+		// 清除 ping 状态
+		c.pingSentAt = time.Time{}
+	}
+	// 返回
+	return isPong
+}
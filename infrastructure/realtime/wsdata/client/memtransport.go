@@ -0,0 +1,147 @@
+package client
+
+import (
+	"errors"
+	"sync"
+)
+
+// 内存连接已关闭
+var ErrTransportClosed = errors.New("内存连接已关闭")
+
+// 内存连接: 不访问网络, 由调用方推送交易所数据并检查发出的请求
+type MemoryTransport struct {
+	// 并发锁
+	mux sync.Mutex
+	// 推送给客户端的数据
+	inbound chan []byte
+	// 客户端发出的请求记录
+	sent [][]byte
+	// 客户端发出请求的通知
+	outbound chan []byte
+	// 关闭通知
+	done chan struct{}
+	// 是否已关闭
+	closed bool
+}
+
+// 创建内存连接
+func NewMemoryTransport() *MemoryTransport {
+	// 返回连接
+	return &MemoryTransport{
+		// 推送数据
+		inbound: make(chan []byte, 1024),
+		// 请求通知
+		outbound: make(chan []byte, 1024),
+		// 关闭通知
+		done: make(chan struct{}),
+	}
+}
+
+// 返回始终使用该内存连接的建立方式
+func (t *MemoryTransport) Dialer() Dialer {
+	// 返回建立方式
+	return func(url string) (Transport, error) {
+		// 上锁
+		t.mux.Lock()
+		// 函数结束前解锁
+		defer t.mux.Unlock()
+		// 已关闭的连接无法再次建立
+		if t.closed {
+			// 返回错误
+			return nil, ErrTransportClosed
+		}
+		// 返回连接
+		return t, nil
+	}
+}
+
+// 模拟交易所推送一条数据
+func (t *MemoryTransport) Push(frame []byte) {
+	// 事件选择
+	select {
+	// 推送数据
+	case t.inbound <- frame:
+	// 已关闭
+	case <-t.done:
+	}
+}
+
+// 模拟交易所推送一条字符串数据
+func (t *MemoryTransport) PushString(frame string) {
+	// 推送数据
+	t.Push([]byte(frame))
+}
+
+// 客户端发出请求的通知通道, 缓冲满后不再通知
+func (t *MemoryTransport) Outbound() <-chan []byte {
+	// 返回通道
+	return t.outbound
+}
+
+// 客户端已发出的全部请求
+func (t *MemoryTransport) Sent() [][]byte {
+	// 上锁
+	t.mux.Lock()
+	// 函数结束前解锁
+	defer t.mux.Unlock()
+	// 返回副本
+	return append([][]byte(nil), t.sent...)
+}
+
+// 读取一条数据, 阻塞直到有推送或连接关闭
+func (t *MemoryTransport) ReadMessage() ([]byte, error) {
+	// 事件选择
+	select {
+	// 收到推送
+	case data := <-t.inbound:
+		// 返回数据
+		return data, nil
+	// 已关闭
+	case <-t.done:
+		// 返回错误
+		return nil, ErrTransportClosed
+	}
+}
+
+// 记录客户端发出的请求
+func (t *MemoryTransport) WriteMessage(data []byte) error {
+	// 上锁
+	t.mux.Lock()
+	// 函数结束前解锁
+	defer t.mux.Unlock()
+	// 已关闭
+	if t.closed {
+		// 返回错误
+		return ErrTransportClosed
+	}
+	// 复制数据
+	frame := append([]byte(nil), data...)
+	// 记录请求
+	t.sent = append(t.sent, frame)
+	// 非阻塞通知
+	select {
+	// 发送通知
+	case t.outbound <- frame:
+	// 缓冲已满
+	default:
+	}
+	// 返回
+	return nil
+}
+
+// 关闭连接
+func (t *MemoryTransport) Close() error {
+	// 上锁
+	t.mux.Lock()
+	// 函数结束前解锁
+	defer t.mux.Unlock()
+	// 未关闭
+	if !t.closed {
+		// 标记关闭
+		t.closed = true
+		// 关闭通知
+		close(t.done)
+	}
+	// 返回
+	return nil
+}
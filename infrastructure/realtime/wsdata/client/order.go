@@ -0,0 +1,425 @@
+package client
+
+import (
+	. "github.com/wiger123/okex_v5_golang/database"
+	. "github.com/wiger123/okex_v5_golang/utils"
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 订单方向
+type Side string
+
+// 订单方向枚举
+const (
+	// 买入
+	SideBuy Side = "buy"
+	// 卖出
+	SideSell Side = "sell"
+)
+
+// 持仓方向
+type PosSide string
+
+// 持仓方向枚举
+const (
+	// 不指定, 买卖模式或币币交易
+	PosSideNone PosSide = ""
+	// 买卖模式
+	PosSideNet PosSide = "net"
+	// 开平仓模式: 多
+	PosSideLong PosSide = "long"
+	// 开平仓模式: 空
+	PosSideShort PosSide = "short"
+)
+
+// 订单类型
+type OrdType string
+
+// 订单类型枚举
+const (
+	// 市价单
+	OrdTypeMarket OrdType = "market"
+	// 限价单
+	OrdTypeLimit OrdType = "limit"
+	// 只做 maker 单
+	OrdTypePostOnly OrdType = "post_only"
+	// 全部成交或立即取消
+	OrdTypeFok OrdType = "fok"
+	// 立即成交并取消剩余
+	OrdTypeIoc OrdType = "ioc"
+	// 市价委托立即成交并取消剩余, 仅适用交割和永续
+	OrdTypeOptimalLimitIoc OrdType = "optimal_limit_ioc"
+)
+
+// 交易模式
+type TdMode string
+
+// 交易模式枚举
+const (
+	// 非保证金
+	TdModeCash TdMode = "cash"
+	// 全仓
+	TdModeCross TdMode = "cross"
+	// 逐仓
+	TdModeIsolated TdMode = "isolated"
+)
+
+// 账户持仓模式
+type PosMode string
+
+// 持仓模式枚举
+const (
+	// 买卖模式
+	PosModeNet PosMode = "net_mode"
+	// 开平仓模式
+	PosModeLongShort PosMode = "long_short_mode"
+)
+
+// 订单构建选项
+type OrderOption func(o *PostOrder)
+
+// 委托价
+func WithPx(px Decimal) OrderOption {
+	// 返回选项
+	return func(o *PostOrder) {
+		// 委托价
+		o.Px = px
+	}
+}
+
+// 持仓方向
+func WithPosSide(posSide PosSide) OrderOption {
+	// 返回选项
+	return func(o *PostOrder) {
+		// 持仓方向
+		o.PosSide = string(posSide)
+	}
+}
+
+// 开平仓方向: 仅在开平仓模式且为保证金交易 (cross / isolated) 时设置, 买卖模式和 cash 模式下不设置持仓方向
+func WithHedgePosSide(posSide PosSide) OrderOption {
+	// 返回选项
+	return func(o *PostOrder) {
+		// 开平仓方向
+		o.hedgePosSide = posSide
+	}
+}
+
+// 用户提供的订单 ID
+func WithClOrdId(clOrdId string) OrderOption {
+	// 返回选项
+	return func(o *PostOrder) {
+		// 用户提供的订单 ID
+		o.ClOrdId = clOrdId
+	}
+}
+
+// 保证金币种
+func WithCcy(ccy string) OrderOption {
+	// 返回选项
+	return func(o *PostOrder) {
+		// 保证金币种
+		o.Ccy = ccy
+	}
+}
+
+// 订单标签
+func WithTag(tag string) OrderOption {
+	// 返回选项
+	return func(o *PostOrder) {
+		// 订单标签
+		o.Tag = tag
+	}
+}
+
+// 是否只减仓
+func WithReduceOnly(reduceOnly bool) OrderOption {
+	// 返回选项
+	return func(o *PostOrder) {
+		// 是否只减仓
+		o.ReduceOnly = reduceOnly
+	}
+}
+
+// 市价单委托数量的类型: base_ccy 或 quote_ccy
+func WithTgtCcy(tgtCcy string) OrderOption {
+	// 返回选项
+	return func(o *PostOrder) {
+		// 市价单委托数量的类型
+		o.TgtCcy = tgtCcy
+	}
+}
+
+// 构建订单时按产品精度取整: 委托价买单向下, 卖单向上, 数量向下; 未指定时不合规的订单被拒绝
+func WithSnap() OrderOption {
+	// 返回选项
+	return func(o *PostOrder) {
+		// 取整
+		o.snap = true
+	}
+}
+
+// 数量以计价货币金额 (USDT) 表示, 构建订单时按委托价和合约面值换算为张数 (币币为交易币数量) 并按 lotSz 向下取整; 需要委托价和产品信息
+func WithQuoteSz(quoteSz bool) OrderOption {
+	// 返回选项
+	return func(o *PostOrder) {
+		// 按金额下单
+		o.quoteSz = quoteSz
+	}
+}
+
+// 指定产品信息缓存, 构建和发送订单前按 tickSz, lotSz, minSz 校验
+func WithInstruments(instruments *Instruments) ClientOption {
+	// 返回选项
+	return func(c *OkxClient) {
+		// 产品信息缓存
+		c.instruments = instruments
+	}
+}
+
+// 设置账户持仓模式, 用于决定和校验持仓方向; 应在登录后按账户配置 (/api/v5/account/config) 设置, 默认为买卖模式
+func (c *OkxClient) SetPosMode(posMode PosMode) {
+	// 上锁
+	c.mux.Lock()
+	// 函数结束前解锁
+	defer c.mux.Unlock()
+	// 持仓模式
+	c.posMode = posMode
+}
+
+// 构建订单参数, 发送前校验参数组合
+func (c *OkxClient) NewOrder(instId string, tdMode TdMode, side Side, ordType OrdType, sz Decimal, opts ...OrderOption) (PostOrder, error) {
+	// 订单参数
+	order := PostOrder{
+		// 产品 ID
+		InstId: instId,
+		// 交易模式
+		TdMode: string(tdMode),
+		// 订单方向
+		Side: string(side),
+		// 订单类型
+		OrdType: string(ordType),
+		// 买入或卖出的数量
+		Sz: sz,
+	}
+	// 逐个选项
+	for _, opt := range opts {
+		// 设置选项
+		opt(&order)
+	}
+	// 上锁
+	c.mux.RLock()
+	// 持仓模式
+	posMode := c.posMode
+	// 解锁
+	c.mux.RUnlock()
+	// 开平仓模式的保证金交易才设置开平仓方向
+	if order.hedgePosSide != PosSideNone && posMode == PosModeLongShort && TdMode(order.TdMode) != TdModeCash {
+		// 持仓方向
+		order.PosSide = string(order.hedgePosSide)
+	}
+	// 金额换算为下单数量
+	if order.quoteSz {
+		// 换算数量
+		if err := c.convertQuoteSz(&order); err != nil {
+			// 返回错误
+			return PostOrder{}, err
+		}
+	}
+	// 按产品精度取整
+	if order.snap {
+		// 取整
+		c.snapOrder(&order)
+	}
+	// 校验参数
+	if err := validateOrder(&order, posMode); err != nil {
+		// 返回错误
+		return PostOrder{}, err
+	}
+	// 校验产品精度
+	if err := c.checkInstrument(&order); err != nil {
+		// 返回错误
+		return PostOrder{}, err
+	}
+	// 返回参数
+	return order, nil
+}
+
+// 校验订单参数组合
+func validateOrder(o *PostOrder, posMode PosMode) error {
+	// 产品 ID
+	if o.InstId == "" {
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "缺少产品 ID"}
+	}
+	// 交易模式
+	switch TdMode(o.TdMode) {
+	// 合法值
+	case TdModeCash, TdModeCross, TdModeIsolated:
+	// 非法值
+	default:
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "未知交易模式 " + o.TdMode}
+	}
+	// 订单方向
+	switch Side(o.Side) {
+	// 合法值
+	case SideBuy, SideSell:
+	// 非法值
+	default:
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "未知订单方向 " + o.Side}
+	}
+	// 数量必须为正数
+	if o.Sz.Sign() <= 0 {
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "数量必须为正数: " + o.Sz.String()}
+	}
+	// 订单类型与委托价
+	switch OrdType(o.OrdType) {
+	// 市价类订单不能带委托价
+	case OrdTypeMarket, OrdTypeOptimalLimitIoc:
+		// 带有委托价
+		if !o.Px.IsEmpty() {
+			// 返回错误
+			return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: o.OrdType + " 订单不能指定委托价"}
+		}
+	// 限价类订单必须带委托价
+	case OrdTypeLimit, OrdTypePostOnly, OrdTypeFok, OrdTypeIoc:
+		// 委托价必须为正数
+		if o.Px.Sign() <= 0 {
+			// 返回错误
+			return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: o.OrdType + " 订单需要有效委托价"}
+		}
+	// 非法值
+	default:
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "未知订单类型 " + o.OrdType}
+	}
+	// 市价单委托数量类型仅适用市价单
+	if o.TgtCcy != "" && OrdType(o.OrdType) != OrdTypeMarket {
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "tgtCcy 仅适用市价单"}
+	}
+	// 非保证金模式不能只减仓
+	if o.ReduceOnly && TdMode(o.TdMode) == TdModeCash {
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "cash 模式不能只减仓"}
+	}
+	// 持仓方向
+	switch PosSide(o.PosSide) {
+	// 不指定或买卖模式
+	case PosSideNone, PosSideNet:
+	// 开平仓方向
+	case PosSideLong, PosSideShort:
+		// 非保证金模式没有持仓方向
+		if TdMode(o.TdMode) == TdModeCash {
+			// 返回错误
+			return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "cash 模式不能指定持仓方向"}
+		}
+		// 仅开平仓模式可用
+		if posMode != PosModeLongShort {
+			// 返回错误
+			return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "持仓方向 " + o.PosSide + " 仅适用开平仓模式"}
+		}
+	// 非法值
+	default:
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "未知持仓方向 " + o.PosSide}
+	}
+	// 校验通过
+	return nil
+}
+
+// 查找产品信息, 未指定产品信息缓存或未加载时返回 false
+func (c *OkxClient) Instrument(instId string) (Instrument, bool) {
+	// 未指定产品信息缓存
+	if c.instruments == nil {
+		// 返回
+		return Instrument{}, false
+	}
+	// 返回
+	return c.instruments.Get(instId)
+}
+
+// 计价货币金额按委托价换算为下单数量, 并按 lotSz 向下取整
+func (c *OkxClient) convertQuoteSz(o *PostOrder) error {
+	// 需要委托价
+	if o.Px.Sign() <= 0 {
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: "按金额下单需要有效委托价"}
+	}
+	// 产品信息
+	inst, ok := c.Instrument(o.InstId)
+	// 未加载
+	if !ok {
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: o.InstId + " 产品信息未加载, 无法按金额换算数量"}
+	}
+	// 换算并取整
+	o.Sz = inst.SnapSz(inst.QuoteToSz(o.Sz, o.Px))
+	// 返回
+	return nil
+}
+
+// 按产品精度取整委托价和数量, 未加载的产品保持原样
+func (c *OkxClient) snapOrder(o *PostOrder) {
+	// 未指定产品信息缓存
+	if c.instruments == nil {
+		// 返回
+		return
+	}
+	// 产品信息
+	inst, ok := c.instruments.Get(o.InstId)
+	// 未加载
+	if !ok {
+		// 返回
+		return
+	}
+	// 有委托价
+	if !o.Px.IsEmpty() {
+		// 委托价取整
+		o.Px = inst.SnapPx(o.Side, o.Px)
+	}
+	// 数量取整, 市价单按计价货币下单时不取整
+	if o.TgtCcy != "quote_ccy" {
+		// 数量取整
+		o.Sz = inst.SnapSz(o.Sz)
+	}
+}
+
+// 按产品精度校验委托价和数量, 未加载的产品不校验
+func (c *OkxClient) checkInstrument(o *PostOrder) error {
+	// 未指定产品信息缓存
+	if c.instruments == nil {
+		// 返回
+		return nil
+	}
+	// 产品信息
+	inst, ok := c.instruments.Get(o.InstId)
+	// 未加载
+	if !ok {
+		// 返回
+		return nil
+	}
+	// 有委托价
+	if !o.Px.IsEmpty() {
+		// 校验委托价
+		if err := inst.ValidatePx(o.Px); err != nil {
+			// 返回错误
+			return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: err.Error()}
+		}
+	}
+	// 市价单按计价货币下单时数量为金额, 不校验
+	if o.TgtCcy == "quote_ccy" {
+		// 返回
+		return nil
+	}
+	// 校验数量
+	if err := inst.ValidateSz(o.Sz); err != nil {
+		// 返回错误
+		return &InvalidOrderError{ClOrdId: o.ClOrdId, Reason: err.Error()}
+	}
+	// 校验通过
+	return nil
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 连接断开导致请求无响应, 错误类别为 KindConnection
+var ErrConnectionLost = errors.New("OKX 连接断开, 请求未收到响应")
+
+// 等待交易所响应的订单操作
+type PendingOp struct {
+	// 消息的唯一标识
+	Id string
+	// 业务操作
+	Op string
+	// 响应到达时的回调, 在读取协程中执行
+	onResponse func(resp *OpResponse)
+	// 连接断开未收到响应时的回调
+	onFailure func(err error)
+	// 完成通知
+	done chan struct{}
+	// 交易所响应
+	resp *OpResponse
+	// 错误
+	err error
+}
+
+// 完成通知通道
+func (p *PendingOp) Done() <-chan struct{} {
+	// 返回通道
+	return p.done
+}
+
+// 等待交易所响应, 返回逐个订单的操作结果
+func (p *PendingOp) Wait(ctx context.Context) (*OpResponse, error) {
+	// 事件选择
+	select {
+	// 超时或取消
+	case <-ctx.Done():
+		// 返回错误
+		return nil, ctx.Err()
+	// 收到响应
+	case <-p.done:
+		// 返回结果
+		return p.resp, p.err
+	}
+}
+
+// 生成唯一消息标识
+func (c *OkxClient) nextOpId() string {
+	// 计数器自增
+	n := atomic.AddInt64(&c.opCounter, 1)
+	// 随机前缀 + 计数
+	return c.idPrefix + strconv.FormatInt(n, 10)
+}
+
+// 注册等待响应的订单操作, onFailure 在连接断开未收到响应时执行
+func (c *OkxClient) addPending(op string, onResponse func(resp *OpResponse), onFailure func(err error)) *PendingOp {
+	// 创建等待
+	p := &PendingOp{
+		// 消息的唯一标识
+		Id: c.nextOpId(),
+		// 业务操作
+		Op: op,
+		// 响应回调
+		onResponse: onResponse,
+		// 失败回调
+		onFailure: onFailure,
+		// 完成通知
+		done: make(chan struct{}),
+	}
+	// 上锁
+	c.mux.Lock()
+	// 添加等待
+	c.pendingOps[p.Id] = p
+	// 解锁
+	c.mux.Unlock()
+	// 返回
+	return p
+}
+
+// 取出等待响应的订单操作
+func (c *OkxClient) takePending(id string) *PendingOp {
+	// 上锁
+	c.mux.Lock()
+	// 函数结束前解锁
+	defer c.mux.Unlock()
+	// 查找等待
+	p := c.pendingOps[id]
+	// 删除等待
+	delete(c.pendingOps, id)
+	// 返回
+	return p
+}
+
+// 分发订单操作响应
+func (c *OkxClient) dispatchOpResponse(resp *OpResponse) {
+	// 取出等待
+	p := c.takePending(resp.Id)
+	// 未找到等待
+	if p == nil {
+		// 返回
+		return
+	}
+	// 执行回调
+	if p.onResponse != nil {
+		// 回调
+		p.onResponse(resp)
+	}
+	// 记录响应
+	p.resp = resp
+	// 交易所拒绝整个请求或全部订单
+	p.err = newOpError(p.Op, resp)
+	// 完成通知
+	close(p.done)
+	// 通知错误
+	if p.err != nil {
+		// 通知
+		c.reportError(p.err)
+	}
+}
+
+// 连接断开, 全部等待中的请求以错误结束
+func (c *OkxClient) failPending(err error) {
+	// 上锁
+	c.mux.Lock()
+	// 取出全部等待
+	pending := c.pendingOps
+	// 重置等待
+	c.pendingOps = make(map[string]*PendingOp)
+	// 解锁
+	c.mux.Unlock()
+	// 逐个结束
+	for _, p := range pending {
+		// 执行回调
+		if p.onFailure != nil {
+			// 回调
+			p.onFailure(err)
+		}
+		// 记录错误
+		p.err = err
+		// 完成通知
+		close(p.done)
+	}
+}
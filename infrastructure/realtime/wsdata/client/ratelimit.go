@@ -0,0 +1,262 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wiger123/okex_v5_golang/config"
+)
+
+// 超出限频时的处理策略
+type LimitPolicy int
+
+// 限频策略枚举
+const (
+	// 排队等待令牌
+	LimitQueue LimitPolicy = iota
+	// 立即拒绝并返回错误
+	LimitReject
+)
+
+// 单次请求占用的令牌数超过令牌桶容量, 等待也无法获取, 需拆分请求
+var ErrExceedsCapacity = errors.New("单次请求超过限频容量")
+
+// 令牌桶
+type tokenBucket struct {
+	// 容量
+	capacity float64
+	// 当前令牌数
+	tokens float64
+	// 每秒补充令牌数
+	rate float64
+	// 上次补充时间
+	last time.Time
+}
+
+// 创建令牌桶, 时间窗口内补满容量
+func newTokenBucket(capacity float64, window time.Duration, now time.Time) *tokenBucket {
+	// 返回令牌桶
+	return &tokenBucket{
+		// 容量
+		capacity: capacity,
+		// 初始满桶
+		tokens: capacity,
+		// 每秒补充令牌数
+		rate: capacity / window.Seconds(),
+		// 上次补充时间
+		last: now,
+	}
+}
+
+// 按时间补充令牌
+func (b *tokenBucket) refill(now time.Time) {
+	// 补充令牌
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	// 不超过容量
+	if b.tokens > b.capacity {
+		// 满桶
+		b.tokens = b.capacity
+	}
+	// 更新时间
+	b.last = now
+}
+
+// 获取 n 个令牌还需等待的时间
+func (b *tokenBucket) waitFor(n float64) time.Duration {
+	// 令牌足够
+	if b.tokens >= n {
+		// 无需等待
+		return 0
+	}
+	// 等待时间
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+// 限频器: 每个业务操作的请求数和每个产品的订单数
+type RateLimiter struct {
+	// 并发锁
+	mux sync.Mutex
+	// 超出限频时的处理策略
+	policy LimitPolicy
+	// 时间窗口
+	window time.Duration
+	// 业务操作令牌桶
+	opBuckets map[string]*tokenBucket
+	// 产品令牌桶: 业务操作|产品 ID
+	instBuckets map[string]*tokenBucket
+}
+
+// 创建限频器
+func NewRateLimiter(policy LimitPolicy) *RateLimiter {
+	// 返回限频器
+	return &RateLimiter{
+		// 处理策略
+		policy: policy,
+		// 时间窗口
+		window: config.RateLimitWindow * time.Millisecond,
+		// 业务操作令牌桶
+		opBuckets: make(map[string]*tokenBucket),
+		// 产品令牌桶
+		instBuckets: make(map[string]*tokenBucket),
+	}
+}
+
+// 每个产品的订单数上限
+func instCapacity(op string) float64 {
+	// 批量操作
+	if strings.HasPrefix(op, "batch-") {
+		// 返回上限
+		return config.RateLimitBatchOrders
+	}
+	// 单个操作
+	return config.RateLimitSingleOrders
+}
+
+// 获取令牌桶, 需在持有锁时调用
+func (rl *RateLimiter) bucket(op, instId string, now time.Time) *tokenBucket {
+	// 业务操作令牌桶
+	if instId == "" {
+		// 查找令牌桶
+		b, ok := rl.opBuckets[op]
+		// 新建令牌桶
+		if !ok {
+			// 创建
+			b = newTokenBucket(config.RateLimitOpRequests, rl.window, now)
+			// 保存
+			rl.opBuckets[op] = b
+		}
+		// 返回
+		return b
+	}
+	// 产品令牌桶 Key 值
+	key := op + "|" + instId
+	// 查找令牌桶
+	b, ok := rl.instBuckets[key]
+	// 新建令牌桶
+	if !ok {
+		// 创建
+		b = newTokenBucket(instCapacity(op), rl.window, now)
+		// 保存
+		rl.instBuckets[key] = b
+	}
+	// 返回
+	return b
+}
+
+// 获取一次请求的令牌: 业务操作 1 个, 每个订单占用其产品 1 个
+// 排队等待时可由 ctx 取消; 单个产品的订单数超过令牌桶容量时返回 ErrExceedsCapacity
+func (rl *RateLimiter) Acquire(ctx context.Context, op string, instIds []string) error {
+	// 每个产品的订单数
+	counts := make(map[string]float64)
+	// 逐个订单
+	for _, instId := range instIds {
+		// 计数
+		counts[instId]++
+	}
+	// 逐个产品
+	for instId, n := range counts {
+		// 超过容量, 永远无法获取
+		if n > instCapacity(op) {
+			// 返回错误
+			return fmt.Errorf("%v instId: %v 订单数 %v, 容量 %v: %w", op, instId, n, instCapacity(op), ErrExceedsCapacity)
+		}
+	}
+	// 循环直到获取令牌, 被拒绝或取消
+	for {
+		// 需要等待的时间和原因
+		wait, limitedInst := rl.tryAcquire(op, counts)
+		// 获取成功
+		if wait == 0 {
+			// 返回
+			return nil
+		}
+		// 拒绝策略
+		if rl.policy == LimitReject {
+			// 返回错误
+			return &RateLimitError{Op: op, InstId: limitedInst, Wait: wait}
+		}
+		// 排队等待
+		timer := time.NewTimer(wait)
+		// 事件选择
+		select {
+		// 取消
+		case <-ctx.Done():
+			// 停止定时
+			timer.Stop()
+			// 返回错误
+			return ctx.Err()
+		// 等待结束
+		case <-timer.C:
+		}
+	}
+}
+
+// 尝试获取令牌, 不足时返回需要等待的时间
+func (rl *RateLimiter) tryAcquire(op string, counts map[string]float64) (time.Duration, string) {
+	// 上锁
+	rl.mux.Lock()
+	// 函数结束前解锁
+	defer rl.mux.Unlock()
+	// 当前时间
+	now := time.Now()
+	// 业务操作令牌桶
+	opBucket := rl.bucket(op, "", now)
+	// 补充令牌
+	opBucket.refill(now)
+	// 最长等待时间
+	wait := opBucket.waitFor(1)
+	// 限频的产品
+	limitedInst := ""
+	// 逐个产品
+	for instId, n := range counts {
+		// 产品令牌桶
+		b := rl.bucket(op, instId, now)
+		// 补充令牌
+		b.refill(now)
+		// 等待时间
+		if w := b.waitFor(n); w > wait {
+			// 更新等待时间
+			wait, limitedInst = w, instId
+		}
+	}
+	// 令牌不足
+	if wait > 0 {
+		// 返回等待时间
+		return wait, limitedInst
+	}
+	// 扣除业务操作令牌
+	opBucket.tokens--
+	// 逐个产品
+	for instId, n := range counts {
+		// 扣除产品令牌
+		rl.instBuckets[op+"|"+instId].tokens -= n
+	}
+	// 返回
+	return 0, ""
+}
+
+// 当前用量: instId 为空时返回业务操作的请求用量, 否则返回该产品的订单用量
+func (rl *RateLimiter) Usage(op, instId string) (used float64, capacity float64) {
+	// 上锁
+	rl.mux.Lock()
+	// 函数结束前解锁
+	defer rl.mux.Unlock()
+	// 当前时间
+	now := time.Now()
+	// 令牌桶
+	b := rl.bucket(op, instId, now)
+	// 补充令牌
+	b.refill(now)
+	// 返回用量和容量
+	return b.capacity - b.tokens, b.capacity
+}
+
+// 订单操作限频用量, 供策略控制下单节奏
+func (c *OkxClient) RateUsage(op, instId string) (used float64, capacity float64) {
+	// 返回用量
+	return c.limiter.Usage(op, instId)
+}
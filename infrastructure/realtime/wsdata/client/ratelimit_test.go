@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/wiger123/okex_v5_golang/config"
+)
+
+// n 个相同产品
+func repeatInst(instId string, n int) []string {
+	// 产品列表
+	out := make([]string, n)
+	// 逐个
+	for i := range out {
+		// 产品
+		out[i] = instId
+	}
+	// 返回
+	return out
+}
+
+func TestAcquireExceedsCapacity(t *testing.T) {
+	for _, policy := range []LimitPolicy{LimitQueue, LimitReject} {
+		rl := NewRateLimiter(policy)
+		// 单个产品订单数超过容量, 排队也无法获取, 应立即返回
+		done := make(chan error, 1)
+		go func() {
+			done <- rl.Acquire(context.Background(), "order", repeatInst("BTC-USDT", config.RateLimitSingleOrders+1))
+		}()
+		select {
+		case err := <-done:
+			if !errors.Is(err, ErrExceedsCapacity) || KindOf(err) != KindRejected {
+				t.Fatalf("策略 %v: 期望 ErrExceedsCapacity, 实际: %v", policy, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("策略 %v: 超过容量的请求一直排队", policy)
+		}
+		// 未占用令牌
+		if used, _ := rl.Usage("order", "BTC-USDT"); used != 0 {
+			t.Fatalf("策略 %v: 失败的请求占用了令牌: %v", policy, used)
+		}
+	}
+	// 批量操作按批量容量判断
+	rl := NewRateLimiter(LimitReject)
+	if err := rl.Acquire(context.Background(), "batch-orders", repeatInst("BTC-USDT", config.RateLimitSingleOrders+1)); err != nil {
+		t.Fatalf("批量容量内的请求失败: %v", err)
+	}
+}
+
+// 占满业务操作令牌桶
+func exhaust(t *testing.T, rl *RateLimiter, op string) {
+	// 逐个请求
+	for i := 0; i < config.RateLimitOpRequests; i++ {
+		// 每个请求使用不同产品, 只占满业务操作令牌桶
+		if err := rl.Acquire(context.Background(), op, []string{fmt.Sprintf("INST-%v", i)}); err != nil {
+			// 终止测试
+			t.Fatalf("第 %v 个请求: %v", i, err)
+		}
+	}
+}
+
+func TestAcquireRejectPolicy(t *testing.T) {
+	rl := NewRateLimiter(LimitReject)
+	exhaust(t, rl, "order")
+	err := rl.Acquire(context.Background(), "order", []string{"BTC-USDT"})
+	var re *RateLimitError
+	if !errors.As(err, &re) || re.Wait <= 0 || KindOf(err) != KindRateLimited {
+		t.Fatalf("期望 RateLimitError, 实际: %v", err)
+	}
+}
+
+func TestAcquireQueueCancelled(t *testing.T) {
+	rl := NewRateLimiter(LimitQueue)
+	exhaust(t, rl, "order")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- rl.Acquire(ctx, "order", []string{"BTC-USDT"}) }()
+	// 排队期间取消
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("期望 context.Canceled, 实际: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("取消后仍在排队")
+	}
+}
+
+func TestShutdownCancelsQueuedOrders(t *testing.T) {
+	c, _ := newMemClient(t)
+	exhaust(t, c.limiter, "order")
+	done := make(chan error, 1)
+	go func() { done <- c.limiter.Acquire(c.ctx, "order", []string{"BTC-USDT"}) }()
+	// 关闭客户端中断排队
+	c.Shutdown()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("期望 context.Canceled, 实际: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("关闭后仍在排队")
+	}
+}
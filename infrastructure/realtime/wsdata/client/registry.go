@@ -0,0 +1,181 @@
+package client
+
+import (
+	"encoding/json"
+	"sync"
+
+	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
+)
+
+// 推送信息工厂: 为频道创建空的推送信息, 用于解析
+type MessageFactory func() PushMessage
+
+// 频道注册项
+type channelEntry struct {
+	// 推送信息工厂
+	factory MessageFactory
+	// 频道默认信息处理器, 订阅时未指定处理器时使用
+	handler MessageHandler
+}
+
+// 频道注册表: 频道名 -> 推送信息工厂和默认信息处理器
+type Registry struct {
+	// 并发锁
+	mux sync.RWMutex
+	// 注册项
+	entries map[string]channelEntry
+}
+
+// 创建空的频道注册表
+func NewRegistry() *Registry {
+	// 返回
+	return &Registry{entries: make(map[string]channelEntry)}
+}
+
+// 创建包含内置频道的注册表
+func DefaultRegistry() *Registry {
+	// 注册表初始化
+	r := NewRegistry()
+	// 交易数据
+	r.Register("trades", func() PushMessage { return &TradeMessage{} }, nil)
+	// 盘口数据
+	r.Register("books5", func() PushMessage { return &Book5Message{} }, nil)
+	// 行情数据
+	r.Register("tickers", func() PushMessage { return &TickerMessage{} }, nil)
+	// 深度数据
+	for _, channel := range []string{"books", "books-l2-tbt", "books50-l2-tbt", "bbo-tbt"} {
+		// 深度数据
+		r.Register(channel, func() PushMessage { return &BookMessage{} }, nil)
+	}
+	// 标记价格
+	r.Register("mark-price", func() PushMessage { return &MarkPriceMessage{} }, nil)
+	// 指数行情
+	r.Register("index-tickers", func() PushMessage { return &IndexTickerMessage{} }, nil)
+	// 资金费率
+	r.Register("funding-rate", func() PushMessage { return &FundingRateMessage{} }, nil)
+	// 持仓总量
+	r.Register("open-interest", func() PushMessage { return &OpenInterestMessage{} }, nil)
+	// 限价
+	r.Register("price-limit", func() PushMessage { return &PriceLimitMessage{} }, nil)
+	// 强平订单
+	r.Register("liquidation-orders", func() PushMessage { return &LiquidationMessage{} }, nil)
+	// 产品信息
+	r.Register("instruments", func() PushMessage { return &InstrumentsMessage{} }, nil)
+	// 账户数据
+	r.Register("account", func() PushMessage { return &AccountMessage{} }, nil)
+	// 持仓数据
+	r.Register("positions", func() PushMessage { return &PositionsMessage{} }, nil)
+	// 订单数据
+	r.Register("orders", func() PushMessage { return &OrdersMessage{} }, nil)
+	// 返回
+	return r
+}
+
+// 注册频道, 已注册时覆盖; handler 可为空
+func (r *Registry) Register(channel string, factory MessageFactory, handler MessageHandler) {
+	// 上锁
+	r.mux.Lock()
+	// 函数结束前解锁
+	defer r.mux.Unlock()
+	// 注册项赋值
+	r.entries[channel] = channelEntry{factory: factory, handler: handler}
+}
+
+// 查找频道注册项
+func (r *Registry) lookup(channel string) (channelEntry, bool) {
+	// 上锁
+	r.mux.RLock()
+	// 函数结束前解锁
+	defer r.mux.RUnlock()
+	// 注册项
+	entry, ok := r.entries[channel]
+	// 返回
+	return entry, ok && entry.factory != nil
+}
+
+// 指定频道注册表, 默认为内置频道
+func WithRegistry(r *Registry) ClientOption {
+	// 返回选项
+	return func(c *OkxClient) {
+		// 频道注册表
+		c.registry = r
+	}
+}
+
+// 注册自定义频道, 无需修改客户端即可解析和处理新的推送
+func (c *OkxClient) RegisterChannel(channel string, factory MessageFactory, handler MessageHandler) {
+	// 注册频道
+	c.registry.Register(channel, factory, handler)
+}
+
+// Websocket 信息帧: 一次解析取得信息类型和频道参数, 推送数据保留原始内容
+type frame struct {
+	// 频道参数
+	Arg json.RawMessage `json:"arg"`
+	// 事件类型
+	Event string `json:"event"`
+	// 消息的唯一标识
+	Id string `json:"id"`
+	// 业务操作
+	Op string `json:"op"`
+	// 错误码
+	Code string `json:"code"`
+	// 错误消息
+	Msg string `json:"msg"`
+	// 连接 ID
+	ConnID string `json:"connId"`
+	// 增量 or 全量推送
+	Action string `json:"action"`
+	// 推送数据
+	Data json.RawMessage `json:"data"`
+}
+
+// 频道名
+func (f *frame) channel() (string, error) {
+	// 没有频道参数
+	if len(f.Arg) == 0 {
+		// 返回
+		return "", nil
+	}
+	// 频道参数
+	var arg MarketArg
+	// 解析频道参数
+	if err := json.Unmarshal(f.Arg, &arg); err != nil {
+		// 返回错误
+		return "", err
+	}
+	// 返回
+	return arg.Channel, nil
+}
+
+// 构建事件推送信息
+func (f *frame) event() (*EventMessage, error) {
+	// 事件数据初始化
+	em := &EventMessage{Event: f.Event, Code: f.Code, Msg: f.Msg, ConnID: f.ConnID}
+	// 有频道参数
+	if len(f.Arg) > 0 {
+		// 解析频道参数
+		if err := json.Unmarshal(f.Arg, &em.Arg); err != nil {
+			// 返回错误
+			return nil, err
+		}
+	}
+	// 返回
+	return em, nil
+}
+
+// 构建订单操作响应
+func (f *frame) opResponse() (*OpResponse, error) {
+	// 响应数据初始化
+	resp := &OpResponse{Id: f.Id, Op: f.Op, Code: f.Code, Msg: f.Msg}
+	// 有逐个订单结果
+	if len(f.Data) > 0 {
+		// 解析逐个订单结果
+		if err := json.Unmarshal(f.Data, &resp.Data); err != nil {
+			// 返回错误
+			return nil, err
+		}
+	}
+	// 返回
+	return resp, nil
+}
@@ -3,8 +3,8 @@ package client
 import (
 	"log"
 
-	"github.com/wiger123/okex_v5_golang/config"
 	. "github.com/wiger123/okex_v5_golang/database"
+	. "github.com/wiger123/okex_v5_golang/utils"
 	. "github.com/wiger123/okex_v5_golang/wsdata/protocol"
 )
 
@@ -27,13 +27,19 @@ type PostOrder struct {
 	// 订单类型
 	OrdType string `json:"ordType"`
 	// 买入或卖出的数量
-	Sz string `json:"sz"`
-	// 委托价
-	Px string `json:"px"`
+	Sz Decimal `json:"sz"`
+	// 委托价, 市价单为空
+	Px Decimal `json:"px"`
 	// 是否只减仓
 	ReduceOnly bool `json:"reduceOnly"`
 	// 市价单委托数量的类型
 	TgtCcy string `json:"tgtCcy"`
+	// 构建时按产品精度取整, 不发送
+	snap bool
+	// 构建时 Sz 为计价货币金额, 按委托价换算为下单数量, 不发送
+	quoteSz bool
+	// 构建时按账户持仓模式和交易模式决定是否设置的持仓方向, 不发送
+	hedgePosSide PosSide
 }
 
 // 订单请求参数: 下单, 批量下单
@@ -46,59 +52,79 @@ type PostOrderMessage struct {
 	Args []PostOrder `json:"args"`
 }
 
-// 单个订单参数
-func (c *OkxClient) PostSingleOrder(instId, tdMode, clOrdId, side, posSide, ordType, sz, px string) PostOrder {
-	// 登录参数设置
-	singleOrder := &PostOrder{
-		// 产品 ID
-		InstId: instId,
-		// 交易模式
-		TdMode: tdMode,
-		// 用户提供的订单 ID
-		ClOrdId: clOrdId,
-		// 订单方向
-		Side: side,
-		// 持仓方向
-		PosSide: posSide,
-		// 订单类型
-		OrdType: ordType,
-		// 买入或卖出的数量
-		Sz: sz,
-		// 委托价
-		Px: px,
-	}
-	// 返回参数
-	return *singleOrder
-}
+// 批量下单, 撤单, 改单单次最多订单数
+const maxBatchOrders = 20
 
-// 批量下单
-func (c *OkxClient) PostOrders(id, op string, args []PostOrder, dr *DataRepo) error {
-	// orders 上锁
-	dr.Mu.Lock()
-	// orders 添加指针, 指向特殊结构体, 等待交易所返回数据后解除特殊结构体
+// 批量下单, 返回等待交易所逐个订单确认的请求; 没有订单时不发送请求, 返回 nil
+func (c *OkxClient) PostOrders(op string, args []PostOrder, dr *DataRepo) (*PendingOp, error) {
+	// 没有订单
+	if len(args) == 0 {
+		// 返回
+		return nil, nil
+	}
+	// 上锁
+	c.mux.RLock()
+	// 持仓模式
+	posMode := c.posMode
+	// 解锁
+	c.mux.RUnlock()
+	// 逐个订单校验参数组合和产品精度, 未经 NewOrder 构建的订单同样校验, 不合规的订单不发送
 	for i := 0; i < len(args); i++ {
-		// 判断 PingPong 订单类型
-		if args[i].InstId == config.PingPongInstID {
-			// 跳过操作
-			continue
+		// 校验参数组合
+		err := validateOrder(&args[i], posMode)
+		// 校验产品精度
+		if err == nil {
+			// 校验
+			err = c.checkInstrument(&args[i])
 		}
-		// 创建特殊订单
-		newOrder := &Orders{
-			// 订单号
-			ClOrdId: args[i].ClOrdId,
-			// 订单状态: 本地
-			State: "local",
+		// 校验失败
+		if err != nil {
+			// 错误提示
+			log.Printf("[错误提示] %v 订单参数错误: %v", op, err)
+			// 通知错误
+			c.reportError(err)
+			// 返回
+			return nil, err
 		}
-		// 添加到订单列表
-		dr.OrdersData[args[i].ClOrdId] = newOrder
 	}
-	// orders 解锁
-	defer dr.Mu.Unlock()
+	// 产品 ID 列表
+	instIds := make([]string, 0, len(args))
+	// 逐个订单
+	for i := 0; i < len(args); i++ {
+		// 添加产品 ID
+		instIds = append(instIds, args[i].InstId)
+	}
+	// 限频, 需在添加本地订单前完成, 被限频的订单不会留在本地
+	if err := c.limiter.Acquire(c.ctx, op, instIds); err != nil {
+		// 错误提示
+		log.Printf("[错误提示] %v 请求被限频: %v", op, err)
+		// 通知错误
+		c.reportError(err)
+		// 返回
+		return nil, err
+	}
+	// 订单 ID 列表
+	clOrdIds := make([]string, 0, len(args))
+	// 逐个订单
+	for i := 0; i < len(args); i++ {
+		// 添加订单 ID
+		clOrdIds = append(clOrdIds, args[i].ClOrdId)
+	}
+	// 添加本地订单, 等待交易所返回数据后由推送替换
+	dr.AddLocalOrders(instIds, clOrdIds)
+	// 注册等待响应: 被拒绝的订单立即从订单簿删除; 连接断开未收到响应时删除仍为本地状态的订单, 已被交易所接受的订单由推送恢复
+	pending := c.addPending(op, func(resp *OpResponse) {
+		// 处理下单结果
+		dr.HandlePostResponse(clOrdIds, resp)
+	}, func(err error) {
+		// 删除本地订单
+		dr.RemoveLocalOrders(clOrdIds)
+	})
 
 	// 获取参数
 	batchOrders := &PostOrderMessage{
 		// 唯一标识
-		Id: id,
+		Id: pending.Id,
 		// 业务操作
 		Op: op,
 		// 请求参数
@@ -110,15 +136,21 @@ func (c *OkxClient) PostOrders(id, op string, args []PostOrder, dr *DataRepo) er
 	err = c.send(batchOrders)
 	// 错误提示
 	if err != nil {
+		// 取消等待
+		c.takePending(pending.Id)
+		// 订单未发出, 删除本地订单
+		dr.RemoveLocalOrders(clOrdIds)
 		// 错误提示
-		log.Fatalf("[错误提示] 挂单请求失败: %v", err)
+		log.Printf("[错误提示] 挂单请求失败: %v", err)
+		// 通知错误
+		c.reportError(err)
 		// 返回
-		return err
+		return nil, err
 	}
 	// 成功提示
 	log.Printf("[成功提示] 挂单请求成功")
 	// 返回
-	return nil
+	return pending, nil
 }
 
 // 撤单参数: 撤单, 批量撤单
@@ -156,30 +188,53 @@ func (c *OkxClient) CancelSingleOrder(instId, OrdId, clOrdId string) CancelOrder
 	return *cancelSingleOrder
 }
 
-// 批量撤单
-func (c *OkxClient) CancelOrders(id, op string, args []CancelOrder, dr *DataRepo) error {
-	// orders 上锁
-	dr.Mu.Lock()
-	// 逐个订单判定
+// 批量撤单, 返回等待交易所逐个订单确认的请求; 没有订单时不发送请求, 返回 nil
+func (c *OkxClient) CancelOrders(op string, args []CancelOrder, dr *DataRepo) (*PendingOp, error) {
+	// 没有订单
+	if len(args) == 0 {
+		// 返回
+		return nil, nil
+	}
+	// 产品 ID 列表
+	instIds := make([]string, 0, len(args))
+	// 逐个订单
+	for i := 0; i < len(args); i++ {
+		// 添加产品 ID
+		instIds = append(instIds, args[i].InstId)
+	}
+	// 限频, 被限频的请求不删除本地订单
+	if err := c.limiter.Acquire(c.ctx, op, instIds); err != nil {
+		// 错误提示
+		log.Printf("[错误提示] %v 请求被限频: %v", op, err)
+		// 通知错误
+		c.reportError(err)
+		// 返回
+		return nil, err
+	}
+	// 订单 ID 列表
+	clOrdIds := make([]string, 0, len(args))
+	// 逐个订单
 	for i := 0; i < len(args); i++ {
-		// orders 判断 Key 值是否存在
-		if val, ok := dr.OrdersData[args[i].ClOrdId]; ok {
-			// 若存在, orders 判断 Key 值是否仍然指向特殊结构体, 若是, 说明订单未成功发出, 删除 Key
-			if val.State == "local" {
-				// 说明订单未成功发出, 删除 Key
-				delete(dr.OrdersData, args[i].ClOrdId)
+		// 添加订单 ID
+		clOrdIds = append(clOrdIds, args[i].ClOrdId)
+	}
+
+	// 注册等待响应
+	pending := c.addPending(op, func(resp *OpResponse) {
+		// 逐个订单结果
+		for _, result := range resp.Data {
+			// 撤单失败, 订单可能已成交或已撤销
+			if !result.Ok() {
+				// 普通提示
+				log.Printf("[普通提示] 撤单失败 clOrdId: %v, sCode: %v, sMsg: %v", result.ClOrdId, result.SCode, result.SMsg)
 			}
-			// 若存在, 且指向非空地址, 则无需操作, 因为此时撤销订单后, 会自动删除指针
 		}
-		// 若不存在, 说明已经撤销或成交, 则无需操作
-	}
-	// orders 解锁
-	defer dr.Mu.Unlock()
+	}, nil)
 
 	// 获取参数
 	batchOrders := &CancelOrderMessage{
 		// 唯一标识
-		Id: id,
+		Id: pending.Id,
 		// 业务操作
 		Op: op,
 		// 请求参数
@@ -191,13 +246,216 @@ func (c *OkxClient) CancelOrders(id, op string, args []CancelOrder, dr *DataRepo
 	err = c.send(batchOrders)
 	// 错误提示
 	if err != nil {
+		// 取消等待
+		c.takePending(pending.Id)
 		// 错误提示
-		log.Fatalf("[错误提示] 撤单请求失败: %v", err)
+		log.Printf("[错误提示] 撤单请求失败: %v", err)
+		// 通知错误
+		c.reportError(err)
 		// 返回
-		return err
+		return nil, err
 	}
+	// 撤单请求已发出: 仍为本地状态的订单未被交易所接受, 直接删除; 已接受的订单撤销后由推送删除
+	dr.RemoveLocalOrders(clOrdIds)
 	// 成功提示
 	log.Printf("[成功提示] 撤单请求成功")
 	// 返回
+	return pending, nil
+}
+
+// 改单参数: 改单, 批量改单
+type AmendOrder struct {
+	// 产品 ID
+	InstId string `json:"instId"`
+	// 修改失败时是否自动撤单
+	CxlOnFail bool `json:"cxlOnFail"`
+	// 订单 ID
+	OrdId string `json:"ordId"`
+	// 用户提供的订单 ID
+	ClOrdId string `json:"clOrdId"`
+	// 用户提供的修改请求 ID, 不指定时为空
+	ReqId string `json:"reqId,omitempty"`
+	// 修改后的数量, 不修改时为空
+	NewSz string `json:"newSz,omitempty"`
+	// 修改后的价格, 不修改时为空
+	NewPx string `json:"newPx,omitempty"`
+}
+
+// 改单请求参数: 改单, 批量改单
+type AmendOrderMessage struct {
+	// 消息的唯一标识
+	Id string `json:"id"`
+	// 支持的业务操作
+	Op string `json:"op"`
+	// 请求参数
+	Args []AmendOrder `json:"args"`
+}
+
+// 修改订单参数
+func (c *OkxClient) AmendSingleOrder(instId, ordId, clOrdId string, newSz, newPx Decimal) AmendOrder {
+	// 改单参数设置
+	amendSingleOrder := &AmendOrder{
+		// 产品 ID
+		InstId: instId,
+		// 订单 ID
+		OrdId: ordId,
+		// 用户提供的订单 ID
+		ClOrdId: clOrdId,
+		// 修改后的数量, 空值不修改
+		NewSz: newSz.String(),
+		// 修改后的价格, 空值不修改
+		NewPx: newPx.String(),
+	}
+	// 返回参数
+	return *amendSingleOrder
+}
+
+// 批量改单, op 为 amend-order 或 batch-amend-orders; 没有订单时不发送请求, 返回 nil
+func (c *OkxClient) AmendOrders(op string, args []AmendOrder, dr *DataRepo) (*PendingOp, error) {
+	// 没有订单
+	if len(args) == 0 {
+		// 返回
+		return nil, nil
+	}
+	// 产品 ID 列表
+	instIds := make([]string, 0, len(args))
+	// 逐个订单
+	for i := 0; i < len(args); i++ {
+		// 添加产品 ID
+		instIds = append(instIds, args[i].InstId)
+	}
+	// 限频, 需在锁定数据库前完成, 排队时不阻塞数据推送
+	if err := c.limiter.Acquire(c.ctx, op, instIds); err != nil {
+		// 错误提示
+		log.Printf("[错误提示] %v 请求被限频: %v", op, err)
+		// 通知错误
+		c.reportError(err)
+		// 返回
+		return nil, err
+	}
+	// 复制参数, 不修改调用方的数据
+	args = append([]AmendOrder(nil), args...)
+	// 修改请求 ID 列表
+	reqIds := make([]string, 0, len(args))
+	// 逐个订单
+	for i := 0; i < len(args); i++ {
+		// 未指定修改请求 ID
+		if args[i].ReqId == "" {
+			// 生成唯一 ID, 用于识别订单频道推送的改单结果
+			args[i].ReqId = c.nextOpId()
+		}
+		// 添加修改请求 ID
+		reqIds = append(reqIds, args[i].ReqId)
+	}
+	// 记录发出的改单
+	dr.BeginAmends(reqIds)
+	// 注册等待响应: 修改成功的订单同步更新本地订单簿; 改单失败时订单频道推送原订单, 由数据库恢复
+	pending := c.addPending(op, func(resp *OpResponse) {
+		// 逐个订单结果, 响应顺序和数量不一定与请求参数一致
+		for _, result := range resp.Data {
+			// 改单失败
+			if !result.Ok() {
+				// 普通提示
+				log.Printf("[普通提示] 改单失败 clOrdId: %v, sCode: %v, sMsg: %v", result.ClOrdId, result.SCode, result.SMsg)
+				// 跳过
+				continue
+			}
+			// 按订单 ID 查找对应的请求参数
+			arg, ok := matchAmendArg(args, result)
+			// 结果与参数无法对应
+			if !ok {
+				// 普通提示
+				log.Printf("[普通提示] 改单结果无对应请求 clOrdId: %v, ordId: %v", result.ClOrdId, result.OrdId)
+				// 跳过
+				continue
+			}
+			// 修改后的数量, 不修改时为空值
+			newSz, _ := NewDecimal(arg.NewSz)
+			// 修改后的价格, 不修改时为空值
+			newPx, _ := NewDecimal(arg.NewPx)
+			// 更新本地订单
+			dr.AmendLocalOrder(arg.ReqId, arg.ClOrdId, arg.OrdId, newSz, newPx)
+		}
+		// 结束改单记录
+		dr.EndAmends(reqIds)
+	}, func(err error) {
+		// 结束改单记录
+		dr.EndAmends(reqIds)
+	})
+
+	// 获取参数
+	batchOrders := &AmendOrderMessage{
+		// 唯一标识
+		Id: pending.Id,
+		// 业务操作
+		Op: op,
+		// 请求参数
+		Args: args,
+	}
+	// 发起改单
+	err := c.send(batchOrders)
+	// 错误提示
+	if err != nil {
+		// 取消等待
+		c.takePending(pending.Id)
+		// 结束改单记录
+		dr.EndAmends(reqIds)
+		// 错误提示
+		log.Printf("[错误提示] 改单请求失败: %v", err)
+		// 通知错误
+		c.reportError(err)
+		// 返回
+		return nil, err
+	}
+	// 成功提示
+	log.Printf("[成功提示] 改单请求成功")
+	// 返回
+	return pending, nil
+}
+
+// 按用户订单 ID 或订单 ID 查找改单结果对应的请求参数
+func matchAmendArg(args []AmendOrder, result OpResult) (AmendOrder, bool) {
+	// 逐个参数
+	for _, arg := range args {
+		// 用户订单 ID 匹配
+		if result.ClOrdId != "" && arg.ClOrdId == result.ClOrdId {
+			// 返回
+			return arg, true
+		}
+		// 订单 ID 匹配
+		if result.OrdId != "" && arg.OrdId == result.OrdId {
+			// 返回
+			return arg, true
+		}
+	}
+	// 未找到
+	return AmendOrder{}, false
+}
+
+// 撤销数据库中全部已被交易所接受的挂单, 包括已确认但未收到推送的本地订单, instId 为空时撤销全部产品
+func (c *OkxClient) CancelAllOrders(instId string, dr *DataRepo) error {
+	// 撤单参数列表
+	var args []CancelOrder
+	// 逐个未完成订单
+	for _, val := range dr.OpenOrders() {
+		// 本地订单尚未被交易所确认, 或产品不符
+		if val.OrdId == "" || (instId != "" && val.InstId != instId) {
+			// 跳过
+			continue
+		}
+		// 添加撤单参数
+		args = append(args, c.CancelSingleOrder(val.InstId, val.OrdId, val.ClOrdId))
+	}
+	// 按批量撤单上限分批
+	for start := 0; start < len(args); start += maxBatchOrders {
+		// 本批结束位置
+		end := Min(start+maxBatchOrders, len(args))
+		// 批量撤单
+		if _, err := c.CancelOrders("batch-cancel-orders", args[start:end], dr); err != nil {
+			// 返回错误
+			return err
+		}
+	}
+	// 返回
 	return nil
 }
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// 数据传输接口: 读取, 发送, 关闭
+type Transport interface {
+	// 读取一条文本数据, 阻塞直到收到数据或连接关闭
+	ReadMessage() ([]byte, error)
+	// 发送一条文本数据
+	WriteMessage(data []byte) error
+	// 关闭连接
+	Close() error
+}
+
+// 建立连接
+type Dialer func(url string) (Transport, error)
+
+// websocket 连接
+type websocketTransport struct {
+	// gorilla websocket 连接
+	conn *websocket.Conn
+}
+
+// 建立 websocket 连接
+func DialWebsocket(url string) (Transport, error) {
+	// 不带请求头
+	return dialWebsocket(url, nil)
+}
+
+// 建立附带请求头的 websocket 连接, 如模拟盘标记
+func DialWebsocketWithHeader(header http.Header) Dialer {
+	// 返回建立方式
+	return func(url string) (Transport, error) {
+		// 带请求头
+		return dialWebsocket(url, header)
+	}
+}
+
+// 发起 websocket 连接
+func dialWebsocket(url string, header http.Header) (Transport, error) {
+	// 发起 websocket 连接
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	// 报错
+	if err != nil {
+		// 返回错误
+		return nil, err
+	}
+	// 返回连接
+	return &websocketTransport{conn: conn}, nil
+}
+
+// 读取一条数据
+func (t *websocketTransport) ReadMessage() ([]byte, error) {
+	// 读取 websocket 数据
+	_, data, err := t.conn.ReadMessage()
+	// 返回
+	return data, err
+}
+
+// 发送一条文本数据
+func (t *websocketTransport) WriteMessage(data []byte) error {
+	// 发送数据
+	return t.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// 关闭连接
+func (t *websocketTransport) Close() error {
+	// 关闭连接
+	return t.conn.Close()
+}
@@ -0,0 +1,30 @@
+package protocol
+
+// 事件推送信息: 登录, 订阅, 取消订阅, 错误
+type EventMessage struct {
+	// 事件类型: login, subscribe, unsubscribe, error
+	Event string `json:"event"`
+	// 错误码, 成功时为空或 0
+	Code string `json:"code"`
+	// 错误消息
+	Msg string `json:"msg"`
+	// 订阅频道参数
+	Arg struct {
+		// 频道名
+		Channel string `json:"channel"`
+		// 产品类型
+		InstType string `json:"instType"`
+		// 标的指数
+		Uly string `json:"uly"`
+		// 产品 ID
+		InstID string `json:"instId"`
+	} `json:"arg"`
+	// 连接 ID
+	ConnID string `json:"connId"`
+}
+
+// 事件是否为错误
+func (em *EventMessage) IsError() bool {
+	// 返回结果
+	return em.Event == "error" || (em.Code != "" && em.Code != "0")
+}
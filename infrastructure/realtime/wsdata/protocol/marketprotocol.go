@@ -0,0 +1,381 @@
+package protocol
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"strings"
+)
+
+// 校验和使用的深度档位数
+const checksumLevels = 25
+
+// 行情频道参数
+type MarketArg struct {
+	// 频道名
+	Channel string `json:"channel"`
+	// 产品类型
+	InstType string `json:"instType"`
+	// 产品 ID
+	InstID string `json:"instId"`
+}
+
+// 行情数据
+type Ticker struct {
+	// 产品类型
+	InstType string `json:"instType"`
+	// 产品 ID
+	InstID string `json:"instId"`
+	// 最新成交价
+	Last string `json:"last"`
+	// 最新成交的数量
+	LastSz string `json:"lastSz"`
+	// 卖一价
+	AskPx string `json:"askPx"`
+	// 卖一价对应的数量
+	AskSz string `json:"askSz"`
+	// 买一价
+	BidPx string `json:"bidPx"`
+	// 买一价对应的数量
+	BidSz string `json:"bidSz"`
+	// 24 小时开盘价
+	Open24h string `json:"open24h"`
+	// 24 小时最高价
+	High24h string `json:"high24h"`
+	// 24 小时最低价
+	Low24h string `json:"low24h"`
+	// UTC 0 时开盘价
+	SodUtc0 string `json:"sodUtc0"`
+	// UTC+8 时开盘价
+	SodUtc8 string `json:"sodUtc8"`
+	// 24 小时成交量, 以币为单位
+	VolCcy24h string `json:"volCcy24h"`
+	// 24 小时成交量, 以张为单位
+	Vol24h string `json:"vol24h"`
+	// 数据产生时间
+	Ts string `json:"ts"`
+}
+
+// 行情数据推送信息
+type TickerMessage struct {
+	// 频道参数
+	Arg MarketArg `json:"arg"`
+	// 行情数据
+	Data []Ticker `json:"data"`
+}
+
+// 解析行情数据
+func (tm *TickerMessage) ChannelAndInstID() (string, string) {
+	// 返回频道名称, 产品 ID
+	return tm.Arg.Channel, tm.Arg.InstID
+}
+
+// 由帧头和原始推送数据解析行情数据
+func (tm *TickerMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和行情数据
+	return decodeFrame(arg, data, &tm.Arg, &tm.Data)
+}
+
+// 深度数据: books, books50-l2-tbt, bbo-tbt
+type Book struct {
+	// 卖方深度: [价格, 数量, 已弃用, 订单数]
+	Asks [][]string `json:"asks"`
+	// 买方深度: [价格, 数量, 已弃用, 订单数]
+	Bids [][]string `json:"bids"`
+	// 时间戳
+	Ts string `json:"ts"`
+	// 校验和
+	Checksum int32 `json:"checksum"`
+	// 上一次推送的序列号, 全量推送为 -1
+	PrevSeqId int64 `json:"prevSeqId"`
+	// 序列号
+	SeqId int64 `json:"seqId"`
+}
+
+// 深度数据推送信息
+type BookMessage struct {
+	// 频道参数
+	Arg MarketArg `json:"arg"`
+	// 增量 or 全量推送数据, bbo-tbt 为空, 每次均为全量
+	Action string `json:"action"`
+	// 深度数据
+	Data []Book `json:"data"`
+}
+
+// 解析深度数据
+func (bm *BookMessage) ChannelAndInstID() (string, string) {
+	// 返回频道名称, 产品 ID
+	return bm.Arg.Channel, bm.Arg.InstID
+}
+
+// 由帧头和原始推送数据解析深度数据
+func (bm *BookMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 增量 or 全量推送
+	bm.Action = action
+	// 解析频道参数和深度数据
+	return decodeFrame(arg, data, &bm.Arg, &bm.Data)
+}
+
+// 标记价格
+type MarkPrice struct {
+	// 产品类型
+	InstType string `json:"instType"`
+	// 产品 ID
+	InstID string `json:"instId"`
+	// 标记价格
+	MarkPx string `json:"markPx"`
+	// 数据产生时间
+	Ts string `json:"ts"`
+}
+
+// 标记价格推送信息
+type MarkPriceMessage struct {
+	// 频道参数
+	Arg MarketArg `json:"arg"`
+	// 标记价格
+	Data []MarkPrice `json:"data"`
+}
+
+// 解析标记价格
+func (mm *MarkPriceMessage) ChannelAndInstID() (string, string) {
+	// 返回频道名称, 产品 ID
+	return mm.Arg.Channel, mm.Arg.InstID
+}
+
+// 由帧头和原始推送数据解析标记价格
+func (mm *MarkPriceMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和标记价格
+	return decodeFrame(arg, data, &mm.Arg, &mm.Data)
+}
+
+// 指数行情
+type IndexTicker struct {
+	// 指数
+	InstID string `json:"instId"`
+	// 最新指数价格
+	IdxPx string `json:"idxPx"`
+	// 24 小时开盘价
+	Open24h string `json:"open24h"`
+	// 24 小时指数最高价格
+	High24h string `json:"high24h"`
+	// 24 小时指数最低价格
+	Low24h string `json:"low24h"`
+	// UTC 0 时开盘价
+	SodUtc0 string `json:"sodUtc0"`
+	// UTC+8 时开盘价
+	SodUtc8 string `json:"sodUtc8"`
+	// 数据产生时间
+	Ts string `json:"ts"`
+}
+
+// 指数行情推送信息
+type IndexTickerMessage struct {
+	// 频道参数
+	Arg MarketArg `json:"arg"`
+	// 指数行情
+	Data []IndexTicker `json:"data"`
+}
+
+// 解析指数行情
+func (im *IndexTickerMessage) ChannelAndInstID() (string, string) {
+	// 返回频道名称, 产品 ID
+	return im.Arg.Channel, im.Arg.InstID
+}
+
+// 由帧头和原始推送数据解析指数行情
+func (im *IndexTickerMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和指数行情
+	return decodeFrame(arg, data, &im.Arg, &im.Data)
+}
+
+// 资金费率
+type FundingRate struct {
+	// 产品类型
+	InstType string `json:"instType"`
+	// 产品 ID
+	InstID string `json:"instId"`
+	// 当期资金费率
+	FundingRate string `json:"fundingRate"`
+	// 当期资金费时间
+	FundingTime string `json:"fundingTime"`
+	// 下一期预测资金费率
+	NextFundingRate string `json:"nextFundingRate"`
+	// 下一期资金费时间
+	NextFundingTime string `json:"nextFundingTime"`
+}
+
+// 资金费率推送信息
+type FundingRateMessage struct {
+	// 频道参数
+	Arg MarketArg `json:"arg"`
+	// 资金费率
+	Data []FundingRate `json:"data"`
+}
+
+// 解析资金费率
+func (fm *FundingRateMessage) ChannelAndInstID() (string, string) {
+	// 返回频道名称, 产品 ID
+	return fm.Arg.Channel, fm.Arg.InstID
+}
+
+// 由帧头和原始推送数据解析资金费率
+func (fm *FundingRateMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和资金费率
+	return decodeFrame(arg, data, &fm.Arg, &fm.Data)
+}
+
+// 持仓总量
+type OpenInterest struct {
+	// 产品类型
+	InstType string `json:"instType"`
+	// 产品 ID
+	InstID string `json:"instId"`
+	// 持仓量, 以张为单位
+	Oi string `json:"oi"`
+	// 持仓量, 以币为单位
+	OiCcy string `json:"oiCcy"`
+	// 数据更新的时间
+	Ts string `json:"ts"`
+}
+
+// 持仓总量推送信息
+type OpenInterestMessage struct {
+	// 频道参数
+	Arg MarketArg `json:"arg"`
+	// 持仓总量
+	Data []OpenInterest `json:"data"`
+}
+
+// 解析持仓总量
+func (om *OpenInterestMessage) ChannelAndInstID() (string, string) {
+	// 返回频道名称, 产品 ID
+	return om.Arg.Channel, om.Arg.InstID
+}
+
+// 由帧头和原始推送数据解析持仓总量
+func (om *OpenInterestMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和持仓总量
+	return decodeFrame(arg, data, &om.Arg, &om.Data)
+}
+
+// 限价
+type PriceLimit struct {
+	// 产品 ID
+	InstID string `json:"instId"`
+	// 最高买价
+	BuyLmt string `json:"buyLmt"`
+	// 最低卖价
+	SellLmt string `json:"sellLmt"`
+	// 限价数据更新时间
+	Ts string `json:"ts"`
+}
+
+// 限价推送信息
+type PriceLimitMessage struct {
+	// 频道参数
+	Arg MarketArg `json:"arg"`
+	// 限价
+	Data []PriceLimit `json:"data"`
+}
+
+// 解析限价
+func (pm *PriceLimitMessage) ChannelAndInstID() (string, string) {
+	// 返回频道名称, 产品 ID
+	return pm.Arg.Channel, pm.Arg.InstID
+}
+
+// 由帧头和原始推送数据解析限价
+func (pm *PriceLimitMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和限价
+	return decodeFrame(arg, data, &pm.Arg, &pm.Data)
+}
+
+// 强平订单明细
+type LiquidationDetail struct {
+	// 订单方向
+	Side string `json:"side"`
+	// 持仓方向
+	PosSide string `json:"posSide"`
+	// 破产价格
+	BkPx string `json:"bkPx"`
+	// 强平数量
+	Sz string `json:"sz"`
+	// 穿仓亏损数量
+	BkLoss string `json:"bkLoss"`
+	// 强平币种, 仅适用于币币杠杆
+	Ccy string `json:"ccy"`
+	// 强平发生的时间
+	Ts string `json:"ts"`
+}
+
+// 强平订单
+type Liquidation struct {
+	// 产品类型
+	InstType string `json:"instType"`
+	// 产品 ID
+	InstID string `json:"instId"`
+	// 标的指数
+	Uly string `json:"uly"`
+	// 强平订单明细
+	Details []LiquidationDetail `json:"details"`
+}
+
+// 强平订单推送信息
+type LiquidationMessage struct {
+	// 频道参数
+	Arg MarketArg `json:"arg"`
+	// 强平订单
+	Data []Liquidation `json:"data"`
+}
+
+// 解析强平订单, 按产品类型订阅, 产品 ID 为空
+func (lm *LiquidationMessage) ChannelAndInstID() (string, string) {
+	// 返回频道名称, 产品 ID
+	return lm.Arg.Channel, lm.Arg.InstID
+}
+
+// 由帧头和原始推送数据解析强平订单
+func (lm *LiquidationMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和强平订单
+	return decodeFrame(arg, data, &lm.Arg, &lm.Data)
+}
+
+// 产品信息推送: 首次订阅推送全部产品, 之后推送变化的产品
+type InstrumentsMessage struct {
+	// 频道参数
+	Arg MarketArg `json:"arg"`
+	// 产品信息
+	Data []Instrument `json:"data"`
+}
+
+// 解析产品信息, 按产品类型订阅, 产品 ID 为空
+func (im *InstrumentsMessage) ChannelAndInstID() (string, string) {
+	// 返回频道名称, 产品 ID
+	return im.Arg.Channel, im.Arg.InstID
+}
+
+// 由帧头和原始推送数据解析产品信息
+func (im *InstrumentsMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和产品信息
+	return decodeFrame(arg, data, &im.Arg, &im.Data)
+}
+
+// 深度校验和: 前 25 档买卖交替拼接 价格:数量, CRC32 结果按有符号 32 位整数
+func BookChecksum(bids, asks [][]string) int32 {
+	// 拼接内容
+	parts := make([]string, 0, checksumLevels*4)
+	// 逐档
+	for i := 0; i < checksumLevels; i++ {
+		// 买方档位
+		if i < len(bids) && len(bids[i]) >= 2 {
+			// 价格:数量
+			parts = append(parts, bids[i][0], bids[i][1])
+		}
+		// 卖方档位
+		if i < len(asks) && len(asks[i]) >= 2 {
+			// 价格:数量
+			parts = append(parts, asks[i][0], asks[i][1])
+		}
+	}
+	// CRC32
+	return int32(crc32.ChecksumIEEE([]byte(strings.Join(parts, ":"))))
+}
@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"hash/crc32"
+	"strconv"
+	"testing"
+)
+
+// 字符串的有符号 CRC32
+func signedCRC(s string) int32 {
+	// 返回
+	return int32(crc32.ChecksumIEEE([]byte(s)))
+}
+
+func TestBookChecksumDocExamples(t *testing.T) {
+	tests := []struct {
+		name       string
+		bids, asks [][]string
+		joined     string
+		want       int32
+	}{
+		{
+			// 文档示例: 买卖档位数相同, 买卖交替拼接
+			name:   "等长",
+			bids:   [][]string{{"3366.1", "7", "0", "3"}, {"3366", "6", "3", "4"}},
+			asks:   [][]string{{"3366.8", "9", "10", "3"}, {"3368", "8", "3", "4"}},
+			joined: "3366.1:7:3366.8:9:3366:6:3368:8",
+			want:   -1881014294,
+		},
+		{
+			// 文档示例: 买方档位不足时只拼接卖方
+			name:   "买方档位不足",
+			bids:   [][]string{{"3366.1", "7", "0", "3"}},
+			asks:   [][]string{{"3366.8", "9", "10", "3"}, {"3368", "8", "3", "4"}, {"3372", "8", "3", "4"}},
+			joined: "3366.1:7:3366.8:9:3368:8:3372:8",
+			want:   831078360,
+		},
+	}
+	for _, tt := range tests {
+		if got := signedCRC(tt.joined); got != tt.want {
+			t.Fatalf("%v: 示例字符串 CRC32 = %v, 期望 %v", tt.name, got, tt.want)
+		}
+		if got := BookChecksum(tt.bids, tt.asks); got != tt.want {
+			t.Errorf("%v: BookChecksum = %v, 期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBookChecksumKeepsOriginalStrings(t *testing.T) {
+	// 价格和数量按推送的原始字符串拼接, 不做格式化
+	bids := [][]string{{"0.10", "1.500"}}
+	asks := [][]string{{"0.11", "2"}}
+	if got, want := BookChecksum(bids, asks), signedCRC("0.10:1.500:0.11:2"); got != want {
+		t.Errorf("BookChecksum = %v, 期望 %v", got, want)
+	}
+}
+
+// n 档深度, 价格从 start 开始按 step 变化
+func levels(n int, start, step int) [][]string {
+	// 深度
+	out := make([][]string, n)
+	// 逐档
+	for i := range out {
+		// 价格, 数量
+		out[i] = []string{strconv.Itoa(start + i*step), strconv.Itoa(i + 1)}
+	}
+	// 返回
+	return out
+}
+
+func TestBookChecksumTruncatesTo25Levels(t *testing.T) {
+	bids := levels(30, 1000, -1)
+	asks := levels(30, 1001, 1)
+	// 只使用前 25 档
+	if got, want := BookChecksum(bids, asks), BookChecksum(bids[:25], asks[:25]); got != want {
+		t.Errorf("超过 25 档的深度参与了校验: %v != %v", got, want)
+	}
+	// 第 26 档变化不影响校验和
+	changed := append([][]string(nil), bids...)
+	changed[25] = []string{"1", "999"}
+	if BookChecksum(changed, asks) != BookChecksum(bids, asks) {
+		t.Error("第 26 档变化影响了校验和")
+	}
+	// 第 25 档变化影响校验和
+	changed[24] = []string{"1", "999"}
+	if BookChecksum(changed, asks) == BookChecksum(bids, asks) {
+		t.Error("第 25 档变化未影响校验和")
+	}
+}
+
+func TestBookChecksumSkipsIncompleteLevels(t *testing.T) {
+	// 不完整的档位跳过
+	bids := [][]string{{"10", "1"}, {"9"}}
+	asks := [][]string{{"11", "2"}}
+	if got, want := BookChecksum(bids, asks), signedCRC("10:1:11:2"); got != want {
+		t.Errorf("BookChecksum = %v, 期望 %v", got, want)
+	}
+}
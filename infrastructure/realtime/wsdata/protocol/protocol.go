@@ -1,11 +1,43 @@
 package protocol
 
+import (
+	"encoding/json"
+
+	. "github.com/wiger123/okex_v5_golang/utils"
+)
+
 // 所有 websocket 推送信息接口
 type PushMessage interface {
 	// 定义接口
 	ChannelAndInstID() (string, string)
 }
 
+// 帧解析接口: 由已拆分的帧头和原始推送数据构建推送信息, 每帧只需解析一次
+// 未实现该接口的推送信息按整帧解析
+type FrameDecoder interface {
+	// 解析频道参数, 推送方式和推送数据
+	DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error
+}
+
+// 解析频道参数和推送数据, 缺失的部分保持零值
+func decodeFrame(arg, data json.RawMessage, argOut, dataOut interface{}) error {
+	// 有频道参数
+	if len(arg) > 0 {
+		// 解析频道参数
+		if err := json.Unmarshal(arg, argOut); err != nil {
+			// 返回错误
+			return err
+		}
+	}
+	// 没有推送数据
+	if len(data) == 0 {
+		// 返回
+		return nil
+	}
+	// 解析推送数据
+	return json.Unmarshal(data, dataOut)
+}
+
 // 交易数据
 type Trade struct {
 	// 产品 ID
@@ -13,9 +45,9 @@ type Trade struct {
 	// 成交 ID
 	TradeID string `json:"tradeId"`
 	// 成交价格
-	Px string `json:"px"`
+	Px Decimal `json:"px"`
 	// 成交数量
-	Sz string `json:"sz"`
+	Sz Decimal `json:"sz"`
 	// 成交方向
 	Side string `json:"side"`
 	// 成交时间
@@ -41,12 +73,18 @@ func (tm *TradeMessage) ChannelAndInstID() (string, string) {
 	return tm.Arg.Channel, tm.Arg.InstID
 }
 
+// 由帧头和原始推送数据解析交易数据
+func (tm *TradeMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和交易数据
+	return decodeFrame(arg, data, &tm.Arg, &tm.Data)
+}
+
 // 盘口数据
 type Book5 struct {
 	// 卖方深度
-	Asks [][]string `json:"asks"`
+	Asks [][]Decimal `json:"asks"`
 	// 买方深度
-	Bids [][]string `json:"bids"`
+	Bids [][]Decimal `json:"bids"`
 	// 时间戳
 	Ts string `json:"ts"`
 	// 校验和
@@ -74,6 +112,14 @@ func (bm *Book5Message) ChannelAndInstID() (string, string) {
 	return bm.Arg.Channel, bm.Arg.InstID
 }
 
+// 由帧头和原始推送数据解析盘口数据
+func (bm *Book5Message) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 增量 or 全量推送
+	bm.Action = action
+	// 解析频道参数和盘口数据
+	return decodeFrame(arg, data, &bm.Arg, &bm.Data)
+}
+
 // 账户资产详情
 type AccountDetails struct {
 	// 币种
@@ -171,6 +217,12 @@ func (bm *AccountMessage) ChannelAndInstID() (string, string) {
 	return bm.Arg.Channel, ""
 }
 
+// 由帧头和原始推送数据解析账户数据
+func (bm *AccountMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和账户数据
+	return decodeFrame(arg, data, &bm.Arg, &bm.Data)
+}
+
 // 持仓数据
 type Positions struct {
 	// 产品类型
@@ -182,57 +234,57 @@ type Positions struct {
 	// 持仓方向
 	PosSide string `json:"posSide"`
 	// 持仓数量
-	Pos string `json:"pos"`
+	Pos Decimal `json:"pos"`
 	// 交易币余额
-	BaseBal string `json:"baseBal"`
+	BaseBal Decimal `json:"baseBal"`
 	// 计价币余额
-	QuoteBal string `json:"quoteBal"`
+	QuoteBal Decimal `json:"quoteBal"`
 	// 持仓数量币种
 	PosCcy string `json:"posCcy"`
 	// 可平仓数量
-	AvailPos string `json:"availPos"`
+	AvailPos Decimal `json:"availPos"`
 	// 开仓平均价
-	AvgPx string `json:"avgPx"`
+	AvgPx Decimal `json:"avgPx"`
 	// 未实现收益
-	Upl string `json:"upl"`
+	Upl Decimal `json:"upl"`
 	// 未实现收益率
-	UplRatio string `json:"uplRatio"`
+	UplRatio Decimal `json:"uplRatio"`
 	// 产品 ID
 	InstId string `json:"instId"`
 	// 杠杆倍数
-	Lever string `json:"lever"`
+	Lever Decimal `json:"lever"`
 	// 预估强平价
-	LiqPx string `json:"liqPx"`
+	LiqPx Decimal `json:"liqPx"`
 	// 标记价格
-	MarkPx string `json:"markPx"`
+	MarkPx Decimal `json:"markPx"`
 	// 初始保证金
-	Imr string `json:"imr"`
+	Imr Decimal `json:"imr"`
 	// 保证金余额
-	Margin string `json:"margin"`
+	Margin Decimal `json:"margin"`
 	// 保证金率
-	MgnRatio string `json:"mgnRatio"`
+	MgnRatio Decimal `json:"mgnRatio"`
 	// 维持保证金
-	Mmr string `json:"mmr"`
+	Mmr Decimal `json:"mmr"`
 	// 负债额
-	Liab string `json:"liab"`
+	Liab Decimal `json:"liab"`
 	// 负债币种
 	LiabCcy string `json:"liabCcy"`
 	// 利息
-	Interest string `json:"interest"`
+	Interest Decimal `json:"interest"`
 	// 最新成交 ID
 	TradeId string `json:"tradeId"`
 	// 以美金价值为单位的持仓数量
-	NotionalUsd string `json:"notionalUsd"`
+	NotionalUsd Decimal `json:"notionalUsd"`
 	// 期权价值
-	OptVal string `json:"optVal"`
+	OptVal Decimal `json:"optVal"`
 	// 信号区
 	Adl string `json:"adl"`
 	// 占用保证金的币种
 	Ccy string `json:"ccy"`
 	// 最新成交价
-	Last string `json:"last"`
+	Last Decimal `json:"last"`
 	// 美金价格
-	UsdPx string `json:"usdPx"`
+	UsdPx Decimal `json:"usdPx"`
 	// 美金本位持仓仓位 delta
 	DeltaBS string `json:"deltaBS"`
 	//  币本位持仓仓位 delta
@@ -282,26 +334,32 @@ func (pm *PositionsMessage) ChannelAndInstID() (string, string) {
 	return pm.Arg.Channel, pm.Arg.InstId
 }
 
+// 由帧头和原始推送数据解析持仓数据
+func (pm *PositionsMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和持仓数据
+	return decodeFrame(arg, data, &pm.Arg, &pm.Data)
+}
+
 // 多仓空仓数据
 type PositionsLong struct {
 	// 持仓方向
 	PosSide string `json:"posSide"`
 	// 持仓数量
-	Pos string `json:"pos"`
+	Pos Decimal `json:"pos"`
 	// 可平仓数量
-	AvailPos string `json:"availPos"`
+	AvailPos Decimal `json:"availPos"`
 	// 开仓平均价
-	AvgPx string `json:"avgPx"`
+	AvgPx Decimal `json:"avgPx"`
 	// 未实现收益
-	Upl string `json:"upl"`
+	Upl Decimal `json:"upl"`
 	// 未实现收益率
-	UplRatio string `json:"uplRatio"`
+	UplRatio Decimal `json:"uplRatio"`
 	// 杠杆倍数
-	Lever string `json:"lever"`
+	Lever Decimal `json:"lever"`
 	// 预估强平价
-	LiqPx string `json:"liqPx"`
+	LiqPx Decimal `json:"liqPx"`
 	// 标记价格
-	MarkPx string `json:"markPx"`
+	MarkPx Decimal `json:"markPx"`
 }
 
 // 多仓空仓数据
@@ -309,21 +367,21 @@ type PositionsShort struct {
 	// 持仓方向
 	PosSide string `json:"posSide"`
 	// 持仓数量
-	Pos string `json:"pos"`
+	Pos Decimal `json:"pos"`
 	// 可平仓数量
-	AvailPos string `json:"availPos"`
+	AvailPos Decimal `json:"availPos"`
 	// 开仓平均价
-	AvgPx string `json:"avgPx"`
+	AvgPx Decimal `json:"avgPx"`
 	// 未实现收益
-	Upl string `json:"upl"`
+	Upl Decimal `json:"upl"`
 	// 未实现收益率
-	UplRatio string `json:"uplRatio"`
+	UplRatio Decimal `json:"uplRatio"`
 	// 杠杆倍数
-	Lever string `json:"lever"`
+	Lever Decimal `json:"lever"`
 	// 预估强平价
-	LiqPx string `json:"liqPx"`
+	LiqPx Decimal `json:"liqPx"`
 	// 标记价格
-	MarkPx string `json:"markPx"`
+	MarkPx Decimal `json:"markPx"`
 }
 
 // 订单数据
@@ -341,11 +399,11 @@ type Orders struct {
 	// 订单标签
 	Tag string `json:"tag"`
 	// 委托价格
-	Px string `json:"px"`
+	Px Decimal `json:"px"`
 	// 原始委托数量
-	Sz string `json:"sz"`
+	Sz Decimal `json:"sz"`
 	// 委托单预估美元价值
-	NotionalUsd string `json:"notionalUsd"`
+	NotionalUsd Decimal `json:"notionalUsd"`
 	// 订单类型
 	OrdType string `json:"ordType"`
 	// 订单方向
@@ -357,51 +415,51 @@ type Orders struct {
 	// 市价单委托数量的类型
 	TgtCcy string `json:"tgtCcy"`
 	// 最新成交价格
-	FillPx string `json:"fillPx"`
+	FillPx Decimal `json:"fillPx"`
 	// 最新成交 ID
 	TradeId string `json:"tradeId"`
 	// 最新成交数量
-	FillSz string `json:"fillSz"`
+	FillSz Decimal `json:"fillSz"`
 	// 最新成交时间
 	FillTime string `json:"fillTime"`
 	// 最新一笔成交的手续费
-	FillFee string `json:"fillFee"`
+	FillFee Decimal `json:"fillFee"`
 	// 最新一笔成交的手续费币种
 	FillFeeCcy string `json:"fillFeeCcy"`
 	// 最新一笔成交的流动性方向
 	ExecType string `json:"execType"`
 	// 累计成交数量
-	AccFillSz string `json:"accFillSz"`
+	AccFillSz Decimal `json:"accFillSz"`
 	// 委托单已成交的美元价值
-	FillNotionalUsd string `json:"fillNotionalUsd"`
+	FillNotionalUsd Decimal `json:"fillNotionalUsd"`
 	// 成交均价
-	AvgPx string `json:"avgPx"`
+	AvgPx Decimal `json:"avgPx"`
 	// 订单状态
 	State string `json:"state"`
 	// 杠杆倍数
-	Lever string `json:"lever"`
+	Lever Decimal `json:"lever"`
 	// 止盈触发价
-	TpTriggerPx string `json:"tpTriggerPx"`
+	TpTriggerPx Decimal `json:"tpTriggerPx"`
 	// 止盈触发价类型
 	TpTriggerPxType string `json:"tpTriggerPxType"`
 	// 止盈委托价
-	TpOrdPx string `json:"tpOrdPx"`
+	TpOrdPx Decimal `json:"tpOrdPx"`
 	// 止损触发价
-	SlTriggerPx string `json:"slTriggerPx"`
+	SlTriggerPx Decimal `json:"slTriggerPx"`
 	// 止损触发价类型
 	SlTriggerPxType string `json:"slTriggerPxType"`
 	// 止损委托价
-	SlOrdPx string `json:"slOrdPx"`
+	SlOrdPx Decimal `json:"slOrdPx"`
 	// 交易手续费币种
 	FeeCcy string `json:"feeCcy"`
 	// 订单交易手续费
-	Fee string `json:"fee"`
+	Fee Decimal `json:"fee"`
 	// 返佣金币种
 	RebateCcy string `json:"rebateCcy"`
 	// 返佣金额
-	Rebate string `json:"rebate"`
+	Rebate Decimal `json:"rebate"`
 	// 收益
-	Pnl string `json:"pnl"`
+	Pnl Decimal `json:"pnl"`
 	// 订单来源
 	Source string `json:"source"`
 	// 订单种类分类
@@ -420,6 +478,12 @@ type Orders struct {
 	Msg string `json:"msg"`
 }
 
+// 改单是否失败: -1 失败, 1 修改失败后自动撤单
+func (o *Orders) AmendFailed() bool {
+	// 返回结果
+	return o.AmendResult == "-1" || o.AmendResult == "1"
+}
+
 // 订单信息
 type OrdersMessage struct {
 	// 请求订阅的频道列表
@@ -444,3 +508,9 @@ func (om *OrdersMessage) ChannelAndInstID() (string, string) {
 	// 返回频道名称, 产品 ID
 	return om.Arg.Channel, om.Arg.InstId
 }
+
+// 由帧头和原始推送数据解析订单数据
+func (om *OrdersMessage) DecodeFrame(arg json.RawMessage, action string, data json.RawMessage) error {
+	// 解析频道参数和订单数据
+	return decodeFrame(arg, data, &om.Arg, &om.Data)
+}
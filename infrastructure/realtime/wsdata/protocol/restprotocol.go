@@ -0,0 +1,326 @@
+package protocol
+
+import (
+	"fmt"
+
+	. "github.com/wiger123/okex_v5_golang/utils"
+)
+
+// 产品基础信息
+type Instrument struct {
+	// 产品类型
+	InstType string `json:"instType"`
+	// 产品 ID
+	InstId string `json:"instId"`
+	// 标的指数
+	Uly string `json:"uly"`
+	// 交易品种
+	InstFamily string `json:"instFamily"`
+	// 交易货币币种
+	BaseCcy string `json:"baseCcy"`
+	// 计价货币币种
+	QuoteCcy string `json:"quoteCcy"`
+	// 盈亏结算和保证金币种
+	SettleCcy string `json:"settleCcy"`
+	// 合约面值
+	CtVal Decimal `json:"ctVal"`
+	// 合约乘数
+	CtMult Decimal `json:"ctMult"`
+	// 合约面值计价币种
+	CtValCcy string `json:"ctValCcy"`
+	// 合约类型: linear 正向, inverse 反向
+	CtType string `json:"ctType"`
+	// 上线日期
+	ListTime string `json:"listTime"`
+	// 交割日期
+	ExpTime string `json:"expTime"`
+	// 最大杠杆倍数
+	Lever string `json:"lever"`
+	// 下单价格精度
+	TickSz Decimal `json:"tickSz"`
+	// 下单数量精度
+	LotSz Decimal `json:"lotSz"`
+	// 最小下单数量
+	MinSz Decimal `json:"minSz"`
+	// 产品状态
+	State string `json:"state"`
+}
+
+// 委托价按下单价格精度取整: 买单向下, 卖单向上, 不会比原价格更差
+func (i *Instrument) SnapPx(side string, px Decimal) Decimal {
+	// 卖单
+	if side == "sell" {
+		// 向上取整
+		return px.CeilTo(i.TickSz)
+	}
+	// 买单向下取整
+	return px.FloorTo(i.TickSz)
+}
+
+// 数量按下单数量精度向下取整, 不会超过原数量
+func (i *Instrument) SnapSz(sz Decimal) Decimal {
+	// 向下取整
+	return sz.FloorTo(i.LotSz)
+}
+
+// 校验委托价: 正数且为下单价格精度的整数倍
+func (i *Instrument) ValidatePx(px Decimal) error {
+	// 非正数
+	if px.Sign() <= 0 {
+		// 返回错误
+		return fmt.Errorf("%v 委托价必须为正数: %v", i.InstId, px)
+	}
+	// 价格精度
+	if i.TickSz.Sign() > 0 && !px.IsMultipleOf(i.TickSz) {
+		// 返回错误
+		return fmt.Errorf("%v 委托价 %v 不是 tickSz %v 的整数倍", i.InstId, px, i.TickSz)
+	}
+	// 校验通过
+	return nil
+}
+
+// 校验数量: 不小于最小下单数量且为下单数量精度的整数倍
+func (i *Instrument) ValidateSz(sz Decimal) error {
+	// 非正数
+	if sz.Sign() <= 0 {
+		// 返回错误
+		return fmt.Errorf("%v 数量必须为正数: %v", i.InstId, sz)
+	}
+	// 最小下单数量
+	if sz.LessThan(i.MinSz) {
+		// 返回错误
+		return fmt.Errorf("%v 数量 %v 小于 minSz %v", i.InstId, sz, i.MinSz)
+	}
+	// 数量精度
+	if i.LotSz.Sign() > 0 && !sz.IsMultipleOf(i.LotSz) {
+		// 返回错误
+		return fmt.Errorf("%v 数量 %v 不是 lotSz %v 的整数倍", i.InstId, sz, i.LotSz)
+	}
+	// 校验通过
+	return nil
+}
+
+// 换算结果保留的小数位数, 下单前再按 lotSz 取整
+const convPlaces = 12
+
+// 换算除法, 保留 convPlaces 位小数; 除数为 0 时返回空值
+func div(a, b Decimal) Decimal {
+	// 除法
+	q, err := a.Div(b, convPlaces)
+	// 除数为 0
+	if err != nil {
+		// 返回空值
+		return Decimal{}
+	}
+	// 返回
+	return q
+}
+
+// 是否合约产品: 有合约面值
+func (i *Instrument) IsContract() bool {
+	// 返回
+	return i.CtVal.Sign() > 0
+}
+
+// 是否反向合约: 面值以美元计, 盈亏和保证金以币结算
+func (i *Instrument) IsInverse() bool {
+	// 返回
+	return i.CtType == "inverse"
+}
+
+// 每张合约面值: ctVal * ctMult, 正向合约单位为币, 反向合约单位为美元
+func (i *Instrument) ContractFace() Decimal {
+	// 未设置合约乘数
+	if i.CtMult.Sign() <= 0 {
+		// 返回
+		return i.CtVal
+	}
+	// 返回
+	return i.CtVal.Mul(i.CtMult)
+}
+
+// 下单数量换算为币数量: 币币为原数量, 合约按面值和价格换算; 价格无效时返回空值
+func (i *Instrument) SzToCoin(sz, px Decimal) Decimal {
+	// 币币
+	if !i.IsContract() {
+		// 返回
+		return sz
+	}
+	// 正向合约
+	if !i.IsInverse() {
+		// 张数 * 面值
+		return sz.Mul(i.ContractFace())
+	}
+	// 价格无效
+	if px.Sign() <= 0 {
+		// 返回空值
+		return Decimal{}
+	}
+	// 张数 * 面值 / 价格
+	return div(sz.Mul(i.ContractFace()), px)
+}
+
+// 币数量换算为下单数量, 未按 lotSz 取整; 价格或面值无效时返回空值
+func (i *Instrument) CoinToSz(coin, px Decimal) Decimal {
+	// 币币
+	if !i.IsContract() {
+		// 返回
+		return coin
+	}
+	// 正向合约
+	if !i.IsInverse() {
+		// 币数量 / 面值
+		return div(coin, i.ContractFace())
+	}
+	// 价格无效
+	if px.Sign() <= 0 {
+		// 返回空值
+		return Decimal{}
+	}
+	// 币数量 * 价格 / 面值
+	return div(coin.Mul(px), i.ContractFace())
+}
+
+// 下单数量换算为计价货币金额 (USDT / USD)
+func (i *Instrument) SzToQuote(sz, px Decimal) Decimal {
+	// 反向合约
+	if i.IsContract() && i.IsInverse() {
+		// 张数 * 面值
+		return sz.Mul(i.ContractFace())
+	}
+	// 币数量 * 价格
+	return i.SzToCoin(sz, px).Mul(px)
+}
+
+// 计价货币金额换算为下单数量, 未按 lotSz 取整; 价格或面值无效时返回空值
+func (i *Instrument) QuoteToSz(quote, px Decimal) Decimal {
+	// 反向合约
+	if i.IsContract() && i.IsInverse() {
+		// 金额 / 面值
+		return div(quote, i.ContractFace())
+	}
+	// 价格无效
+	if px.Sign() <= 0 {
+		// 返回空值
+		return Decimal{}
+	}
+	// 金额 / 价格 换算为币数量
+	return i.CoinToSz(div(quote, px), px)
+}
+
+// 持仓盈亏: 正向合约和币币以计价货币计, 反向合约以币计; 空仓方向为 short, 买卖模式按 sz 正负
+func (i *Instrument) Pnl(posSide string, sz, avgPx, px Decimal) Decimal {
+	// 价格无效
+	if avgPx.Sign() <= 0 || px.Sign() <= 0 {
+		// 返回空值
+		return Decimal{}
+	}
+	// 价差
+	diff := px.Sub(avgPx)
+	// 空仓
+	if posSide == "short" {
+		// 反向价差
+		diff = diff.Neg()
+	}
+	// 反向合约: 张数 * 面值 * (1 / 开仓均价 - 1 / 当前价格)
+	if i.IsContract() && i.IsInverse() {
+		// 返回
+		return div(sz.Mul(i.ContractFace()).Mul(diff), avgPx.Mul(px))
+	}
+	// 币数量 * 价差
+	return i.SzToCoin(sz, px).Mul(diff)
+}
+
+// 持仓收益率 (非百分比): 正向合约按开仓均价, 反向合约按当前价格计算价差比例, 再乘杠杆倍数
+func (i *Instrument) PnlRatio(posSide string, avgPx, px, lever Decimal) Decimal {
+	// 价格无效
+	if avgPx.Sign() <= 0 || px.Sign() <= 0 {
+		// 返回空值
+		return Decimal{}
+	}
+	// 价差
+	diff := px.Sub(avgPx)
+	// 空仓
+	if posSide == "short" {
+		// 反向价差
+		diff = diff.Neg()
+	}
+	// 基准价格, 正向为开仓均价
+	base := avgPx
+	// 反向合约
+	if i.IsContract() && i.IsInverse() {
+		// 基准价格为当前价格
+		base = px
+	}
+	// 未设置杠杆
+	if lever.Sign() <= 0 {
+		// 不加杠杆
+		lever = DecimalFromInt(1)
+	}
+	// 返回
+	return div(diff.Mul(lever), base)
+}
+
+// 成交明细
+type Fill struct {
+	// 产品类型
+	InstType string `json:"instType"`
+	// 产品 ID
+	InstId string `json:"instId"`
+	// 最新成交 ID
+	TradeId string `json:"tradeId"`
+	// 订单 ID
+	OrdId string `json:"ordId"`
+	// 用户提供的订单 ID
+	ClOrdId string `json:"clOrdId"`
+	// 账单 ID
+	BillId string `json:"billId"`
+	// 订单标签
+	Tag string `json:"tag"`
+	// 最新成交价格
+	FillPx string `json:"fillPx"`
+	// 最新成交数量
+	FillSz string `json:"fillSz"`
+	// 订单方向
+	Side string `json:"side"`
+	// 持仓方向
+	PosSide string `json:"posSide"`
+	// 流动性方向
+	ExecType string `json:"execType"`
+	// 交易手续费币种
+	FeeCcy string `json:"feeCcy"`
+	// 手续费
+	Fee string `json:"fee"`
+	// 成交时间
+	Ts string `json:"ts"`
+}
+
+// 杠杆倍数
+type LeverageInfo struct {
+	// 产品 ID
+	InstId string `json:"instId"`
+	// 保证金模式
+	MgnMode string `json:"mgnMode"`
+	// 持仓方向
+	PosSide string `json:"posSide"`
+	// 杠杆倍数
+	Lever string `json:"lever"`
+}
+
+// 账户配置
+type AccountConfig struct {
+	// 账户 ID
+	Uid string `json:"uid"`
+	// 账户层级
+	AcctLv string `json:"acctLv"`
+	// 持仓模式: long_short_mode 开平仓模式, net_mode 买卖模式
+	PosMode string `json:"posMode"`
+	// 是否自动借币
+	AutoLoan bool `json:"autoLoan"`
+}
+
+// 系统时间
+type ServerTime struct {
+	// 系统时间, Unix 时间戳的毫秒数格式
+	Ts string `json:"ts"`
+}
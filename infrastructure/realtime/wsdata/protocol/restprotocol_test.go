@@ -0,0 +1,125 @@
+package protocol
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/wiger123/okex_v5_golang/utils"
+)
+
+// 测试用产品: 币币, 正向合约, 带乘数的正向合约, 反向合约
+var (
+	spotInst    = Instrument{InstId: "BTC-USDT", InstType: "SPOT"}
+	linearInst  = Instrument{InstId: "BTC-USDT-SWAP", InstType: "SWAP", CtVal: MustDecimal("0.01"), CtMult: MustDecimal("1"), CtValCcy: "BTC", CtType: "linear"}
+	multInst    = Instrument{InstId: "ETH-USDT-SWAP", InstType: "SWAP", CtVal: MustDecimal("0.1"), CtMult: MustDecimal("10"), CtValCcy: "ETH", CtType: "linear"}
+	inverseInst = Instrument{InstId: "BTC-USD-SWAP", InstType: "SWAP", CtVal: MustDecimal("100"), CtMult: MustDecimal("1"), CtValCcy: "USD", CtType: "inverse"}
+)
+
+// 比较小数, 期望为空字符串时要求空值
+func checkDecimal(t *testing.T, name string, got Decimal, want string) {
+	// 期望空值
+	if want == "" {
+		// 非空
+		if !got.IsEmpty() {
+			// 报告错误
+			t.Errorf("%v = %v, 期望空值", name, got)
+		}
+		// 返回
+		return
+	}
+	// 数值不等
+	if got.IsEmpty() || !got.Equal(MustDecimal(want)) {
+		// 报告错误
+		t.Errorf("%v = %v, 期望 %v", name, got, want)
+	}
+}
+
+func TestInstrumentSizeConversion(t *testing.T) {
+	tests := []struct {
+		name  string
+		inst  Instrument
+		sz    string
+		px    string
+		coin  string
+		quote string
+	}{
+		{name: "币币", inst: spotInst, sz: "0.5", px: "20000", coin: "0.5", quote: "10000"},
+		{name: "正向合约", inst: linearInst, sz: "3", px: "20000", coin: "0.03", quote: "600"},
+		{name: "正向合约乘数", inst: multInst, sz: "3", px: "1500", coin: "3", quote: "4500"},
+		{name: "反向合约", inst: inverseInst, sz: "3", px: "20000", coin: "0.015", quote: "300"},
+	}
+	for _, tt := range tests {
+		sz, px := MustDecimal(tt.sz), MustDecimal(tt.px)
+		checkDecimal(t, tt.name+" SzToCoin", tt.inst.SzToCoin(sz, px), tt.coin)
+		checkDecimal(t, tt.name+" CoinToSz", tt.inst.CoinToSz(MustDecimal(tt.coin), px), tt.sz)
+		checkDecimal(t, tt.name+" SzToQuote", tt.inst.SzToQuote(sz, px), tt.quote)
+		checkDecimal(t, tt.name+" QuoteToSz", tt.inst.QuoteToSz(MustDecimal(tt.quote), px), tt.sz)
+	}
+	// 反向合约币数量换算依赖价格, 价格无效时返回空值
+	checkDecimal(t, "反向合约无价格 SzToCoin", inverseInst.SzToCoin(MustDecimal("3"), Decimal{}), "")
+	checkDecimal(t, "反向合约无价格 CoinToSz", inverseInst.CoinToSz(MustDecimal("0.015"), DecimalFromInt(0)), "")
+	// 反向合约金额换算不依赖价格
+	checkDecimal(t, "反向合约无价格 QuoteToSz", inverseInst.QuoteToSz(MustDecimal("300"), Decimal{}), "3")
+	// 正向合约张数换算不依赖价格
+	checkDecimal(t, "正向合约无价格 SzToCoin", linearInst.SzToCoin(MustDecimal("3"), Decimal{}), "0.03")
+	checkDecimal(t, "正向合约无价格 QuoteToSz", linearInst.QuoteToSz(MustDecimal("600"), Decimal{}), "")
+}
+
+func TestInstrumentPnl(t *testing.T) {
+	tests := []struct {
+		name    string
+		inst    Instrument
+		posSide string
+		sz      string
+		avgPx   string
+		px      string
+		want    string
+	}{
+		{name: "币币多", inst: spotInst, posSide: "long", sz: "0.5", avgPx: "20000", px: "22000", want: "1000"},
+		{name: "正向多盈利", inst: linearInst, posSide: "long", sz: "2", avgPx: "20000", px: "22000", want: "40"},
+		{name: "正向空亏损", inst: linearInst, posSide: "short", sz: "2", avgPx: "20000", px: "22000", want: "-40"},
+		{name: "正向空盈利", inst: linearInst, posSide: "short", sz: "2", avgPx: "22000", px: "20000", want: "40"},
+		// 反向合约以币计: 2 * 100 * (1/20000 - 1/22000)
+		{name: "反向多盈利", inst: inverseInst, posSide: "long", sz: "2", avgPx: "20000", px: "22000", want: "0.000909090909"},
+		{name: "反向空亏损", inst: inverseInst, posSide: "short", sz: "2", avgPx: "20000", px: "22000", want: "-0.000909090909"},
+		// 买卖模式空仓数量为负
+		{name: "正向买卖模式空仓", inst: linearInst, posSide: "net", sz: "-2", avgPx: "20000", px: "22000", want: "-40"},
+		{name: "价格无效", inst: linearInst, posSide: "long", sz: "2", avgPx: "0", px: "22000", want: ""},
+	}
+	for _, tt := range tests {
+		got := tt.inst.Pnl(tt.posSide, MustDecimal(tt.sz), MustDecimal(tt.avgPx), MustDecimal(tt.px))
+		checkDecimal(t, tt.name+" Pnl", got, tt.want)
+	}
+}
+
+func TestInstrumentPnlRatio(t *testing.T) {
+	tests := []struct {
+		name    string
+		inst    Instrument
+		posSide string
+		avgPx   string
+		px      string
+		lever   string
+		want    string
+	}{
+		{name: "正向多", inst: linearInst, posSide: "long", avgPx: "20000", px: "22000", lever: "10", want: "1"},
+		{name: "正向空", inst: linearInst, posSide: "short", avgPx: "20000", px: "22000", lever: "10", want: "-1"},
+		{name: "正向未设置杠杆", inst: linearInst, posSide: "long", avgPx: "20000", px: "22000", lever: "0", want: "0.1"},
+		// 反向合约按当前价格计算价差比例: 2000 / 22000 * 10
+		{name: "反向多", inst: inverseInst, posSide: "long", avgPx: "20000", px: "22000", lever: "10", want: "0.909090909091"},
+		{name: "反向空", inst: inverseInst, posSide: "short", avgPx: "20000", px: "22000", lever: "10", want: "-0.909090909091"},
+		{name: "价格无效", inst: inverseInst, posSide: "long", avgPx: "20000", px: "0", lever: "10", want: ""},
+	}
+	for _, tt := range tests {
+		got := tt.inst.PnlRatio(tt.posSide, MustDecimal(tt.avgPx), MustDecimal(tt.px), MustDecimal(tt.lever))
+		checkDecimal(t, tt.name+" PnlRatio", got, tt.want)
+		if tt.want == "" || tt.lever == "0" {
+			continue
+		}
+		// 策略止盈止损使用的百分比收益率与 PnlRatio 一致
+		pr := GetProfitRatio(MustDecimal(tt.avgPx).Float64(), MustDecimal(tt.px).Float64(), MustDecimal(tt.lever).Float64(), tt.posSide, tt.inst.CtType)
+		if math.Abs(pr-got.Float64()*100) > 1e-6 {
+			t.Errorf("%v GetProfitRatio = %v, PnlRatio = %v", tt.name, pr, got)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package protocol
+
+// 单个订单操作结果: 下单, 撤单, 改单
+type OpResult struct {
+	// 用户提供的订单 ID
+	ClOrdId string `json:"clOrdId"`
+	// 订单 ID
+	OrdId string `json:"ordId"`
+	// 订单标签
+	Tag string `json:"tag"`
+	// 修改订单时使用的 request ID
+	ReqId string `json:"reqId"`
+	// 事件执行结果的 code, 0 代表成功
+	SCode string `json:"sCode"`
+	// 事件执行失败时的 msg
+	SMsg string `json:"sMsg"`
+}
+
+// 订单操作是否成功
+func (r *OpResult) Ok() bool {
+	// 返回结果
+	return r.SCode == "0"
+}
+
+// 订单操作响应
+type OpResponse struct {
+	// 消息的唯一标识, 与请求一致
+	Id string `json:"id"`
+	// 业务操作
+	Op string `json:"op"`
+	// 请求结果 code, 0 代表全部成功
+	Code string `json:"code"`
+	// 请求失败时的 msg
+	Msg string `json:"msg"`
+	// 逐个订单的操作结果
+	Data []OpResult `json:"data"`
+}